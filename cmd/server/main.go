@@ -2,106 +2,316 @@ package main
 
 import (
 	"context"
-	"log"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
+	// Recurring tasks name IANA time zones; the runtime image has no
+	// zoneinfo of its own.
+	_ "time/tzdata"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/blob"
 	"github.com/CashInvoice-Golang-Assignment/internal/config"
-	"github.com/CashInvoice-Golang-Assignment/internal/handler"
-	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/logging"
+	"github.com/CashInvoice-Golang-Assignment/internal/ratelimit"
 	"github.com/CashInvoice-Golang-Assignment/internal/repository"
-	"github.com/CashInvoice-Golang-Assignment/internal/service"
-	"github.com/CashInvoice-Golang-Assignment/internal/worker"
+	"github.com/CashInvoice-Golang-Assignment/internal/server"
+	"github.com/CashInvoice-Golang-Assignment/internal/tlsutil"
+	"github.com/CashInvoice-Golang-Assignment/internal/tracing"
 	"github.com/CashInvoice-Golang-Assignment/pkg/database"
-	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/redis/go-redis/v9"
 )
 
+// General API info for the OpenAPI spec in docs/, regenerated with
+// go generate ./docs.
+//
+//	@title						Task API
+//	@version					1.0
+//	@description				Task management service with JWT authentication and role-based access.
+//	@BasePath					/
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				"Bearer " followed by a token from /auth/login.
+
+// Usage:
+//
+//	server [--config file]              run the API server
+//	server [--config file] config print print the effective config, secrets masked
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flag.Parse()
+
+	printConfig := false
+	switch args := flag.Args(); {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "config" && args[1] == "print":
+		printConfig = true
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Keep stdout clean for config print.
+	logOut := os.Stdout
+	if printConfig {
+		logOut = os.Stderr
+	}
+	logging.Setup(logOut, os.Getenv("LOG_LEVEL"))
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		slog.Error("refusing to start", "error", err)
+		os.Exit(1)
+	}
+	if printConfig {
+		out, err := cfg.YAML()
+		if err != nil {
+			slog.Error("could not render config", "error", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	cfg := config.Load()
-	db := database.Connect(cfg)
-	database.RunMigrations(db)
-
-	taskQueue := make(chan string, 100)
-	wg := &sync.WaitGroup{}
-
-	taskRepo := repository.NewMySQLTaskRepository(db)
-	taskService := service.NewTaskService(taskRepo, taskQueue)
-	taskHandler := handler.NewTaskHandler(taskService)
-	userRepo := repository.NewMySQLUserRepository(db)
-	authService := service.NewAuthService(userRepo)
-	authHandler := handler.NewAuthHandler(
-		authService,
-		os.Getenv("JWT_SECRET"),
-		10,
+	logging.SetLevel(cfg.Log.Level)
+	logging.RegisterSecret(cfg.DB.Password)
+	logging.RegisterSecret(cfg.Auth.JWTSecret)
+	logging.RegisterSecret(cfg.Metrics.Token)
+	logging.RegisterSecret(cfg.RateLimit.RedisPassword)
+	logging.RegisterSecret(cfg.Attachments.S3.SecretAccessKey)
+
+	tlsConfig, err := tlsutil.ServerConfig(cfg.TLS)
+	if err != nil {
+		slog.Error("TLS setup failed", "error", err)
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		FilePath:    cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+
+	var (
+		db          *sql.DB
+		taskRepo    repository.TaskRepository
+		userRepo    repository.UserRepository
+		commentRepo repository.CommentRepository
+		eventRepo   repository.TaskEventRepository
+		tagRepo     repository.TagRepository
+		depRepo     repository.DependencyRepository
+		seriesRepo  repository.SeriesRepository
 	)
-	// Public
-	delay := time.Duration(cfg.AutoCompleteMinutes) * time.Minute
-	worker := worker.NewAutoCompleteWorker(taskRepo, taskQueue, delay, wg)
-	worker.Start(ctx, 4)
-
-	r := gin.Default()
-
-	// Public
-	auth := r.Group("/auth")
-	auth.POST("/login", authHandler.Login)
-	auth.POST("/register", authHandler.Register)
-
-	// Protected
-	tasks := r.Group("/tasks")
-	tasks.Use(middleware.JWTMiddleware(cfg.JWTSecret))
-	tasks.POST("", taskHandler.Create)
-	tasks.GET("", taskHandler.GetAllTask)
-	tasks.GET("/:id", taskHandler.GetByID)
-	tasks.DELETE("/:id", taskHandler.Delete)
-
-	// Admin-only group
-	admin := auth.Group("/admin")
-	admin.Use(middleware.JWTMiddleware(cfg.JWTSecret))
-	admin.Use(middleware.AdminOnly()) // we’ll create this
-	admin.POST("/register", authHandler.RegisterAdmin)
+	switch cfg.DB.Driver {
+	case "sqlite":
+		db = database.ConnectSQLite(cfg.DB.Path)
+		database.RunSQLiteMigrations(db)
+		taskRepo = repository.NewSQLiteTaskRepository(db)
+		userRepo = repository.NewSQLiteUserRepository(db)
+		commentRepo = repository.NewSQLiteCommentRepository(db)
+		eventRepo = repository.NewSQLTaskEventRepository(db)
+		tagRepo = repository.NewSQLTagRepository(db)
+		depRepo = repository.NewSQLDependencyRepository(db)
+		seriesRepo = repository.NewSQLSeriesRepository(db)
+	case "mysql":
+		db = database.Connect(cfg.DB)
+		database.RunMigrations(db)
+		taskRepo = repository.NewMySQLTaskRepository(db)
+		userRepo = repository.NewMySQLUserRepository(db)
+		commentRepo = repository.NewMySQLCommentRepository(db)
+		eventRepo = repository.NewSQLTaskEventRepository(db)
+		tagRepo = repository.NewSQLTagRepository(db)
+		depRepo = repository.NewSQLDependencyRepository(db)
+		seriesRepo = repository.NewSQLSeriesRepository(db)
+	}
+	attachmentRepo := repository.NewSQLAttachmentRepository(db)
+
+	var blobStore blob.Store
+	switch cfg.Attachments.Storage {
+	case "local":
+		blobStore, err = blob.NewFileStore(cfg.Attachments.Dir)
+	case "s3":
+		s3 := cfg.Attachments.S3
+		blobStore, err = blob.NewS3Store(blob.S3Config{
+			Endpoint:        s3.Endpoint,
+			Region:          s3.Region,
+			Bucket:          s3.Bucket,
+			AccessKeyID:     s3.AccessKeyID,
+			SecretAccessKey: s3.SecretAccessKey,
+			PathStyle:       s3.PathStyle,
+		}, nil)
+	}
+	if err != nil {
+		slog.Error("attachment storage setup failed", "error", err)
+		os.Exit(1)
+	}
+
+	var (
+		rateLimitStore ratelimit.Store
+		redisClient    *redis.Client
+	)
+	switch cfg.RateLimit.Store {
+	case "redis":
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.RateLimit.RedisAddr,
+			Password: cfg.RateLimit.RedisPassword,
+		})
+		pingCtx, pingCancel := context.WithTimeout(ctx, 3*time.Second)
+		if err := redisClient.Ping(pingCtx).Err(); err != nil {
+			// Requests are let through while Redis is down; say so loudly.
+			slog.Warn("redis unreachable, rate limits will not apply until it is", "addr", cfg.RateLimit.RedisAddr, "error", err)
+		}
+		pingCancel()
+		rateLimitStore = ratelimit.NewRedisStore(redisClient, "ratelimit:")
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+
+	app := server.New(cfg, server.Deps{
+		TaskRepo:       taskRepo,
+		UserRepo:       userRepo,
+		CommentRepo:    commentRepo,
+		EventRepo:      eventRepo,
+		TagRepo:        tagRepo,
+		DepRepo:        depRepo,
+		SeriesRepo:     seriesRepo,
+		AttachmentRepo: attachmentRepo,
+		BlobStore:      blobStore,
+		DB:             db,
+		RateLimitStore: rateLimitStore,
+	})
+	app.StartWorkers(ctx, cfg.Worker.Count)
 
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+		Addr:      cfg.Server.Addr,
+		Handler:   app.Router,
+		TLSConfig: tlsConfig,
+	}
+
+	var h3 *http3.Server
+	if cfg.TLS.HTTP3 {
+		h3 = &http3.Server{
+			Addr:      cfg.Server.Addr,
+			Handler:   app.Router,
+			TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+		}
+		srv.Handler = advertiseHTTP3(h3, app.Router)
+		go func() {
+			slog.Info("HTTP/3 server running", "addr", h3.Addr)
+			if err := h3.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("HTTP/3 listen failed", "error", err)
+				os.Exit(1)
+			}
+		}()
 	}
 
 	go func() {
-		log.Println("Server running on :8080")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+		slog.Info("server running", "addr", srv.Addr, "tls", tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			// The certificate comes from TLSConfig.GetCertificate.
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("listen failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 
+	go reloadOnSIGHUP(app, *configPath)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutdown signal received")
+	slog.Info("shutdown signal received")
+
+	// Fail readiness first so load balancers stop routing here, then give
+	// them time to notice before connections are refused.
+	app.Health.SetShuttingDown()
+	slog.Info("readiness set to failing, draining", "delay", cfg.Server.ShutdownDrainDelay)
+	time.Sleep(cfg.Server.ShutdownDrainDelay)
 
 	// Stop accepting new requests
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer shutdownCancel()
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Println("Server shutdown error:", err)
+		slog.Error("server shutdown error", "error", err)
+	}
+	if h3 != nil {
+		if err := h3.Shutdown(shutdownCtx); err != nil {
+			slog.Error("HTTP/3 server shutdown error", "error", err)
+		}
 	}
 
 	cancel()
-	close(taskQueue)
+	app.Close()
 
-	wg.Wait()
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			slog.Error("error closing redis", "error", err)
+		}
+	}
 
 	// Close DB
-	err := db.Close()
+	err = db.Close()
 	if err != nil {
-		log.Println("ERROR CLOSING DB ", err)
+		slog.Error("error closing DB", "error", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown error", "error", err)
 	}
 
-	log.Println("Graceful shutdown complete")
+	slog.Info("graceful shutdown complete")
+
+}
 
+// advertiseHTTP3 adds the Alt-Svc header that tells clients arriving over
+// TCP they can switch to HTTP/3.
+func advertiseHTTP3(h3 *http3.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h3.SetQUICHeaders(w.Header()); err != nil {
+			slog.Debug("could not set Alt-Svc header", "error", err)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reloadOnSIGHUP re-reads the config (file and environment) on every
+// SIGHUP and applies what can change at runtime. A config that fails to
+// load or validate is rejected and the running one stays in effect.
+func reloadOnSIGHUP(app *server.Server, configPath string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		slog.Info("SIGHUP received, reloading config", "file", configPath)
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			app.ReloadFailed(err)
+			continue
+		}
+		logging.RegisterSecret(cfg.DB.Password)
+		logging.RegisterSecret(cfg.Auth.JWTSecret)
+		logging.RegisterSecret(cfg.Metrics.Token)
+		logging.RegisterSecret(cfg.RateLimit.RedisPassword)
+		logging.RegisterSecret(cfg.Attachments.S3.SecretAccessKey)
+		app.Reload(cfg)
+	}
 }
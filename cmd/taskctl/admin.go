@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) adminCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Administrative commands; the profile must be logged in as an admin",
+	}
+	users := &cobra.Command{
+		Use:   "users",
+		Short: "Manage user accounts",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		Args:  cobra.NoArgs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			list, err := c.ListUsers(ctx)
+			if err != nil {
+				return err
+			}
+			return a.render(list, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "ID\tEMAIL\tROLE")
+				for _, u := range list {
+					fmt.Fprintf(w, "%s\t%s\t%s\n", u.ID, u.Email, u.Role)
+				}
+			})
+		}),
+	}
+
+	var admin, passwordStdin bool
+	add := &cobra.Command{
+		Use:   "add <email>",
+		Short: "Create a user, prompting for the password",
+		Args:  cobra.ExactArgs(1),
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			password, err := a.readPassword("Password for "+args[0]+": ", passwordStdin)
+			if err != nil {
+				return err
+			}
+			if admin {
+				return c.RegisterAdmin(ctx, args[0], password)
+			}
+			return c.Register(ctx, args[0], password)
+		}),
+	}
+	add.Flags().BoolVar(&admin, "admin", false, "create an admin account")
+	add.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+
+	rm := &cobra.Command{
+		Use:               "rm <id>...",
+		Short:             "Delete users",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeUserIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, id := range args {
+				if err := c.DeleteUser(ctx, id); err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	users.AddCommand(list, add, rm)
+	cmd.AddCommand(users)
+	return cmd
+}
+
+func (a *app) completeUserIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	c, err := a.completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	list, err := c.ListUsers(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ids := make([]string, 0, len(list))
+	for _, u := range list {
+		ids = append(ids, u.ID+"\t"+u.Email)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
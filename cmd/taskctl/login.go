@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+const defaultServer = "http://localhost:8080"
+
+func (a *app) loginCmd() *cobra.Command {
+	var server, email string
+	var passwordStdin bool
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in and save the tokens in the selected profile",
+		Long: `Log in and save the tokens in the selected profile, creating it if needed.
+The profile becomes the default for later commands. The server and email
+default to the ones the profile was last used with.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := a.currentProfile()
+			p := a.profiles.Profiles[name]
+			if p == nil {
+				p = &profile{Server: defaultServer}
+			}
+			if server != "" {
+				p.Server = server
+			}
+			if email != "" {
+				p.Email = email
+			}
+			if p.Email == "" {
+				return errors.New("--email is required")
+			}
+
+			password, err := a.readPassword("Password: ", passwordStdin)
+			if err != nil {
+				return err
+			}
+			t, err := client.New(p.Server).Login(cmd.Context(), p.Email, password)
+			if err != nil {
+				return err
+			}
+			p.setTokens(t)
+
+			a.profiles.Profiles[name] = p
+			a.profiles.Current = name
+			if err := a.profiles.save(a.configPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(a.out, "Logged in to %s as %s (%s), profile %q\n", p.Server, p.Email, t.Role, name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&server, "server", "", "API base URL (default "+defaultServer+")")
+	cmd.Flags().StringVar(&email, "email", "", "account email")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+	return cmd
+}
+
+func (a *app) logoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Forget the selected profile's tokens",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := a.currentProfile()
+			p := a.profiles.Profiles[name]
+			if p == nil {
+				return fmt.Errorf("no profile %q", name)
+			}
+			p.setTokens(client.Tokens{})
+			return a.profiles.save(a.configPath)
+		},
+	}
+}
+
+func (a *app) profilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "Manage server profiles",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			type row struct {
+				Name     string `json:"name"`
+				Server   string `json:"server"`
+				Email    string `json:"email,omitempty"`
+				Current  bool   `json:"current"`
+				LoggedIn bool   `json:"logged_in"`
+			}
+			current := a.currentProfile()
+			rows := []row{}
+			for _, name := range a.profiles.names() {
+				p := a.profiles.Profiles[name]
+				rows = append(rows, row{name, p.Server, p.Email, name == current, p.RefreshToken != ""})
+			}
+			return a.render(rows, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "\tNAME\tSERVER\tEMAIL\tLOGGED IN")
+				for _, r := range rows {
+					mark := ""
+					if r.Current {
+						mark = "*"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", mark, r.Name, r.Server, r.Email, yesNo(r.LoggedIn))
+				}
+			})
+		},
+	}
+
+	use := &cobra.Command{
+		Use:               "use <name>",
+		Short:             "Make a profile the default",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.profiles.Profiles[args[0]] == nil {
+				return fmt.Errorf("no profile %q", args[0])
+			}
+			a.profiles.Current = args[0]
+			return a.profiles.save(a.configPath)
+		},
+	}
+
+	rm := &cobra.Command{
+		Use:               "rm <name>",
+		Short:             "Delete a profile and its tokens",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.profiles.Profiles[args[0]] == nil {
+				return fmt.Errorf("no profile %q", args[0])
+			}
+			delete(a.profiles.Profiles, args[0])
+			if a.profiles.Current == args[0] {
+				a.profiles.Current = ""
+			}
+			return a.profiles.save(a.configPath)
+		},
+	}
+
+	cmd.AddCommand(list, use, rm)
+	return cmd
+}
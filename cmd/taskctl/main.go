@@ -0,0 +1,25 @@
+// Command taskctl is a command-line client for the task API.
+//
+// Usage:
+//
+//	taskctl login --server https://tasks.example.com --email alice@example.com
+//	taskctl tasks add "Write report" -d "Q3 numbers"
+//	taskctl tasks list -o yaml
+//	taskctl tasks done <id>
+//	taskctl admin users list
+//
+// Credentials are kept per profile in the user config directory
+// (~/.config/taskctl/config.yaml on Linux). Select a profile with
+// --profile or TASKCTL_PROFILE; login makes its profile the default.
+// "taskctl completion bash|zsh|fish|powershell" prints a completion script.
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	if err := newApp(os.Stdin, os.Stdout, os.Stderr).run(os.Args[1:]); err != nil {
+		os.Exit(1)
+	}
+}
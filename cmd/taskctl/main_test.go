@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/server/servertest"
+	"gopkg.in/yaml.v3"
+)
+
+// taskctl runs the CLI against configPath and returns its stdout.
+func taskctl(t *testing.T, configPath, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	a := newApp(strings.NewReader(stdin), &out, &errOut)
+	err := a.run(append([]string{"--config", configPath}, args...))
+	return out.String(), err
+}
+
+func mustTaskctl(t *testing.T, configPath, stdin string, args ...string) string {
+	t.Helper()
+	out, err := taskctl(t, configPath, stdin, args...)
+	if err != nil {
+		t.Fatalf("taskctl %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+func TestTasksCommands(t *testing.T) {
+	ts := servertest.New(t)
+	ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	cfg := filepath.Join(t.TempDir(), "taskctl", "config.yaml")
+
+	if _, err := taskctl(t, cfg, "", "tasks", "list"); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Fatalf("list before login: %v", err)
+	}
+	if _, err := taskctl(t, cfg, "wrong\n", "login", "--server", ts.URL, "--email", "alice@test.com"); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	mustTaskctl(t, cfg, "password123\n", "login", "--server", ts.URL, "--email", "alice@test.com")
+
+	info, err := os.Stat(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	var created models.Task
+	out := mustTaskctl(t, cfg, "", "tasks", "add", "write report", "-d", "Q3", "-o", "json")
+	if err := json.Unmarshal([]byte(out), &created); err != nil || created.Title != "write report" || created.Description != "Q3" {
+		t.Fatalf("tasks add = %q, %v", out, err)
+	}
+	mustTaskctl(t, cfg, "", "tasks", "add", "second", "-t", "urgent,home")
+
+	out = mustTaskctl(t, cfg, "", "tasks", "list")
+	if !strings.HasPrefix(out, "ID") || !strings.Contains(out, created.ID) || !strings.Contains(out, "second") {
+		t.Errorf("tasks list table:\n%s", out)
+	}
+
+	var listed []models.Task
+	out = mustTaskctl(t, cfg, "", "tasks", "list", "-n", "1", "-o", "yaml")
+	if err := yaml.Unmarshal([]byte(out), &listed); err != nil || len(listed) != 1 || listed[0].Title != "second" {
+		t.Fatalf("tasks list -n 1 -o yaml = %q, %v", out, err)
+	}
+	out = mustTaskctl(t, cfg, "", "tasks", "list", "--tag", "urgent", "--tag", "work", "--all")
+	if strings.Contains(out, "second") {
+		t.Errorf("tasks list --all matched a task missing a tag:\n%s", out)
+	}
+	out = mustTaskctl(t, cfg, "", "tasks", "list", "--tag", "urgent")
+	if !strings.Contains(out, "home,urgent") || strings.Contains(out, "write report") {
+		t.Errorf("tasks list --tag urgent:\n%s", out)
+	}
+
+	var sub models.Task
+	out = mustTaskctl(t, cfg, "", "tasks", "add", "appendix", "--parent", created.ID, "-o", "json")
+	if err := json.Unmarshal([]byte(out), &sub); err != nil || sub.ParentID == nil || *sub.ParentID != created.ID {
+		t.Fatalf("tasks add --parent = %q, %v", out, err)
+	}
+	out = mustTaskctl(t, cfg, "", "tasks", "subtasks", created.ID)
+	if !strings.Contains(out, sub.ID) || strings.Contains(out, "second") {
+		t.Errorf("tasks subtasks:\n%s", out)
+	}
+	mustTaskctl(t, cfg, "", "tasks", "done", sub.ID)
+	out = mustTaskctl(t, cfg, "", "tasks", "show", created.ID)
+	if !strings.Contains(out, "100%") {
+		t.Errorf("tasks show after the subtask is done:\n%s", out)
+	}
+
+	var first, then models.Task
+	json.Unmarshal([]byte(mustTaskctl(t, cfg, "", "tasks", "add", "first", "-o", "json")), &first)
+	json.Unmarshal([]byte(mustTaskctl(t, cfg, "", "tasks", "add", "then", "-o", "json")), &then)
+	mustTaskctl(t, cfg, "", "tasks", "block", then.ID, first.ID)
+	out = mustTaskctl(t, cfg, "", "tasks", "deps", then.ID)
+	if !strings.Contains(out, "blocked by") || !strings.Contains(out, first.ID) {
+		t.Errorf("tasks deps:\n%s", out)
+	}
+	if _, err := taskctl(t, cfg, "", "tasks", "done", then.ID); err == nil {
+		t.Error("tasks done on a blocked task succeeded")
+	}
+	mustTaskctl(t, cfg, "", "tasks", "unblock", then.ID, first.ID)
+	mustTaskctl(t, cfg, "", "tasks", "done", then.ID)
+
+	var standup models.Task
+	out = mustTaskctl(t, cfg, "", "tasks", "add", "standup", "--repeat", "FREQ=WEEKLY;BYDAY=MO,FR", "--tz", "Europe/Berlin", "-o", "json")
+	if err := json.Unmarshal([]byte(out), &standup); err != nil || standup.SeriesID == nil {
+		t.Errorf("tasks add --repeat = %q, %v", out, err)
+	}
+	if _, err := taskctl(t, cfg, "", "tasks", "add", "x", "--repeat", "FREQ=HOURLY"); err == nil {
+		t.Error("tasks add with an unsupported rule succeeded")
+	}
+
+	mustTaskctl(t, cfg, "", "tasks", "done", created.ID)
+	var shown models.Task
+	out = mustTaskctl(t, cfg, "", "tasks", "show", created.ID, "-o", "json")
+	if err := json.Unmarshal([]byte(out), &shown); err != nil || shown.Status != models.StatusCompleted {
+		t.Fatalf("tasks show after done = %q, %v", out, err)
+	}
+
+	mustTaskctl(t, cfg, "", "tasks", "rm", created.ID)
+	if _, err := taskctl(t, cfg, "", "tasks", "show", created.ID); err == nil {
+		t.Error("show after rm succeeded")
+	}
+	if out := mustTaskctl(t, cfg, "", "tasks", "trash"); !strings.Contains(out, created.ID) {
+		t.Errorf("tasks trash after rm:\n%s", out)
+	}
+	mustTaskctl(t, cfg, "", "tasks", "restore", created.ID)
+	mustTaskctl(t, cfg, "", "tasks", "show", created.ID)
+	out = mustTaskctl(t, cfg, "", "tasks", "history", created.ID)
+	for _, want := range []string{"created", `status: "pending" -> "completed"`, "deleted", "restored"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("tasks history lacks %q:\n%s", want, out)
+		}
+	}
+
+	ops := `[{"op": "create", "title": "imported"}, {"op": "status", "id": "` + created.ID + `", "status": "pending"}]`
+	out = mustTaskctl(t, cfg, ops, "tasks", "batch", "--atomic")
+	if !strings.Contains(out, "create  201") || !strings.Contains(out, "status  200") {
+		t.Errorf("tasks batch:\n%s", out)
+	}
+	out, err = taskctl(t, cfg, `[{"op": "delete", "id": "missing"}]`, "tasks", "batch")
+	if err == nil || !strings.Contains(out, "404") {
+		t.Errorf("tasks batch with a failing operation = %q, %v", out, err)
+	}
+
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("remember the appendix\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var attached []models.Attachment
+	out = mustTaskctl(t, cfg, "", "tasks", "attach", created.ID, notes, "-o", "json")
+	if err := json.Unmarshal([]byte(out), &attached); err != nil || len(attached) != 1 || attached[0].Name != "notes.txt" {
+		t.Fatalf("tasks attach = %q, %v", out, err)
+	}
+	if out := mustTaskctl(t, cfg, "", "tasks", "attachments", created.ID); !strings.Contains(out, attached[0].ID) || !strings.Contains(out, "notes.txt") {
+		t.Errorf("tasks attachments:\n%s", out)
+	}
+	if out := mustTaskctl(t, cfg, "", "tasks", "download", created.ID, attached[0].ID, "--to", "-"); out != "remember the appendix\n" {
+		t.Errorf("tasks download --to - = %q", out)
+	}
+	copied := filepath.Join(dir, "copy.txt")
+	mustTaskctl(t, cfg, "", "tasks", "download", created.ID, attached[0].ID, "--to", copied)
+	if data, err := os.ReadFile(copied); err != nil || string(data) != "remember the appendix\n" {
+		t.Errorf("downloaded %q, %v", data, err)
+	}
+	mustTaskctl(t, cfg, "", "tasks", "detach", created.ID, attached[0].ID)
+	if out := mustTaskctl(t, cfg, "", "tasks", "attachments", created.ID); strings.Contains(out, attached[0].ID) {
+		t.Errorf("tasks attachments after detach:\n%s", out)
+	}
+
+	if _, err := taskctl(t, cfg, "", "admin", "users", "list"); err == nil {
+		t.Error("admin users list as a regular user succeeded")
+	}
+	if _, err := taskctl(t, cfg, "", "tasks", "list", "-o", "xml"); err == nil {
+		t.Error("-o xml accepted")
+	}
+
+	mustTaskctl(t, cfg, "", "logout")
+	if _, err := taskctl(t, cfg, "", "tasks", "list"); err == nil {
+		t.Error("list after logout succeeded")
+	}
+}
+
+func TestProfilesAndAdminUsers(t *testing.T) {
+	ts := servertest.New(t)
+	ts.CreateAdmin(t, "admin@test.com", "admin-password")
+	ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+
+	mustTaskctl(t, cfg, "admin-password\n", "-p", "ops", "login", "--server", ts.URL, "--email", "admin@test.com")
+	mustTaskctl(t, cfg, "password123\n", "-p", "alice", "login", "--server", ts.URL, "--email", "alice@test.com")
+
+	// The last login is the default; -p and profiles use switch.
+	mustTaskctl(t, cfg, "", "tasks", "add", "alice's task")
+	if _, err := taskctl(t, cfg, "", "admin", "users", "list"); err == nil {
+		t.Fatal("admin users list as alice succeeded")
+	}
+	mustTaskctl(t, cfg, "", "profiles", "use", "ops")
+
+	out := mustTaskctl(t, cfg, "", "profiles", "list")
+	if !strings.Contains(out, "*  ops") {
+		t.Errorf("profiles list does not mark ops current:\n%s", out)
+	}
+
+	mustTaskctl(t, cfg, "newpass123\n", "admin", "users", "add", "bob@test.com", "--password-stdin")
+	var users []models.User
+	out = mustTaskctl(t, cfg, "", "admin", "users", "list", "-o", "json")
+	if err := json.Unmarshal([]byte(out), &users); err != nil || len(users) != 3 {
+		t.Fatalf("admin users list = %q, %v", out, err)
+	}
+	var bob models.User
+	for _, u := range users {
+		if u.Email == "bob@test.com" {
+			bob = u
+		}
+	}
+	if bob.Role != "user" {
+		t.Fatalf("bob = %+v", bob)
+	}
+	mustTaskctl(t, cfg, "", "admin", "users", "rm", bob.ID)
+	out = mustTaskctl(t, cfg, "", "admin", "users", "list")
+	if strings.Contains(out, "bob@test.com") {
+		t.Errorf("bob still listed:\n%s", out)
+	}
+
+	out = mustTaskctl(t, cfg, "", "-p", "alice", "tasks", "list")
+	if !strings.Contains(out, "alice's task") {
+		t.Errorf("-p alice tasks list:\n%s", out)
+	}
+
+	// Completion offers task IDs described by title. The shell puts
+	// __complete first.
+	var completions bytes.Buffer
+	err := newApp(strings.NewReader(""), &completions, &bytes.Buffer{}).
+		run([]string{"__complete", "--config", cfg, "-p", "alice", "tasks", "show", ""})
+	if out := completions.String(); err != nil || !strings.Contains(out, "\talice's task") {
+		t.Errorf("completion:\n%s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+func validOutput(format string) bool {
+	return slices.Contains(outputFormats, format)
+}
+
+// render writes v in the --output format. table draws the table form.
+func (a *app) render(v any, table func(w *tabwriter.Writer)) error {
+	switch a.output {
+	case "json":
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		// Go through JSON so YAML uses the API's field names and omits the
+		// same fields.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(a.out)
+		enc.SetIndent(2)
+		if err := enc.Encode(generic); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+	return strings.Join(tags, ",")
+}
+
+// formatChanges lists the fields of a task event as "field: old -> new".
+func formatChanges(changes []models.FieldChange) string {
+	if len(changes) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = fmt.Sprintf("%s: %s -> %s", c.Field, formatValue(c.Old), formatValue(c.New))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatValue formats one side of a FieldChange as decoded from JSON.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return strconv.Quote(v)
+	case []any:
+		tags := make([]string, len(v))
+		for i, tag := range v {
+			tags[i] = fmt.Sprint(tag)
+		}
+		return formatTags(tags)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
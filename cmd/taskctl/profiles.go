@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultProfile = "default"
+
+// profileFile is taskctl's config file. It holds tokens, so it is written
+// with mode 0600.
+type profileFile struct {
+	Current  string              `yaml:"current_profile,omitempty"`
+	Profiles map[string]*profile `yaml:"profiles"`
+}
+
+// profile is one server and the credentials for it.
+type profile struct {
+	Server       string    `yaml:"server"`
+	Email        string    `yaml:"email,omitempty"`
+	Role         string    `yaml:"role,omitempty"`
+	Token        string    `yaml:"token,omitempty"`
+	RefreshToken string    `yaml:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `yaml:"expires_at,omitempty"`
+}
+
+func (p *profile) tokens() client.Tokens {
+	return client.Tokens{Token: p.Token, RefreshToken: p.RefreshToken, ExpiresAt: p.ExpiresAt, Role: p.Role}
+}
+
+func (p *profile) setTokens(t client.Tokens) {
+	p.Token, p.RefreshToken, p.ExpiresAt, p.Role = t.Token, t.RefreshToken, t.ExpiresAt, t.Role
+}
+
+// defaultConfigPath is $XDG_CONFIG_HOME/taskctl/config.yaml or the
+// platform's equivalent.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".taskctl", "config.yaml")
+	}
+	return filepath.Join(dir, "taskctl", "config.yaml")
+}
+
+// loadProfiles reads path. A missing file is an empty config.
+func loadProfiles(path string) (*profileFile, error) {
+	pf := &profileFile{Profiles: map[string]*profile{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return pf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, pf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if pf.Profiles == nil {
+		pf.Profiles = map[string]*profile{}
+	}
+	return pf, nil
+}
+
+func (pf *profileFile) save(path string) error {
+	b, err := yaml.Marshal(pf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// Write and rename so a crash never leaves a truncated file behind.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// names returns the profile names in order.
+func (pf *profileFile) names() []string {
+	names := make([]string, 0, len(pf.Profiles))
+	for name := range pf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/client"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// app holds the state shared by every command.
+type app struct {
+	in          io.Reader
+	out, errOut io.Writer
+
+	configPath  string
+	profileName string
+	output      string
+
+	profiles *profileFile
+}
+
+func newApp(in io.Reader, out, errOut io.Writer) *app {
+	return &app{in: in, out: out, errOut: errOut}
+}
+
+func (a *app) run(args []string) error {
+	root := a.rootCmd()
+	root.SetArgs(args)
+	return root.Execute()
+}
+
+func (a *app) rootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "taskctl",
+		Short:        "Command-line client for the task API",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !validOutput(a.output) {
+				return fmt.Errorf("invalid --output %q: want one of %s", a.output, strings.Join(outputFormats, ", "))
+			}
+			pf, err := loadProfiles(a.configPath)
+			if err != nil {
+				return err
+			}
+			a.profiles = pf
+			return nil
+		},
+	}
+	root.SetIn(a.in)
+	root.SetOut(a.out)
+	root.SetErr(a.errOut)
+
+	configPath := os.Getenv("TASKCTL_CONFIG")
+	if configPath == "" {
+		configPath = defaultConfigPath()
+	}
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.configPath, "config", configPath, "config file (env TASKCTL_CONFIG)")
+	flags.StringVarP(&a.profileName, "profile", "p", os.Getenv("TASKCTL_PROFILE"), "profile to use (env TASKCTL_PROFILE)")
+	flags.StringVarP(&a.output, "output", "o", "table", "output format: "+strings.Join(outputFormats, ", "))
+
+	_ = root.RegisterFlagCompletionFunc("profile", a.completeProfiles)
+	_ = root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(a.loginCmd(), a.logoutCmd(), a.profilesCmd(), a.tasksCmd(), a.adminCmd())
+	return root
+}
+
+// currentProfile names the selected profile: --profile, then the one last
+// logged in to, then "default".
+func (a *app) currentProfile() string {
+	switch {
+	case a.profileName != "":
+		return a.profileName
+	case a.profiles.Current != "":
+		return a.profiles.Current
+	}
+	return defaultProfile
+}
+
+// client returns an API client for the selected profile. Refreshed tokens
+// are written back to the config file.
+func (a *app) client() (*client.Client, error) {
+	name := a.currentProfile()
+	p := a.profiles.Profiles[name]
+	if p == nil || p.RefreshToken == "" {
+		return nil, fmt.Errorf("profile %q is not logged in; run taskctl login", name)
+	}
+	return client.New(p.Server,
+		client.WithTokens(p.tokens()),
+		client.WithTokenRefreshHook(func(t client.Tokens) {
+			p.setTokens(t)
+			if err := a.profiles.save(a.configPath); err != nil {
+				fmt.Fprintf(a.errOut, "warning: saving refreshed token: %v\n", err)
+			}
+		}),
+	), nil
+}
+
+// withClient wraps a RunE that needs an API client for the selected
+// profile.
+func (a *app) withClient(fn func(ctx context.Context, c *client.Client, args []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		c, err := a.client()
+		if err != nil {
+			return err
+		}
+		return fn(cmd.Context(), c, args)
+	}
+}
+
+// completionClient is client for completion functions. The root's pre-run
+// has already happened by the time __complete parses the flags, so the
+// config is loaded again from the --config path.
+func (a *app) completionClient() (*client.Client, error) {
+	pf, err := loadProfiles(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+	a.profiles = pf
+	return a.client()
+}
+
+// readPassword prompts on the terminal without echo. When stdin is not a
+// terminal, or fromStdin is set, it reads the first line of stdin instead.
+func (a *app) readPassword(prompt string, fromStdin bool) (string, error) {
+	if f, ok := a.in.(*os.File); ok && !fromStdin && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(a.errOut, prompt)
+		b, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(a.errOut)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	line, err := bufio.NewReader(a.in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password given on stdin")
+	}
+	return password, nil
+}
+
+func (a *app) completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	pf, err := loadProfiles(a.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return pf.names(), cobra.ShellCompDirectiveNoFileComp
+}
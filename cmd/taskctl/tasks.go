@@ -0,0 +1,503 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) tasksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks",
+		Aliases: []string{"task"},
+		Short:   "List and manage tasks",
+	}
+
+	var limit int
+	var listTags []string
+	var matchAll bool
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List tasks, newest first",
+		Args:  cobra.NoArgs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			opts := client.ListOptions{Tags: listTags, MatchAll: matchAll}
+			if limit > 0 {
+				opts.Limit = limit
+			}
+			tasks := []models.Task{}
+			for t, err := range c.Tasks(ctx, opts) {
+				if err != nil {
+					return err
+				}
+				tasks = append(tasks, t)
+				if limit > 0 && len(tasks) == limit {
+					break
+				}
+			}
+			return a.renderTasks(tasks)
+		}),
+	}
+	list.Flags().IntVarP(&limit, "limit", "n", 0, "show at most this many tasks (0 for all)")
+	list.Flags().StringSliceVarP(&listTags, "tag", "t", nil, "only tasks with any of these tags")
+	list.Flags().BoolVar(&matchAll, "all", false, "with --tag, only tasks with all of the tags")
+
+	var description, parent, rrule, timezone string
+	var addTags []string
+	add := &cobra.Command{
+		Use:   "add <title>",
+		Short: "Create a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			nt := client.NewTask{Title: args[0], Description: description, Tags: addTags}
+			if parent != "" {
+				nt.ParentID = &parent
+			}
+			if rrule != "" {
+				nt.Recurrence = &client.Recurrence{RRule: rrule, Timezone: timezone}
+			}
+			t, err := c.CreateTask(ctx, nt)
+			if err != nil {
+				return err
+			}
+			return a.renderTask(t)
+		}),
+	}
+	add.Flags().StringVarP(&description, "description", "d", "", "task description")
+	add.Flags().StringSliceVarP(&addTags, "tag", "t", nil, "tag the task; repeat or separate with commas")
+	add.Flags().StringVar(&parent, "parent", "", "create the task as a subtask of this task")
+	add.Flags().StringVar(&rrule, "repeat", "", `repeat the task by an RFC 5545 rule, e.g. "FREQ=WEEKLY;BYDAY=MO"`)
+	add.Flags().StringVar(&timezone, "tz", "", "time zone of a repeating task's schedule (default UTC)")
+	add.RegisterFlagCompletionFunc("parent", a.completeTaskIDs)
+
+	subtasks := &cobra.Command{
+		Use:               "subtasks <id>",
+		Short:             "List a task's direct subtasks, newest first",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			tasks := []models.Task{}
+			opts := client.ListOptions{}
+			for {
+				page, err := c.ListSubtasks(ctx, args[0], opts)
+				if err != nil {
+					return err
+				}
+				tasks = append(tasks, page.Tasks...)
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+			return a.renderTasks(tasks)
+		}),
+	}
+
+	show := &cobra.Command{
+		Use:               "show <id>",
+		Short:             "Show a task",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			t, err := c.GetTask(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return a.renderTask(t)
+		}),
+	}
+
+	history := &cobra.Command{
+		Use:               "history <id>",
+		Short:             "Show who changed a task and how, oldest first",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			events := []models.TaskEvent{}
+			opts := client.ListOptions{}
+			for {
+				page, err := c.TaskHistory(ctx, args[0], opts)
+				if err != nil {
+					return err
+				}
+				events = append(events, page.Events...)
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+			return a.render(events, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "WHEN\tEVENT\tACTOR\tBY\tCHANGES")
+				for _, e := range events {
+					by := e.ActorID
+					if by == "" {
+						by = "-"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(e.CreatedAt), e.Type, e.Actor, by, formatChanges(e.Changes))
+				}
+			})
+		}),
+	}
+
+	done := &cobra.Command{
+		Use:               "done <id>...",
+		Short:             "Mark tasks completed",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, id := range args {
+				if _, err := c.UpdateTask(ctx, id, client.TaskUpdate{Status: client.Ptr(models.StatusCompleted)}); err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	block := &cobra.Command{
+		Use:               "block <id> <blocker-id>...",
+		Short:             "Mark a task as blocked by other tasks",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, blocker := range args[1:] {
+				if _, err := c.AddDependency(ctx, args[0], blocker); err != nil {
+					return fmt.Errorf("%s: %w", blocker, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	unblock := &cobra.Command{
+		Use:               "unblock <id> <blocker-id>...",
+		Short:             "Stop a task from being blocked by other tasks",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, blocker := range args[1:] {
+				if err := c.RemoveDependency(ctx, args[0], blocker); err != nil {
+					return fmt.Errorf("%s: %w", blocker, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	deps := &cobra.Command{
+		Use:               "deps <id>",
+		Short:             "List the tasks blocking a task and the tasks it blocks",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			deps, err := c.Dependencies(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return a.render(deps, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "RELATION\tID\tSTATUS\tTITLE")
+				for _, t := range deps.BlockedBy {
+					fmt.Fprintf(w, "blocked by\t%s\t%s\t%s\n", t.ID, t.Status, t.Title)
+				}
+				for _, t := range deps.Blocks {
+					fmt.Fprintf(w, "blocks\t%s\t%s\t%s\n", t.ID, t.Status, t.Title)
+				}
+			})
+		}),
+	}
+
+	rm := &cobra.Command{
+		Use:               "rm <id>...",
+		Short:             "Delete tasks",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, id := range args {
+				if err := c.DeleteTask(ctx, id); err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	trash := &cobra.Command{
+		Use:   "trash",
+		Short: "List deleted tasks, most recently deleted first",
+		Args:  cobra.NoArgs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			tasks := []models.Task{}
+			opts := client.ListOptions{}
+			for {
+				page, err := c.ListTrash(ctx, opts)
+				if err != nil {
+					return err
+				}
+				tasks = append(tasks, page.Tasks...)
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+			return a.render(tasks, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "ID\tSTATUS\tTITLE\tDELETED")
+				for _, t := range tasks {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.ID, t.Status, t.Title, formatTime(*t.DeletedAt))
+				}
+			})
+		}),
+	}
+
+	restore := &cobra.Command{
+		Use:   "restore <id>...",
+		Short: "Restore deleted tasks from the trash",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, id := range args {
+				if _, err := c.RestoreTask(ctx, id); err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	attach := &cobra.Command{
+		Use:               "attach <id> <file>...",
+		Short:             "Attach files to a task",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			attachments := []models.Attachment{}
+			for _, path := range args[1:] {
+				attachment, err := uploadFile(ctx, c, args[0], path)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				attachments = append(attachments, *attachment)
+			}
+			return a.renderAttachments(attachments)
+		}),
+	}
+
+	attachments := &cobra.Command{
+		Use:               "attachments <id>",
+		Short:             "List the files attached to a task, oldest first",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			attachments := []models.Attachment{}
+			opts := client.ListOptions{}
+			for {
+				page, err := c.ListAttachments(ctx, args[0], opts)
+				if err != nil {
+					return err
+				}
+				attachments = append(attachments, page.Attachments...)
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+			return a.renderAttachments(attachments)
+		}),
+	}
+
+	var to string
+	download := &cobra.Command{
+		Use:   "download <id> <attachment-id>",
+		Short: "Download a file attached to a task",
+		Long: `Download a file attached to a task into the current directory, under
+its own name. An existing file of that name is not overwritten; name
+another with --to, or - for stdout.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			content, err := c.DownloadAttachment(ctx, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			defer content.Close()
+
+			if to == "-" {
+				_, err = io.Copy(a.out, content)
+				return err
+			}
+			flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			path := to
+			if path == "" {
+				// The server keeps only the base name, but never trust it
+				// with a path.
+				path, flag = filepath.Base(content.Name), os.O_WRONLY|os.O_CREATE|os.O_EXCL
+			}
+			f, err := os.OpenFile(path, flag, 0o644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, content); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}),
+	}
+	download.Flags().StringVar(&to, "to", "", "write the file here instead, or - for stdout")
+
+	detach := &cobra.Command{
+		Use:               "detach <id> <attachment-id>...",
+		Short:             "Delete files attached to a task",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			for _, id := range args[1:] {
+				if err := c.DeleteAttachment(ctx, args[0], id); err != nil {
+					return fmt.Errorf("%s: %w", id, err)
+				}
+			}
+			return nil
+		}),
+	}
+
+	var atomic bool
+	batch := &cobra.Command{
+		Use:   "batch [file]",
+		Short: "Run the task operations in a JSON file, or stdin",
+		Long: `Run a JSON array of operations such as
+  {"op": "create", "title": "Write report", "tags": ["q3"]}
+  {"op": "update", "id": "<id>", "title": "New title"}
+  {"op": "status", "id": "<id>", "status": "completed"}
+  {"op": "delete", "id": "<id>"}
+They are sent in batches of 100. With --atomic they all succeed or none
+does, so there may only be 100 of them.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: a.withClient(func(ctx context.Context, c *client.Client, args []string) error {
+			in := a.in
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			var ops []client.BatchOperation
+			if err := json.NewDecoder(in).Decode(&ops); err != nil {
+				return fmt.Errorf("reading operations: %w", err)
+			}
+			mode := client.BatchBestEffort
+			if atomic {
+				if len(ops) > client.MaxBatchSize {
+					return fmt.Errorf("an atomic batch takes at most %d operations, got %d", client.MaxBatchSize, len(ops))
+				}
+				mode = client.BatchAtomic
+			}
+
+			all := client.BatchResponse{Results: []client.BatchResult{}}
+			for start := 0; start < len(ops); start += client.MaxBatchSize {
+				resp, err := c.Batch(ctx, mode, ops[start:min(start+client.MaxBatchSize, len(ops))])
+				if err != nil {
+					return err
+				}
+				for _, r := range resp.Results {
+					r.Index += start
+					all.Results = append(all.Results, r)
+				}
+				all.Succeeded += resp.Succeeded
+				all.Failed += resp.Failed
+			}
+			err := a.render(all, func(w *tabwriter.Writer) {
+				fmt.Fprintln(w, "#\tOP\tSTATUS\tID\tRESULT")
+				for _, r := range all.Results {
+					id, result := ops[r.Index].ID, "ok"
+					if r.Task != nil {
+						id = r.Task.ID
+					}
+					if r.Error != nil {
+						result = r.Error.Detail
+					}
+					fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", r.Index, r.Op, r.Status, id, result)
+				}
+			})
+			if err == nil && all.Failed > 0 {
+				err = fmt.Errorf("%d of %d operations failed", all.Failed, len(ops))
+			}
+			return err
+		}),
+	}
+	batch.Flags().BoolVar(&atomic, "atomic", false, "apply all operations or none")
+
+	cmd.AddCommand(list, add, show, history, subtasks, deps, block, unblock, done, rm, trash, restore, batch,
+		attach, attachments, download, detach)
+	return cmd
+}
+
+func (a *app) renderTasks(tasks []models.Task) error {
+	return a.render(tasks, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ID\tSTATUS\tTITLE\tTAGS\tCREATED")
+		for _, t := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, t.Status, t.Title, formatTags(t.Tags), formatTime(t.CreatedAt))
+		}
+	})
+}
+
+func (a *app) renderAttachments(attachments []models.Attachment) error {
+	return a.render(attachments, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tTYPE\tSIZE\tCREATED")
+		for _, at := range attachments {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", at.ID, at.Name, at.ContentType, at.Size, formatTime(at.CreatedAt))
+		}
+	})
+}
+
+// uploadFile attaches the file at path to a task under its base name.
+func uploadFile(ctx context.Context, c *client.Client, taskID, path string) (*models.Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return c.UploadAttachment(ctx, taskID, filepath.Base(path), f)
+}
+
+func (a *app) renderTask(t *models.Task) error {
+	return a.render(t, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "ID:\t%s\n", t.ID)
+		fmt.Fprintf(w, "Title:\t%s\n", t.Title)
+		fmt.Fprintf(w, "Description:\t%s\n", t.Description)
+		fmt.Fprintf(w, "Status:\t%s\n", t.Status)
+		fmt.Fprintf(w, "Tags:\t%s\n", formatTags(t.Tags))
+		if t.ParentID != nil {
+			fmt.Fprintf(w, "Parent:\t%s\n", *t.ParentID)
+		}
+		if t.Progress != nil {
+			fmt.Fprintf(w, "Progress:\t%d%%\n", *t.Progress)
+		}
+		fmt.Fprintf(w, "Created:\t%s\n", formatTime(t.CreatedAt))
+		fmt.Fprintf(w, "Updated:\t%s\n", formatTime(t.UpdatedAt))
+	})
+}
+
+// completeTaskIDs offers the IDs of the newest tasks, described by title.
+func (a *app) completeTaskIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	c, err := a.completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	page, err := c.ListTasks(cmd.Context(), client.ListOptions{Limit: 100})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ids := make([]string, 0, len(page.Tasks))
+	for _, t := range page.Tasks {
+		ids = append(ids, t.ID+"\t"+t.Title)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,79 @@
+// Command convert turns the Swagger 2.0 spec written by swag into OpenAPI 3,
+// validates it and removes the input. It is run by go generate in docs.
+//
+// Usage:
+//
+//	convert swagger.json openapi.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: convert <swagger.json> <openapi.json>")
+		os.Exit(2)
+	}
+	if err := convert(os.Args[1], os.Args[2]); err != nil {
+		fmt.Fprintln(os.Stderr, "convert:", err)
+		os.Exit(1)
+	}
+}
+
+func convert(in, out string) error {
+	raw, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	var doc2 openapi2.T
+	if err := json.Unmarshal(raw, &doc2); err != nil {
+		return fmt.Errorf("parse %s: %w", in, err)
+	}
+
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return fmt.Errorf("convert: %w", err)
+	}
+	problemContent(doc3)
+	if err := doc3.Validate(context.Background()); err != nil {
+		return fmt.Errorf("invalid OpenAPI 3 spec: %w", err)
+	}
+
+	b, err := json.MarshalIndent(doc3, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Remove(in)
+}
+
+// problemContent lists error responses under application/problem+json,
+// which swag has no way to express.
+func problemContent(doc *openapi3.T) {
+	const problemRef = "#/components/schemas/apperr.Problem"
+	for _, item := range doc.Paths.Map() {
+		for _, op := range item.Operations() {
+			for _, resp := range op.Responses.Map() {
+				if resp.Value == nil {
+					continue
+				}
+				mt := resp.Value.Content.Get("application/json")
+				if mt == nil || mt.Schema == nil || mt.Schema.Ref != problemRef {
+					continue
+				}
+				resp.Value.Content = openapi3.Content{apperr.ProblemContentType: mt}
+			}
+		}
+	}
+}
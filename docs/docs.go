@@ -0,0 +1,19 @@
+// Package docs holds the OpenAPI 3 spec of the HTTP API, generated from the
+// swag annotations on the handlers. Regenerate it after changing a route or
+// an annotation:
+//
+//	go generate ./docs
+//
+// swag only emits Swagger 2.0, so convert turns its output into OpenAPI 3
+// and removes the intermediate file.
+package docs
+
+import _ "embed"
+
+//go:generate go tool swag init --quiet --dir ../ --generalInfo cmd/server/main.go --parseInternal --outputTypes json --output .
+//go:generate go run ./convert swagger.json openapi.json
+
+// OpenAPI is the generated spec, served at /docs/openapi.json.
+//
+//go:embed openapi.json
+var OpenAPI []byte
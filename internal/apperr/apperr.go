@@ -0,0 +1,56 @@
+// Package apperr defines the domain errors repositories and services
+// return. Callers test them with errors.Is; the HTTP layer maps each one to
+// a status and a stable error code. The errors and problem types are those
+// of pkg/api, which clients see.
+package apperr
+
+import (
+	"strings"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+var (
+	ErrNotFound     = api.ErrNotFound
+	ErrForbidden    = api.ErrForbidden
+	ErrUnauthorized = api.ErrUnauthorized
+	ErrConflict     = api.ErrConflict
+	// ErrValidation is usually wrapped in a *ValidationError carrying
+	// per-field details.
+	ErrValidation           = api.ErrValidation
+	ErrPreconditionFailed   = api.ErrPreconditionFailed
+	ErrFailedDependency     = api.ErrFailedDependency
+	ErrTooLarge             = api.ErrTooLarge
+	ErrUnsupportedMediaType = api.ErrUnsupportedMediaType
+	ErrQuotaExceeded        = api.ErrQuotaExceeded
+	ErrRateLimited          = api.ErrRateLimited
+)
+
+type FieldError = api.FieldError
+
+// ValidationError lists every invalid field of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Validation returns a ValidationError for the given fields.
+func Validation(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return ErrValidation.Error()
+	}
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrValidation }
+
+const ProblemContentType = api.ProblemContentType
+
+type Problem = api.Problem
@@ -0,0 +1,32 @@
+// Package blob stores the content of files, such as task attachments,
+// apart from the database. FileStore keeps them on the local filesystem
+// and S3Store in an S3-compatible bucket.
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Store keeps blobs under keys the caller makes up. Keys use "/" as a
+// separator and must be relative paths without "." or ".." elements.
+type Store interface {
+	// Put stores the size bytes read from r under key, replacing any blob
+	// already there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob at key, which the caller closes. A missing blob
+	// is apperr.ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob at key. A missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey rejects keys that could reach outside the store.
+func checkKey(key string) error {
+	if key == "." || !fs.ValidPath(key) {
+		return fmt.Errorf("blob: invalid key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+)
+
+// FileStore keeps blobs as files under a directory, one per key.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a store rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("blob: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Compile-time check
+var _ Store = (*FileStore)(nil)
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the blob to a temporary file next to its final path and
+// renames it into place, so a failed or concurrent Put never leaves a
+// partial blob behind.
+func (s *FileStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	// A Delete emptying the directory may remove it between MkdirAll and
+	// CreateTemp; one retry gets past that.
+	var f *os.File
+	for range 2 {
+		if err = os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			return fmt.Errorf("blob: %w", err)
+		}
+		if f, err = os.CreateTemp(filepath.Dir(p), ".put-*"); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("blob: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	n, err := io.Copy(f, contextReader{ctx, r})
+	if err == nil && n != size {
+		err = fmt.Errorf("blob: wrote %d bytes, expected %d", n, size)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (s *FileStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("blob %w", apperr.ErrNotFound)
+	}
+	return f, err
+}
+
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	// Drop the key's directories once they are empty; Remove fails on
+	// ones that are not, which ends the walk.
+	for dir := filepath.Dir(p); dir != filepath.Clean(s.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// contextReader stops reading once ctx is done, so a cancelled request
+// does not go on writing a large blob.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,273 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+)
+
+// S3Config says where an S3Store keeps its blobs.
+type S3Config struct {
+	// Endpoint is the base URL of the service, such as
+	// https://s3.eu-west-1.amazonaws.com, or http://localhost:9000 for a
+	// local MinIO.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket as Endpoint/Bucket rather than as the
+	// subdomain Bucket.Endpoint. Most S3-compatible servers need it.
+	PathStyle bool
+}
+
+// S3Store keeps blobs as objects in a bucket of Amazon S3 or any service
+// with the same API, such as MinIO. Requests are signed with AWS Signature
+// Version 4. Uploads are not signed over their content, which is checked
+// by the caller instead, so they can be streamed.
+type S3Store struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	// now is the clock requests are signed with.
+	now func() time.Time
+}
+
+// NewS3Store returns a store for the bucket in cfg. hc may be nil for a
+// default client; bodies can be large, so calls are bounded by their
+// context rather than a client timeout.
+func NewS3Store(cfg S3Config, hc *http.Client) (*S3Store, error) {
+	u, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("blob: invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if hc == nil {
+		hc = &http.Client{}
+	}
+	return &S3Store{cfg: cfg, endpoint: u, client: hc, now: time.Now}, nil
+}
+
+// Compile-time check
+var _ Store = (*S3Store)(nil)
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	body := io.NopCloser(r)
+	if size == 0 {
+		body = http.NoBody
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, body, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp, key)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("blob %w", apperr.ErrNotFound)
+	}
+	defer resp.Body.Close()
+	return nil, s3Error(resp, key)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return s3Error(resp, key)
+}
+
+// do sends a signed request for the object at key.
+func (s *S3Store) do(ctx context.Context, method, key string, body io.ReadCloser, size int64, contentType string) (*http.Response, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("blob: %w", err)
+	}
+	payloadHash := emptySHA256
+	if body != nil {
+		req.Body, req.ContentLength = body, size
+		payloadHash = unsignedPayload
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, payloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("blob: s3 %s %s: %w", method, key, err)
+	}
+	return resp, nil
+}
+
+func (s *S3Store) objectURL(key string) (*url.URL, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	u := *s.endpoint
+	object := "/" + key
+	if s.cfg.PathStyle {
+		object = "/" + s.cfg.Bucket + object
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	u.Path += object
+	u.RawPath = s.endpoint.EscapedPath() + uriEncode(object, false)
+	return &u, nil
+}
+
+// s3Error reads the XML error document of a failed request.
+func s3Error(resp *http.Response, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var doc struct {
+		Code    string
+		Message string
+	}
+	if xml.Unmarshal(body, &doc) != nil || doc.Code == "" {
+		doc.Code, doc.Message = resp.Status, strings.TrimSpace(string(body))
+	}
+	return fmt.Errorf("blob: s3 %s %s: %s: %s", resp.Request.Method, key, doc.Code, doc.Message)
+}
+
+const (
+	// emptySHA256 is the payload hash of a request without a body.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// unsignedPayload leaves the body out of the signature.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sign adds the Signature Version 4 headers to req. The host and the
+// Content-Type, Range and X-Amz-* headers are signed.
+func (s *S3Store) sign(req *http.Request, payloadHash string) {
+	amzDate := s.now().UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	fmt.Fprintf(&canonical, "%s\n%s\n%s\n", req.Method, canonicalPath(req.URL), canonicalQuery(req.URL))
+	for _, name := range names {
+		fmt.Fprintf(&canonical, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+	fmt.Fprintf(&canonical, "\n%s\n%s", signedHeaders, payloadHash)
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonical.String())
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalPath(u *url.URL) string {
+	if p := u.EscapedPath(); p != "" {
+		return p
+	}
+	return "/"
+}
+
+func canonicalQuery(u *url.URL) string {
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := q[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes s the way Signature Version 4 expects: everything but
+// unreserved characters, and "/" too when encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,17 @@
+package clock
+
+import "time"
+
+// Clock abstracts time so the auto-complete worker can be driven
+// deterministically in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time { return time.Now() }
+
+func (Real) After(d time.Duration) <-chan time.Time { return time.After(d) }
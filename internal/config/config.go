@@ -1,45 +1,357 @@
+// Package config loads the server configuration in layers: built-in
+// defaults, then an optional YAML or TOML file, then environment variables.
+// The result is validated before the server starts.
 package config
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	DBHost string
-	DBPort string
-	DBUser string
-	DBPass string
-	DBName string
+	Server      ServerConfig      `yaml:"server"`
+	TLS         TLSConfig         `yaml:"tls"`
+	DB          DBConfig          `yaml:"db"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Worker      WorkerConfig      `yaml:"worker"`
+	Tasks       TasksConfig       `yaml:"tasks"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
+	Log         LogConfig         `yaml:"log"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Health      HealthConfig      `yaml:"health"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Docs        DocsConfig        `yaml:"docs"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+	// ShutdownDrainDelay is how long /readyz reports failure before the
+	// HTTP server stops accepting connections; ShutdownTimeout bounds the
+	// wait for in-flight requests after that.
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout"`
+	// TrustedProxies lists proxy addresses/CIDRs whose X-Forwarded-For is
+	// believed when working out the client IP. Empty trusts none.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// TLSConfig turns on HTTPS when CertFile and KeyFile are set. The files
+// are re-read when they change, so certificates can be rotated in place.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// MinVersion is "1.2" or "1.3".
+	MinVersion string `yaml:"min_version"`
+
+	// ClientAuth is none, optional or require. Client certificates are
+	// verified against ClientCAFile, and ClientIdentities maps verified
+	// certificates to users.
+	ClientAuth       string           `yaml:"client_auth"`
+	ClientCAFile     string           `yaml:"client_ca_file"`
+	ClientIdentities []ClientIdentity `yaml:"client_identities"`
+
+	// HTTP3 also serves HTTP/3 over QUIC on the same port (UDP).
+	HTTP3 bool `yaml:"http3"`
+}
+
+// Enabled reports whether the server should serve HTTPS.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// ClientIdentity maps a client certificate, matched by its Subject
+// (the common name, or a DNS or URI SAN), to the user and role requests
+// made with it act as.
+type ClientIdentity struct {
+	Subject string `yaml:"subject"`
+	UserID  string `yaml:"user_id"`
+	Role    string `yaml:"role"`
+}
+
+type DBConfig struct {
+	// Driver selects the storage backend: "mysql" or "sqlite".
+	Driver string `yaml:"driver"`
+	// Path is the SQLite database file, used when Driver is "sqlite".
+	Path string `yaml:"path"`
+
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
 
-	JWTSecret           string
-	AutoCompleteMinutes int
+	// ReadTimeout and WriteTimeout bound every query the services and the
+	// worker issue, on top of the request context.
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
 }
 
-func Load() *Config {
+type AuthConfig struct {
+	JWTSecret   string        `yaml:"jwt_secret"`
+	TokenExpiry time.Duration `yaml:"token_expiry"`
+	// RefreshExpiry is the lifetime of refresh tokens, which
+	// POST /auth/refresh exchanges for a new token pair.
+	RefreshExpiry time.Duration `yaml:"refresh_expiry"`
+	// SessionExpiry caps how long refreshing can extend a login: no
+	// refresh token outlives the login it continues by more than this.
+	SessionExpiry time.Duration `yaml:"session_expiry"`
+}
+
+type WorkerConfig struct {
+	AutoCompleteMinutes int `yaml:"auto_complete_minutes"`
+	Count               int `yaml:"count"`
+	QueueSize           int `yaml:"queue_size"`
+}
+
+// TasksConfig holds the rules for subtasks and recurring tasks.
+type TasksConfig struct {
+	// MaxDepth is the number of levels a task tree may have, counting the
+	// top-level task. 1 turns subtasks off.
+	MaxDepth int `yaml:"max_depth"`
+	// AutoCompleteParent completes a parent once all its subtasks are
+	// completed.
+	AutoCompleteParent bool `yaml:"auto_complete_parent"`
+	// CompleteWithOpenSubtasks lets a task be completed while some of its
+	// subtasks are not.
+	CompleteWithOpenSubtasks bool `yaml:"complete_with_open_subtasks"`
+	// OnParentDelete is cascade (delete the subtasks too), promote (move
+	// them up to the deleted task's parent) or restrict (refuse while the
+	// task has subtasks).
+	OnParentDelete string `yaml:"on_parent_delete"`
+	// RecurrenceInterval is how often the generator looks for recurring
+	// tasks whose next occurrence is due.
+	RecurrenceInterval time.Duration `yaml:"recurrence_interval"`
+	// TrashRetentionDays is how long deleted tasks stay in the trash, and
+	// can be restored, before they are purged for good.
+	TrashRetentionDays int `yaml:"trash_retention_days"`
+}
+
+// AttachmentsConfig limits the files attached to tasks and says where
+// their content is stored.
+type AttachmentsConfig struct {
+	// MaxSize is the largest file accepted, in bytes.
+	MaxSize int64 `yaml:"max_size"`
+	// AllowedTypes lists the media types accepted, as detected from the
+	// content. "image/*" accepts every image type.
+	AllowedTypes []string `yaml:"allowed_types"`
+	// UserQuota caps the total size of the files on one user's tasks, in
+	// bytes. 0 means no quota.
+	UserQuota int64 `yaml:"user_quota"`
+	// Storage is local (files under Dir) or s3 (objects in an
+	// S3-compatible bucket).
+	Storage string   `yaml:"storage"`
+	Dir     string   `yaml:"dir"`
+	S3      S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	// Endpoint is the base URL of the service, e.g.
+	// https://s3.eu-west-1.amazonaws.com or http://localhost:9000.
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// PathStyle addresses the bucket as endpoint/bucket rather than
+	// bucket.endpoint, as most S3-compatible servers need.
+	PathStyle bool `yaml:"path_style"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn or error.
+	Level string `yaml:"level"`
+}
+
+type MetricsConfig struct {
+	// Enabled mounts /metrics. Scrapes are accepted from AllowedCIDRs or
+	// with Token as a bearer token.
+	Enabled      bool     `yaml:"enabled"`
+	AllowedCIDRs []string `yaml:"allowed_cidrs"`
+	Token        string   `yaml:"token"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, otlp, stdout or file. File is the output path of
+	// the file exporter.
+	Exporter    string  `yaml:"exporter"`
+	File        string  `yaml:"file"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	DBMaxLatency       time.Duration `yaml:"db_max_latency"`
+	QueueMaxSaturation float64       `yaml:"queue_max_saturation"`
+}
+
+// RateLimitConfig holds token-bucket limits per route group. Store is
+// memory or redis; with redis all replicas share the same buckets.
+type RateLimitConfig struct {
+	Enabled       bool            `yaml:"enabled"`
+	Store         string          `yaml:"store"`
+	Auth          RateLimitPolicy `yaml:"auth"`
+	Tasks         RateLimitPolicy `yaml:"tasks"`
+	Batch         RateLimitPolicy `yaml:"batch"`
+	RedisAddr     string          `yaml:"redis_addr"`
+	RedisPassword string          `yaml:"redis_password"`
+}
+
+type RateLimitPolicy struct {
+	PerMinute int `yaml:"per_minute"`
+	Burst     int `yaml:"burst"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins lists browser origins (scheme://host[:port]) allowed
+	// to call the API; "*" allows any. Empty disables CORS.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type DocsConfig struct {
+	// Enabled serves Swagger UI at /docs and the OpenAPI spec at
+	// /docs/openapi.json.
+	Enabled bool `yaml:"enabled"`
+}
+
+// Default returns the built-in defaults every other layer overrides.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:               ":8080",
+			ShutdownDrainDelay: 5 * time.Second,
+			ShutdownTimeout:    10 * time.Second,
+		},
+		TLS: TLSConfig{
+			MinVersion: "1.2",
+			ClientAuth: "none",
+		},
+		DB: DBConfig{
+			Driver:          "mysql",
+			Path:            "data/tasks.db",
+			Port:            "3306",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    5 * time.Second,
+		},
+		Auth: AuthConfig{
+			TokenExpiry:   10 * time.Minute,
+			RefreshExpiry: 7 * 24 * time.Hour,
+			SessionExpiry: 30 * 24 * time.Hour,
+		},
+		Worker: WorkerConfig{
+			AutoCompleteMinutes: 5,
+			Count:               4,
+			QueueSize:           100,
+		},
+		Tasks: TasksConfig{
+			MaxDepth:           3,
+			AutoCompleteParent: true,
+			OnParentDelete:     "cascade",
+			RecurrenceInterval: time.Minute,
+			TrashRetentionDays: 30,
+		},
+		Attachments: AttachmentsConfig{
+			MaxSize:      10 << 20,
+			AllowedTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"},
+			UserQuota:    100 << 20,
+			Storage:      "local",
+			Dir:          "data/attachments",
+			S3:           S3Config{Region: "us-east-1", PathStyle: true},
+		},
+		Log: LogConfig{Level: "info"},
+		Metrics: MetricsConfig{
+			Enabled:      true,
+			AllowedCIDRs: []string{"127.0.0.1/32", "::1/128"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			DBMaxLatency:       500 * time.Millisecond,
+			QueueMaxSaturation: 0.9,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			Store:     "memory",
+			Auth:      RateLimitPolicy{PerMinute: 10, Burst: 5},
+			Tasks:     RateLimitPolicy{PerMinute: 120, Burst: 30},
+			Batch:     RateLimitPolicy{PerMinute: 2, Burst: 2},
+			RedisAddr: "localhost:6379",
+		},
+	}
+}
+
+// Load builds the effective config from defaults, the file at path (if
+// path is non-empty) and the environment, then validates it. Outside
+// production a .env file is loaded into the environment first.
+func Load(path string) (*Config, error) {
 	if os.Getenv("ENV") != "production" {
 		if err := godotenv.Load(); err != nil {
-			log.Println("No .env file found, using system environment variables")
+			slog.Info("no .env file found, using system environment variables")
 		}
 	}
-	minutes, err := strconv.Atoi(os.Getenv("AUTO_COMPLETE_MINUTES"))
-	if err != nil || minutes <= 0 {
-		log.Println("AUTO_COMPLETE_MINUTES not set or invalid, defaulting to 5")
-		minutes = 5
+
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the YAML (.yaml, .yml) or TOML (.toml) file on cfg.
+// Keys missing from the file keep their current values; unknown keys are
+// rejected so typos do not go unnoticed.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
 	}
 
-	cfg := &Config{
-		DBHost: os.Getenv("DB_HOST"),
-		DBPort: os.Getenv("DB_PORT"),
-		DBUser: os.Getenv("DB_USER"),
-		DBPass: os.Getenv("DB_PASSWORD"),
-		DBName: os.Getenv("DB_NAME"),
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		// TOML has no duration type, so decode generically and go through
+		// the YAML decoder, which parses "5s" style durations.
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(raw); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension (use .yaml, .yml or .toml)", path)
+	}
 
-		JWTSecret:           os.Getenv("JWT_SECRET"),
-		AutoCompleteMinutes: minutes,
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
 	}
-	return cfg
+	return nil
 }
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayering(t *testing.T) {
+	t.Setenv("ENV", "production")
+	yamlFile := writeFile(t, "config.yaml", `
+server:
+  addr: ":9090"
+db:
+  driver: sqlite
+  path: /tmp/tasks.db
+  read_timeout: 2s
+auth:
+  jwt_secret: `+testSecret+`
+worker:
+  count: 8
+`)
+	tomlFile := writeFile(t, "config.toml", `
+[server]
+addr = ":9090"
+
+[db]
+driver = "sqlite"
+path = "/tmp/tasks.db"
+read_timeout = "2s"
+
+[auth]
+jwt_secret = "`+testSecret+`"
+
+[worker]
+count = 8
+`)
+
+	for _, path := range []string{yamlFile, tomlFile} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			// Env beats the file, which beats the defaults.
+			t.Setenv("WORKER_COUNT", "2")
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Addr != ":9090" {
+				t.Errorf("addr = %q, want :9090 from file", cfg.Server.Addr)
+			}
+			if cfg.DB.ReadTimeout != 2*time.Second {
+				t.Errorf("read timeout = %v, want 2s from file", cfg.DB.ReadTimeout)
+			}
+			if cfg.Worker.Count != 2 {
+				t.Errorf("worker count = %d, want 2 from env", cfg.Worker.Count)
+			}
+			if cfg.Worker.QueueSize != Default().Worker.QueueSize {
+				t.Errorf("queue size = %d, want default", cfg.Worker.QueueSize)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsBadInput(t *testing.T) {
+	t.Setenv("ENV", "production")
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "server:\n  adress: \":9090\"\n")
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "adress") {
+			t.Fatalf("err = %v, want unknown field error", err)
+		}
+	})
+
+	t.Run("malformed env", func(t *testing.T) {
+		t.Setenv("DB_READ_TIMEOUT", "soon")
+		if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "DB_READ_TIMEOUT") {
+			t.Fatalf("err = %v, want DB_READ_TIMEOUT error", err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWTSecret = "short"
+	cfg.Log.Level = "loud"
+	cfg.Tasks.OnParentDelete = "orphan"
+	cfg.Tasks.RecurrenceInterval = 0
+	cfg.Tasks.TrashRetentionDays = 0
+	cfg.Attachments.AllowedTypes = []string{"image/png", "png"}
+	cfg.Attachments.Storage = "s3"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"db.host is required",
+		"db.name is required",
+		"auth.jwt_secret must be at least 32 characters",
+		"log.level must be",
+		"tasks.on_parent_delete must be",
+		"tasks.recurrence_interval must be at least 1s",
+		"tasks.trash_retention_days must be at least 1",
+		`attachments.allowed_types: "png" is not a media type`,
+		"attachments.s3.endpoint must be a URL",
+		"attachments.s3.bucket is required for s3 storage (S3_BUCKET)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+
+	cfg = Default()
+	cfg.DB.Driver = "sqlite"
+	cfg.Auth.JWTSecret = "change-me-to-a-random-32-plus-char-secret"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "still the placeholder") {
+		t.Errorf("placeholder secret: err = %v", err)
+	}
+
+	cfg.Auth.JWTSecret = testSecret
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+}
+
+func TestYAMLMasksSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWTSecret = testSecret
+	cfg.DB.Password = "hunter22"
+	cfg.Attachments.S3.SecretAccessKey = "s3-secret"
+
+	out, err := cfg.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), testSecret) || strings.Contains(string(out), "hunter22") || strings.Contains(string(out), "s3-secret") {
+		t.Fatalf("secret leaked:\n%s", out)
+	}
+	if !strings.Contains(string(out), "read_timeout: 5s") {
+		t.Errorf("durations should print in file form:\n%s", out)
+	}
+	if cfg.Auth.JWTSecret != testSecret {
+		t.Error("YAML modified the original config")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	old := Default()
+	next := Default()
+	next.Log.Level = "debug"
+	next.RateLimit.Tasks.Burst = 99
+	next.DB.MaxOpenConns = 5
+	next.Server.TrustedProxies = []string{} // same as nil
+
+	reloadable, restart := Changes(old, next)
+	if strings.Join(reloadable, ",") != "log.level,rate_limit.tasks.burst" {
+		t.Errorf("reloadable = %v", reloadable)
+	}
+	if strings.Join(restart, ",") != "db.max_open_conns" {
+		t.Errorf("restart = %v", restart)
+	}
+}
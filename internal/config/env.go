@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envVar binds one environment variable to a config field. The variable
+// names predate the config file and are kept so existing deployments and
+// .env files keep working.
+type envVar struct {
+	name string
+	set  func(c *Config, v string) error
+}
+
+var envVars = []envVar{
+	{"SERVER_ADDR", str(func(c *Config) *string { return &c.Server.Addr })},
+	{"SHUTDOWN_DRAIN_DELAY", dur(func(c *Config) *time.Duration { return &c.Server.ShutdownDrainDelay })},
+	{"SHUTDOWN_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"TRUSTED_PROXIES", list(func(c *Config) *[]string { return &c.Server.TrustedProxies })},
+
+	{"TLS_CERT_FILE", str(func(c *Config) *string { return &c.TLS.CertFile })},
+	{"TLS_KEY_FILE", str(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{"TLS_MIN_VERSION", str(func(c *Config) *string { return &c.TLS.MinVersion })},
+	{"TLS_CLIENT_AUTH", str(func(c *Config) *string { return &c.TLS.ClientAuth })},
+	{"TLS_CLIENT_CA_FILE", str(func(c *Config) *string { return &c.TLS.ClientCAFile })},
+	{"HTTP3_ENABLED", boolean(func(c *Config) *bool { return &c.TLS.HTTP3 })},
+
+	{"DB_DRIVER", str(func(c *Config) *string { return &c.DB.Driver })},
+	{"DB_PATH", str(func(c *Config) *string { return &c.DB.Path })},
+	{"DB_HOST", str(func(c *Config) *string { return &c.DB.Host })},
+	{"DB_PORT", str(func(c *Config) *string { return &c.DB.Port })},
+	{"DB_USER", str(func(c *Config) *string { return &c.DB.User })},
+	{"DB_PASSWORD", str(func(c *Config) *string { return &c.DB.Password })},
+	{"DB_NAME", str(func(c *Config) *string { return &c.DB.Name })},
+	{"DB_MAX_OPEN_CONNS", integer(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", integer(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", dur(func(c *Config) *time.Duration { return &c.DB.ConnMaxLifetime })},
+	{"DB_READ_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.DB.ReadTimeout })},
+	{"DB_WRITE_TIMEOUT", dur(func(c *Config) *time.Duration { return &c.DB.WriteTimeout })},
+
+	{"JWT_SECRET", str(func(c *Config) *string { return &c.Auth.JWTSecret })},
+	{"JWT_EXPIRY", dur(func(c *Config) *time.Duration { return &c.Auth.TokenExpiry })},
+	{"JWT_REFRESH_EXPIRY", dur(func(c *Config) *time.Duration { return &c.Auth.RefreshExpiry })},
+	{"JWT_SESSION_EXPIRY", dur(func(c *Config) *time.Duration { return &c.Auth.SessionExpiry })},
+
+	{"AUTO_COMPLETE_MINUTES", integer(func(c *Config) *int { return &c.Worker.AutoCompleteMinutes })},
+	{"WORKER_COUNT", integer(func(c *Config) *int { return &c.Worker.Count })},
+	{"WORKER_QUEUE_SIZE", integer(func(c *Config) *int { return &c.Worker.QueueSize })},
+
+	{"TASK_MAX_DEPTH", integer(func(c *Config) *int { return &c.Tasks.MaxDepth })},
+	{"TASK_AUTO_COMPLETE_PARENT", boolean(func(c *Config) *bool { return &c.Tasks.AutoCompleteParent })},
+	{"TASK_COMPLETE_WITH_OPEN_SUBTASKS", boolean(func(c *Config) *bool { return &c.Tasks.CompleteWithOpenSubtasks })},
+	{"TASK_ON_PARENT_DELETE", str(func(c *Config) *string { return &c.Tasks.OnParentDelete })},
+	{"TASK_RECURRENCE_INTERVAL", dur(func(c *Config) *time.Duration { return &c.Tasks.RecurrenceInterval })},
+	{"TASK_TRASH_RETENTION_DAYS", integer(func(c *Config) *int { return &c.Tasks.TrashRetentionDays })},
+
+	{"ATTACHMENT_MAX_SIZE", integer64(func(c *Config) *int64 { return &c.Attachments.MaxSize })},
+	{"ATTACHMENT_ALLOWED_TYPES", list(func(c *Config) *[]string { return &c.Attachments.AllowedTypes })},
+	{"ATTACHMENT_USER_QUOTA", integer64(func(c *Config) *int64 { return &c.Attachments.UserQuota })},
+	{"ATTACHMENT_STORAGE", str(func(c *Config) *string { return &c.Attachments.Storage })},
+	{"ATTACHMENT_DIR", str(func(c *Config) *string { return &c.Attachments.Dir })},
+	{"S3_ENDPOINT", str(func(c *Config) *string { return &c.Attachments.S3.Endpoint })},
+	{"S3_REGION", str(func(c *Config) *string { return &c.Attachments.S3.Region })},
+	{"S3_BUCKET", str(func(c *Config) *string { return &c.Attachments.S3.Bucket })},
+	{"S3_ACCESS_KEY_ID", str(func(c *Config) *string { return &c.Attachments.S3.AccessKeyID })},
+	{"S3_SECRET_ACCESS_KEY", str(func(c *Config) *string { return &c.Attachments.S3.SecretAccessKey })},
+	{"S3_PATH_STYLE", boolean(func(c *Config) *bool { return &c.Attachments.S3.PathStyle })},
+
+	{"LOG_LEVEL", str(func(c *Config) *string { return &c.Log.Level })},
+
+	{"METRICS_ENABLED", boolean(func(c *Config) *bool { return &c.Metrics.Enabled })},
+	{"METRICS_ALLOWED_CIDRS", list(func(c *Config) *[]string { return &c.Metrics.AllowedCIDRs })},
+	{"METRICS_TOKEN", str(func(c *Config) *string { return &c.Metrics.Token })},
+
+	{"TRACE_EXPORTER", str(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TRACE_FILE", str(func(c *Config) *string { return &c.Tracing.File })},
+	{"TRACE_SAMPLE_RATIO", float(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+
+	{"HEALTH_DB_MAX_LATENCY", dur(func(c *Config) *time.Duration { return &c.Health.DBMaxLatency })},
+	{"HEALTH_QUEUE_MAX_SATURATION", float(func(c *Config) *float64 { return &c.Health.QueueMaxSaturation })},
+
+	{"RATE_LIMIT_ENABLED", boolean(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_STORE", str(func(c *Config) *string { return &c.RateLimit.Store })},
+	{"RATE_LIMIT_AUTH_PER_MINUTE", integer(func(c *Config) *int { return &c.RateLimit.Auth.PerMinute })},
+	{"RATE_LIMIT_AUTH_BURST", integer(func(c *Config) *int { return &c.RateLimit.Auth.Burst })},
+	{"RATE_LIMIT_TASKS_PER_MINUTE", integer(func(c *Config) *int { return &c.RateLimit.Tasks.PerMinute })},
+	{"RATE_LIMIT_TASKS_BURST", integer(func(c *Config) *int { return &c.RateLimit.Tasks.Burst })},
+	{"RATE_LIMIT_BATCH_PER_MINUTE", integer(func(c *Config) *int { return &c.RateLimit.Batch.PerMinute })},
+	{"RATE_LIMIT_BATCH_BURST", integer(func(c *Config) *int { return &c.RateLimit.Batch.Burst })},
+	{"REDIS_ADDR", str(func(c *Config) *string { return &c.RateLimit.RedisAddr })},
+	{"REDIS_PASSWORD", str(func(c *Config) *string { return &c.RateLimit.RedisPassword })},
+
+	{"CORS_ALLOWED_ORIGINS", list(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+
+	{"DOCS_ENABLED", boolean(func(c *Config) *bool { return &c.Docs.Enabled })},
+}
+
+// applyEnv overrides fields from set, non-empty environment variables.
+// Malformed values are errors rather than silently ignored.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	for _, e := range envVars {
+		v, ok := lookup(e.name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := e.set(c, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", e.name, v, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func str(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func integer(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.New("not an integer")
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func integer64(field func(*Config) *int64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.New("not an integer")
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func float(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return errors.New("not a number")
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+func boolean(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.New("not a boolean")
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func dur(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.New("not a duration (e.g. 500ms, 5s, 1m)")
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// list splits a comma-separated value, dropping empty entries.
+func list(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var out []string
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+		*field(c) = out
+		return nil
+	}
+}
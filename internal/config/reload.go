@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// reloadable lists the settings, by file key, that a running server can
+// apply without a restart. Prefixes cover every field below them.
+var reloadable = []string{
+	"log.level",
+	"rate_limit.enabled",
+	"rate_limit.auth.",
+	"rate_limit.tasks.",
+	"rate_limit.batch.",
+	"worker.auto_complete_minutes",
+	"worker.count",
+	"cors.allowed_origins",
+}
+
+func isReloadable(key string) bool {
+	for _, r := range reloadable {
+		if key == r || (strings.HasSuffix(r, ".") && strings.HasPrefix(key, r)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes compares two configs and splits the keys that differ into those
+// a running server can apply and those that only take effect on restart.
+func Changes(old, new *Config) (reloadable, restart []string) {
+	for _, key := range diff(reflect.ValueOf(*old), reflect.ValueOf(*new), "") {
+		if isReloadable(key) {
+			reloadable = append(reloadable, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	return reloadable, restart
+}
+
+// diff returns the dotted file keys of the leaf fields that differ.
+func diff(a, b reflect.Value, prefix string) []string {
+	var keys []string
+	for i := 0; i < a.NumField(); i++ {
+		key := prefix + strings.Split(a.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			keys = append(keys, diff(fa, fb, key+".")...)
+			continue
+		}
+		if fa.Kind() == reflect.Slice && fa.Len() == 0 && fb.Len() == 0 {
+			continue // nil and empty mean the same
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MinJWTSecretLen is the shortest JWT secret accepted. HS256 keys shorter
+// than the 256-bit hash output weaken the signature.
+const MinJWTSecretLen = 32
+
+// placeholderSecretPrefix starts the placeholder JWT secret shipped in
+// .env. It is public, so a config still using it is rejected however
+// long it is.
+const placeholderSecretPrefix = "change-me"
+
+// Validate reports every problem with c at once, so a misconfigured
+// deployment can be fixed in one pass.
+func (c *Config) Validate() error {
+	var errs []string
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		add("server.addr is required")
+	}
+	if c.Server.ShutdownDrainDelay < 0 {
+		add("server.shutdown_drain_delay must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout must be positive")
+	}
+	for _, p := range c.Server.TrustedProxies {
+		if !validIPOrCIDR(p) {
+			add("server.trusted_proxies: %q is not an IP or CIDR", p)
+		}
+	}
+
+	if c.TLS.Enabled() && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+		add("tls.cert_file and tls.key_file must be set together")
+	}
+	switch c.TLS.MinVersion {
+	case "1.2", "1.3":
+	default:
+		add("tls.min_version must be 1.2 or 1.3, got %q", c.TLS.MinVersion)
+	}
+	switch c.TLS.ClientAuth {
+	case "none":
+	case "optional", "require":
+		if !c.TLS.Enabled() {
+			add("tls.client_auth needs tls.cert_file and tls.key_file")
+		}
+		if c.TLS.ClientCAFile == "" {
+			add("tls.client_ca_file is required when tls.client_auth is %s", c.TLS.ClientAuth)
+		}
+	default:
+		add("tls.client_auth must be none, optional or require, got %q", c.TLS.ClientAuth)
+	}
+	for i, id := range c.TLS.ClientIdentities {
+		if id.Subject == "" || id.UserID == "" {
+			add("tls.client_identities[%d]: subject and user_id are required", i)
+		}
+		if id.Role != "user" && id.Role != "admin" {
+			add("tls.client_identities[%d]: role must be user or admin, got %q", i, id.Role)
+		}
+	}
+	if c.TLS.HTTP3 && !c.TLS.Enabled() {
+		add("tls.http3 needs tls.cert_file and tls.key_file")
+	}
+
+	switch c.DB.Driver {
+	case "mysql":
+		for _, f := range []struct{ name, value string }{
+			{"host", c.DB.Host}, {"port", c.DB.Port}, {"user", c.DB.User}, {"name", c.DB.Name},
+		} {
+			if f.value == "" {
+				add("db.%s is required for the mysql driver (DB_%s)", f.name, strings.ToUpper(f.name))
+			}
+		}
+	case "sqlite":
+		if c.DB.Path == "" {
+			add("db.path is required for the sqlite driver (DB_PATH)")
+		}
+	default:
+		add("db.driver must be mysql or sqlite, got %q", c.DB.Driver)
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		add("db.max_open_conns and db.max_idle_conns must not be negative")
+	}
+	if c.DB.ReadTimeout <= 0 || c.DB.WriteTimeout <= 0 {
+		add("db.read_timeout and db.write_timeout must be positive")
+	}
+
+	switch n := len(c.Auth.JWTSecret); {
+	case n == 0:
+		add("auth.jwt_secret is required (JWT_SECRET)")
+	case n < MinJWTSecretLen:
+		add("auth.jwt_secret must be at least %d characters, got %d", MinJWTSecretLen, n)
+	case strings.HasPrefix(c.Auth.JWTSecret, placeholderSecretPrefix):
+		add("auth.jwt_secret is still the placeholder from .env; set a random secret")
+	}
+	if c.Auth.TokenExpiry <= 0 {
+		add("auth.token_expiry must be positive")
+	}
+	if c.Auth.RefreshExpiry <= c.Auth.TokenExpiry {
+		add("auth.refresh_expiry must be longer than auth.token_expiry")
+	}
+	if c.Auth.SessionExpiry < c.Auth.RefreshExpiry {
+		add("auth.session_expiry must not be shorter than auth.refresh_expiry")
+	}
+
+	if c.Worker.AutoCompleteMinutes <= 0 {
+		add("worker.auto_complete_minutes must be positive")
+	}
+	if c.Worker.Count <= 0 {
+		add("worker.count must be positive")
+	}
+	if c.Worker.QueueSize <= 0 {
+		add("worker.queue_size must be positive")
+	}
+
+	if c.Tasks.MaxDepth < 1 || c.Tasks.MaxDepth > 10 {
+		add("tasks.max_depth must be between 1 and 10, got %d", c.Tasks.MaxDepth)
+	}
+	switch c.Tasks.OnParentDelete {
+	case "cascade", "promote", "restrict":
+	default:
+		add("tasks.on_parent_delete must be cascade, promote or restrict, got %q", c.Tasks.OnParentDelete)
+	}
+	if c.Tasks.RecurrenceInterval < time.Second {
+		add("tasks.recurrence_interval must be at least 1s, got %s", c.Tasks.RecurrenceInterval)
+	}
+	if c.Tasks.TrashRetentionDays < 1 {
+		add("tasks.trash_retention_days must be at least 1, got %d", c.Tasks.TrashRetentionDays)
+	}
+
+	if c.Attachments.MaxSize <= 0 {
+		add("attachments.max_size must be positive")
+	}
+	if c.Attachments.UserQuota < 0 {
+		add("attachments.user_quota must not be negative")
+	}
+	if len(c.Attachments.AllowedTypes) == 0 {
+		add("attachments.allowed_types must list at least one type")
+	}
+	for _, t := range c.Attachments.AllowedTypes {
+		if typ, sub, ok := strings.Cut(t, "/"); !ok || typ == "" || typ == "*" || sub == "" || strings.ContainsAny(t, " ;") {
+			add("attachments.allowed_types: %q is not a media type like image/png or image/*", t)
+		}
+	}
+	switch c.Attachments.Storage {
+	case "local":
+		if c.Attachments.Dir == "" {
+			add("attachments.dir is required for local storage (ATTACHMENT_DIR)")
+		}
+	case "s3":
+		s3 := c.Attachments.S3
+		if u, err := url.Parse(s3.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			add("attachments.s3.endpoint must be a URL like https://s3.eu-west-1.amazonaws.com, got %q", s3.Endpoint)
+		}
+		for _, f := range []struct{ name, value string }{
+			{"region", s3.Region}, {"bucket", s3.Bucket}, {"access_key_id", s3.AccessKeyID}, {"secret_access_key", s3.SecretAccessKey},
+		} {
+			if f.value == "" {
+				add("attachments.s3.%s is required for s3 storage (S3_%s)", f.name, strings.ToUpper(f.name))
+			}
+		}
+	default:
+		add("attachments.storage must be local or s3, got %q", c.Attachments.Storage)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		add("log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	for _, cidr := range c.Metrics.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			add("metrics.allowed_cidrs: %q is not a CIDR", cidr)
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	case "file":
+		if c.Tracing.File == "" {
+			add("tracing.file is required for the file exporter")
+		}
+	default:
+		add("tracing.exporter must be none, otlp, stdout or file, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio must be between 0 and 1")
+	}
+
+	if c.Health.DBMaxLatency <= 0 {
+		add("health.db_max_latency must be positive")
+	}
+	if c.Health.QueueMaxSaturation <= 0 || c.Health.QueueMaxSaturation > 1 {
+		add("health.queue_max_saturation must be in (0, 1]")
+	}
+
+	switch c.RateLimit.Store {
+	case "memory":
+	case "redis":
+		if c.RateLimit.RedisAddr == "" {
+			add("rate_limit.redis_addr is required for the redis store")
+		}
+	default:
+		add("rate_limit.store must be memory or redis, got %q", c.RateLimit.Store)
+	}
+	if c.RateLimit.Enabled {
+		if p := c.RateLimit.Auth; p.PerMinute <= 0 || p.Burst <= 0 {
+			add("rate_limit.auth.per_minute and burst must be positive")
+		}
+		if p := c.RateLimit.Tasks; p.PerMinute <= 0 || p.Burst <= 0 {
+			add("rate_limit.tasks.per_minute and burst must be positive")
+		}
+		if p := c.RateLimit.Batch; p.PerMinute <= 0 || p.Burst <= 0 {
+			add("rate_limit.batch.per_minute and burst must be positive")
+		}
+	}
+
+	for _, o := range c.CORS.AllowedOrigins {
+		if o == "*" {
+			continue
+		}
+		if u, err := url.Parse(o); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			add("cors.allowed_origins: %q is not an origin like https://app.example.com", o)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration:\n  - " + strings.Join(errs, "\n  - "))
+}
+
+func validIPOrCIDR(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
+const masked = "********"
+
+// Masked returns a copy of c with secrets replaced, safe to print or log.
+func (c *Config) Masked() *Config {
+	m := *c
+	for _, s := range []*string{
+		&m.DB.Password, &m.Auth.JWTSecret, &m.Metrics.Token, &m.RateLimit.RedisPassword,
+		&m.Attachments.S3.SecretAccessKey,
+	} {
+		if *s != "" {
+			*s = masked
+		}
+	}
+	return &m
+}
+
+// YAML renders c with secrets masked, in the same shape the config file
+// accepts.
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c.Masked())
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AttachmentHandler struct {
+	service *service.AttachmentService
+}
+
+func NewAttachmentHandler(s *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: s}
+}
+
+// List godoc
+//
+//	@Summary		List attachments
+//	@Description	Lists the files attached to a task, oldest first, one page at a time. Users may only see the attachments of their own tasks.
+//	@Tags			attachments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Task ID"
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(100)
+//	@Param			cursor	query		string	false	"next_cursor from the previous page"
+//	@Success		200		{object}	api.AttachmentListResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		403		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/{id}/attachments [get]
+func (h *AttachmentHandler) List(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	attachments, more, err := h.service.ListAttachments(c.Request.Context(),
+		c.Param("id"), c.GetString("user_id"), c.GetString("role"), page)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	resp := api.AttachmentListResponse{
+		Count:       len(attachments),
+		Attachments: attachments,
+	}
+	if more {
+		resp.NextCursor = encodeCursor(page.Offset + len(attachments))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Upload godoc
+//
+//	@Summary		Attach a file
+//	@Description	Attaches a file, sent as the "file" field of a multipart form, to a task the caller can see. Its type is detected from the content and must be one the server accepts. The file counts against the quota of the task's owner.
+//	@Tags			attachments
+//	@Accept			mpfd
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Task ID"
+//	@Param			file	formData	file	true	"The file to attach"
+//	@Success		201		{object}	api.Attachment
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		403		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		413		{object}	api.Problem
+//	@Failure		415		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Failure		507		{object}	api.Problem
+//	@Router			/tasks/{id}/attachments [post]
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	part, err := filePart(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer part.Close()
+
+	attachment := &models.Attachment{
+		ID:         uuid.NewString(),
+		TaskID:     c.Param("id"),
+		UploaderID: c.GetString("user_id"),
+		Name:       part.FileName(),
+		CreatedAt:  time.Now(),
+	}
+	if err := h.service.UploadAttachment(c.Request.Context(), attachment, c.GetString("role"), part); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// filePart finds the "file" field of a multipart upload. The form is read
+// as a stream, so the file is never held in memory.
+func filePart(c *gin.Context) (*multipart.Part, error) {
+	missing := apperr.Validation(apperr.FieldError{
+		Field:   "file",
+		Code:    "required",
+		Message: "send the file as the file field of a multipart/form-data body",
+	})
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, missing
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, missing
+		}
+		if err != nil {
+			return nil, apperr.Validation(apperr.FieldError{Field: "file", Code: "incomplete", Message: "the multipart body is malformed"})
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// Download godoc
+//
+//	@Summary		Download an attachment
+//	@Description	Returns the content of a file attached to a task the caller can see. The ETag is the file's SHA-256 checksum.
+//	@Tags			attachments
+//	@Produce		octet-stream
+//	@Security		BearerAuth
+//	@Param			id				path		string	true	"Task ID"
+//	@Param			attachment_id	path		string	true	"Attachment ID"
+//	@Param			If-None-Match	header		string	false	"ETag from an earlier download; 304 if it is still current"
+//	@Success		200				{file}		file
+//	@Success		304				"Not modified"
+//	@Failure		401				{object}	api.Problem
+//	@Failure		403				{object}	api.Problem
+//	@Failure		404				{object}	api.Problem
+//	@Failure		429				{object}	api.Problem
+//	@Router			/tasks/{id}/attachments/{attachment_id} [get]
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	attachment, content, err := h.service.OpenAttachment(c.Request.Context(),
+		c.Param("id"), c.Param("attachment_id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	defer content.Close()
+
+	etag := `"` + attachment.Checksum + `"`
+	c.Header("ETag", etag)
+	if notModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	// Served as a download and never sniffed, so an upload cannot turn
+	// into a page on the API's origin.
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// Delete godoc
+//
+//	@Summary		Delete an attachment
+//	@Description	Deletes a file attached to a task. The task's owner and admins may delete any of its attachments.
+//	@Tags			attachments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		string	true	"Task ID"
+//	@Param			attachment_id	path		string	true	"Attachment ID"
+//	@Success		200				{object}	api.MessageResponse
+//	@Failure		401				{object}	api.Problem
+//	@Failure		403				{object}	api.Problem
+//	@Failure		404				{object}	api.Problem
+//	@Failure		429				{object}	api.Problem
+//	@Router			/tasks/{id}/attachments/{attachment_id} [delete]
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	err := h.service.DeleteAttachment(c.Request.Context(),
+		c.Param("id"), c.Param("attachment_id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.MessageResponse{
+		Message: "attachment deleted successfully",
+	})
+}
@@ -2,112 +2,233 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
 	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
-	jwtSecret   string
-	jwtExpiry   int
+	authService   *service.AuthService
+	jwtSecret     string
+	jwtExpiry     time.Duration
+	refreshExpiry time.Duration
+	sessionExpiry time.Duration
 }
 
-func NewAuthHandler(s *service.AuthService, secret string, expiry int) *AuthHandler {
+func NewAuthHandler(s *service.AuthService, secret string, expiry, refreshExpiry, sessionExpiry time.Duration) *AuthHandler {
 	return &AuthHandler{
-		authService: s,
-		jwtSecret:   secret,
-		jwtExpiry:   expiry,
+		authService:   s,
+		jwtSecret:     secret,
+		jwtExpiry:     expiry,
+		refreshExpiry: refreshExpiry,
+		sessionExpiry: sessionExpiry,
 	}
 }
 
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-}
-type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-}
-type RegisterAdminRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
-}
-
+// Login godoc
+//
+//	@Summary		Log in
+//	@Description	Exchanges an email and password for an access token and a refresh token.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body		api.LoginRequest	true	"Credentials"
+//	@Success		200			{object}	api.TokenResponse
+//	@Failure		400			{object}	api.Problem
+//	@Failure		401			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
-	var req LoginRequest
+	var req api.LoginRequest
+
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	user, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "email and password required",
-		})
+	h.issueTokens(c, user, time.Now().Add(h.sessionExpiry))
+}
+
+// Refresh godoc
+//
+//	@Summary		Refresh tokens
+//	@Description	Exchanges a refresh token for a new access token and refresh token, with the user's current role. Refreshing cannot extend a login past the session expiry, after which the user must log in again.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	body		api.RefreshRequest	true	"Refresh token from login"
+//	@Success		200		{object}	api.TokenResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req api.RefreshRequest
+
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
-	user, err := h.authService.Login(req.Email, req.Password)
+	claims, err := service.ParseRefreshToken(req.RefreshToken, h.jwtSecret)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "invalid credentials",
-		})
+		middleware.Fail(c, err)
 		return
 	}
+	user, err := h.authService.Refresh(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	h.issueTokens(c, user, claims.SessionEnd)
+}
 
+// issueTokens responds with a fresh access and refresh token for user,
+// for a login that ends at sessionEnd.
+func (h *AuthHandler) issueTokens(c *gin.Context, user *models.User, sessionEnd time.Time) {
+	expiresAt := time.Now().Add(h.jwtExpiry)
 	token, err := service.GenerateToken(user, h.jwtSecret, h.jwtExpiry)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "could not generate token",
-		})
+		middleware.Fail(c, err)
+		return
+	}
+	refresh, err := service.GenerateRefreshToken(user, h.jwtSecret, h.refreshExpiry, sessionEnd)
+	if err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"role":  user.Role,
+	c.JSON(http.StatusOK, api.TokenResponse{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresAt:    expiresAt.UTC().Truncate(time.Second),
+		Role:         user.Role,
 	})
 }
 
+// Register godoc
+//
+//	@Summary	Register a user
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		user	body		api.RegisterRequest	true	"New user"
+//	@Success	201		{object}	api.MessageResponse
+//	@Failure	400		{object}	api.Problem
+//	@Failure	409		{object}	api.Problem
+//	@Failure	429		{object}	api.Problem
+//	@Router		/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
-	var req RegisterRequest
+	var req api.RegisterRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "valid email and password (min 6 chars) required",
-		})
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
-	err := h.authService.Register(req.Email, req.Password)
+	err := h.authService.Register(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "user already exists or could not create user",
-		})
+		middleware.Fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "user registered successfully",
+	c.JSON(http.StatusCreated, api.MessageResponse{
+		Message: "user registered successfully",
 	})
 }
 
+// RegisterAdmin godoc
+//
+//	@Summary	Register an admin
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		user	body		api.RegisterAdminRequest	true	"New admin"
+//	@Success	201		{object}	api.MessageResponse
+//	@Failure	400		{object}	api.Problem
+//	@Failure	401		{object}	api.Problem
+//	@Failure	403		{object}	api.Problem
+//	@Failure	409		{object}	api.Problem
+//	@Failure	429		{object}	api.Problem
+//	@Router		/auth/admin/register [post]
 func (h *AuthHandler) RegisterAdmin(c *gin.Context) {
-	var req RegisterAdminRequest
+	var req api.RegisterAdminRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "valid email and password (min 8 chars) required",
-		})
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
-	err := h.authService.RegisterAdmin(req.Email, req.Password)
+	err := h.authService.RegisterAdmin(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.MessageResponse{
+		Message: "admin user created successfully",
+	})
+}
+
+// ListUsers godoc
+//
+//	@Summary	List users
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Success	200	{object}	api.UserListResponse
+//	@Failure	401	{object}	api.Problem
+//	@Failure	403	{object}	api.Problem
+//	@Failure	429	{object}	api.Problem
+//	@Router		/auth/admin/users [get]
+func (h *AuthHandler) ListUsers(c *gin.Context) {
+	users, err := h.authService.ListUsers(c.Request.Context())
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.UserListResponse{
+		Count: len(users),
+		Users: users,
+	})
+}
+
+// DeleteUser godoc
+//
+//	@Summary		Delete a user
+//	@Description	Deletes a user account. The user's tasks are kept. Admins cannot delete themselves.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	api.MessageResponse
+//	@Failure		401	{object}	api.Problem
+//	@Failure		403	{object}	api.Problem
+//	@Failure		404	{object}	api.Problem
+//	@Failure		409	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/auth/admin/users/{id} [delete]
+func (h *AuthHandler) DeleteUser(c *gin.Context) {
+	err := h.authService.DeleteUser(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "could not create admin user",
-		})
+		middleware.Fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "admin user created successfully",
+	c.JSON(http.StatusOK, api.MessageResponse{
+		Message: "user deleted successfully",
 	})
 }
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CommentHandler struct {
+	service *service.CommentService
+}
+
+func NewCommentHandler(s *service.CommentService) *CommentHandler {
+	return &CommentHandler{service: s}
+}
+
+// List godoc
+//
+//	@Summary		List comments
+//	@Description	Lists a task's comments, oldest first, one page at a time. Users may only read comments on their own tasks.
+//	@Tags			comments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Task ID"
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(100)
+//	@Param			cursor	query		string	false	"next_cursor from the previous page"
+//	@Success		200		{object}	api.CommentListResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		403		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/{id}/comments [get]
+func (h *CommentHandler) List(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	comments, more, err := h.service.ListComments(c.Request.Context(),
+		c.Param("id"), c.GetString("user_id"), c.GetString("role"), page)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	resp := api.CommentListResponse{
+		Count:    len(comments),
+		Comments: comments,
+	}
+	if more {
+		resp.NextCursor = encodeCursor(page.Offset + len(comments))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Create godoc
+//
+//	@Summary		Add a comment
+//	@Description	Adds a comment to a task the caller can see.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string				true	"Task ID"
+//	@Param			comment	body		api.CommentRequest	true	"Comment to add"
+//	@Success		201		{object}	api.Comment
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		403		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/{id}/comments [post]
+func (h *CommentHandler) Create(c *gin.Context) {
+	var req api.CommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	now := time.Now()
+	comment := &models.Comment{
+		ID:        uuid.NewString(),
+		TaskID:    c.Param("id"),
+		AuthorID:  c.GetString("user_id"),
+		Body:      req.Body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := h.service.AddComment(c.Request.Context(), comment, c.GetString("role")); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// Update godoc
+//
+//	@Summary		Edit a comment
+//	@Description	Replaces a comment's body and marks it edited. Only the author may edit a comment.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string				true	"Task ID"
+//	@Param			comment_id	path		string				true	"Comment ID"
+//	@Param			comment		body		api.CommentRequest	true	"New body"
+//	@Success		200			{object}	api.Comment
+//	@Failure		400			{object}	api.Problem
+//	@Failure		401			{object}	api.Problem
+//	@Failure		403			{object}	api.Problem
+//	@Failure		404			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/tasks/{id}/comments/{comment_id} [patch]
+func (h *CommentHandler) Update(c *gin.Context) {
+	var req api.CommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	comment, err := h.service.EditComment(c.Request.Context(),
+		c.Param("id"), c.Param("comment_id"), c.GetString("user_id"), c.GetString("role"), req.Body)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// Delete godoc
+//
+//	@Summary		Delete a comment
+//	@Description	Deletes a comment. The task's owner and admins may delete any comment on it.
+//	@Tags			comments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Task ID"
+//	@Param			comment_id	path		string	true	"Comment ID"
+//	@Success		200			{object}	api.MessageResponse
+//	@Failure		401			{object}	api.Problem
+//	@Failure		403			{object}	api.Problem
+//	@Failure		404			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/tasks/{id}/comments/{comment_id} [delete]
+func (h *CommentHandler) Delete(c *gin.Context) {
+	err := h.service.DeleteComment(c.Request.Context(),
+		c.Param("id"), c.Param("comment_id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.MessageResponse{
+		Message: "comment deleted successfully",
+	})
+}
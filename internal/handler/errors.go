@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerJSONNames sync.Once
+
+// bindJSON decodes and validates the request body into obj. Failures come
+// back as *apperr.ValidationError, with fields named as in the JSON.
+func bindJSON(c *gin.Context, obj any) error {
+	registerJSONNames.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(func(f reflect.StructField) string {
+				name := strings.Split(f.Tag.Get("json"), ",")[0]
+				if name == "-" {
+					return ""
+				}
+				return name
+			})
+		}
+	})
+
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return nil
+	}
+
+	var (
+		verrs   validator.ValidationErrors
+		typeErr *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &verrs):
+		fields := make([]apperr.FieldError, len(verrs))
+		for i, fe := range verrs {
+			fields[i] = apperr.FieldError{Field: fe.Field(), Code: fe.Tag(), Message: fieldMessage(fe)}
+		}
+		return apperr.Validation(fields...)
+	case errors.As(err, &typeErr):
+		return apperr.Validation(apperr.FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("must be a %s", typeErr.Type),
+		})
+	case errors.Is(err, io.EOF):
+		return apperr.Validation(apperr.FieldError{Field: "body", Code: "required", Message: "request body is required"})
+	default:
+		return apperr.Validation(apperr.FieldError{Field: "body", Code: "malformed", Message: "request body is not valid JSON"})
+	}
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	}
+	return "is invalid"
+}
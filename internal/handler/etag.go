@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagList splits the values of an If-Match or If-None-Match header into
+// entity tags. It returns nil when the header is absent.
+func etagList(c *gin.Context, header string) []string {
+	values := c.Request.Header.Values(header)
+	if len(values) == 0 {
+		return nil
+	}
+	tags := []string{}
+	for _, v := range values {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// ifMatch returns the entity tags of the If-Match header, or nil when it
+// is absent. The service compares them strongly, so a weak tag never
+// matches.
+func ifMatch(c *gin.Context) []string {
+	return etagList(c, "If-Match")
+}
+
+// notModified reports whether the If-None-Match header matches etag. The
+// comparison is weak, as RFC 9110 asks for GET.
+func notModified(c *gin.Context, etag string) bool {
+	tags := etagList(c, "If-None-Match")
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		return tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/")
+	})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(c *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: c}
+}
+
+type LivenessResponse struct {
+	Status health.Status `json:"status" example:"ok"`
+}
+
+// Liveness only proves the process is serving HTTP.
+//
+//	@Summary	Liveness probe
+//	@Tags		health
+//	@Produce	json
+//	@Success	200	{object}	LivenessResponse
+//	@Router		/healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: health.StatusOK})
+}
+
+// Readiness runs every dependency check and returns 503 if any fails.
+//
+//	@Summary	Readiness probe
+//	@Tags		health
+//	@Produce	json
+//	@Success	200	{object}	health.Report
+//	@Failure	503	{object}	health.Report
+//	@Router		/readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
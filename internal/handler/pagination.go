@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 500
+)
+
+// cursorPrefix versions the cursor format, so it can change without old
+// cursors being misread.
+const cursorPrefix = "o1:"
+
+// parsePage reads the limit and cursor query parameters.
+func parsePage(c *gin.Context) (service.Page, error) {
+	page := service.Page{Limit: DefaultPageSize}
+	var fields []apperr.FieldError
+
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxPageSize {
+			fields = append(fields, apperr.FieldError{
+				Field:   "limit",
+				Code:    "range",
+				Message: "must be an integer from 1 to " + strconv.Itoa(MaxPageSize),
+			})
+		} else {
+			page.Limit = n
+		}
+	}
+	if s := c.Query("cursor"); s != "" {
+		offset, ok := decodeCursor(s)
+		if !ok {
+			fields = append(fields, apperr.FieldError{Field: "cursor", Code: "invalid", Message: "is not a cursor returned by this API"})
+		}
+		page.Offset = offset
+	}
+
+	if len(fields) > 0 {
+		return service.Page{}, apperr.Validation(fields...)
+	}
+	return page, nil
+}
+
+// Cursors are opaque to clients; today they encode an offset.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(s string) (int, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(string(b), cursorPrefix))
+	if err != nil || n < 0 || !strings.HasPrefix(string(b), cursorPrefix) {
+		return 0, false
+	}
+	return n, true
+}
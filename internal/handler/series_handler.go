@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SeriesHandler serves recurring tasks. Their occurrences are ordinary
+// tasks, served by TaskHandler.
+type SeriesHandler struct {
+	service *service.TaskService
+}
+
+func NewSeriesHandler(s *service.TaskService) *SeriesHandler {
+	return &SeriesHandler{service: s}
+}
+
+// Get godoc
+//
+//	@Summary		Get a recurring task
+//	@Description	Returns the series an occurrence's series_id refers to: the template and rule its occurrences are created from, and when the next one is due. Users may only read their own.
+//	@Tags			series
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Series ID"
+//	@Success		200	{object}	api.Series
+//	@Failure		401	{object}	api.Problem
+//	@Failure		403	{object}	api.Problem
+//	@Failure		404	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/series/{id} [get]
+func (h *SeriesHandler) Get(c *gin.Context) {
+	series, err := h.service.GetSeries(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// End godoc
+//
+//	@Summary		End a recurring task
+//	@Description	Stops a series from creating further occurrences. The occurrences already created are kept, and the series can still be read.
+//	@Tags			series
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Series ID"
+//	@Success		200	{object}	api.Series
+//	@Failure		401	{object}	api.Problem
+//	@Failure		403	{object}	api.Problem
+//	@Failure		404	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/series/{id} [delete]
+func (h *SeriesHandler) End(c *gin.Context) {
+	series, err := h.service.EndSeries(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	service *service.TagService
+}
+
+func NewTagHandler(s *service.TagService) *TagHandler {
+	return &TagHandler{service: s}
+}
+
+// List godoc
+//
+//	@Summary		List tags
+//	@Description	Lists the caller's tags by name, with how many tasks have each.
+//	@Tags			tags
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	api.TagListResponse
+//	@Failure		401	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/tags [get]
+func (h *TagHandler) List(c *gin.Context) {
+	tags, err := h.service.ListTags(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.TagListResponse{
+		Count: len(tags),
+		Tags:  tags,
+	})
+}
+
+// Rename godoc
+//
+//	@Summary		Rename a tag
+//	@Description	Renames one of the caller's tags on every task that has it. Renaming onto an existing tag is a conflict; merge instead.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name	path		string					true	"Tag name"
+//	@Param			tag		body		api.RenameTagRequest	true	"New name"
+//	@Success		200		{object}	api.Tag
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		409		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tags/{name} [patch]
+func (h *TagHandler) Rename(c *gin.Context) {
+	var req api.RenameTagRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	tag, err := h.service.RenameTag(c.Request.Context(), c.GetString("user_id"), c.Param("name"), req.Name)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Merge godoc
+//
+//	@Summary		Merge a tag into another
+//	@Description	Puts the into tag on every task tagged name, then deletes name. Returns the remaining tag.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name	path		string				true	"Tag to merge away"
+//	@Param			merge	body		api.MergeTagRequest	true	"Tag to keep"
+//	@Success		200		{object}	api.Tag
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tags/{name}/merge [post]
+func (h *TagHandler) Merge(c *gin.Context) {
+	var req api.MergeTagRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	tag, err := h.service.MergeTags(c.Request.Context(), c.GetString("user_id"), c.Param("name"), req.Into)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Delete godoc
+//
+//	@Summary		Delete a tag
+//	@Description	Deletes one of the caller's tags and removes it from their tasks.
+//	@Tags			tags
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			name	path		string	true	"Tag name"
+//	@Success		200		{object}	api.MessageResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tags/{name} [delete]
+func (h *TagHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteTag(c.Request.Context(), c.GetString("user_id"), c.Param("name")); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.MessageResponse{
+		Message: "tag deleted successfully",
+	})
+}
@@ -4,8 +4,11 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
 	"github.com/CashInvoice-Golang-Assignment/internal/models"
 	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -18,116 +21,623 @@ func NewTaskHandler(s *service.TaskService) *TaskHandler {
 	return &TaskHandler{service: s}
 }
 
-type CreateTaskRequest struct {
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
-}
+var errTaskIDRequired = apperr.Validation(apperr.FieldError{Field: "id", Code: "required", Message: "task id is required"})
 
+// Create godoc
+//
+//	@Summary		Create a task
+//	@Description	Creates a pending task owned by the caller. It is auto-completed after the configured delay.
+//	@Description	With parent_id the task is a subtask; it is owned by the parent's owner and may be nested up to the configured depth.
+//	@Description	With recurrence the task is the first occurrence of a recurring task, and is not auto-completed. Its series_id links it to the series.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			task	body		api.CreateTaskRequest	true	"Task to create"
+//	@Success		201		{object}	api.Task
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks [post]
 func (h *TaskHandler) Create(c *gin.Context) {
-	var req CreateTaskRequest
+	var req api.CreateTaskRequest
 
 	// Validate input
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "title is required",
-		})
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
 	userID := c.GetString("user_id") // from JWT middleware
+	role := c.GetString("role")
 
 	task := &models.Task{
 		ID:          uuid.NewString(),
 		Title:       req.Title,
 		Description: req.Description,
 		Status:      models.StatusPending,
+		Tags:        req.Tags,
+		ParentID:    req.ParentID,
 		UserID:      userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := h.service.CreateTask(task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to create task",
-		})
+	var err error
+	if req.Recurrence != nil {
+		err = h.service.CreateRecurringTask(c.Request.Context(), task, *recurrence(req.Recurrence), role)
+	} else {
+		err = h.service.CreateTask(c.Request.Context(), task, role)
+	}
+	if err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, task)
 }
 
+// Batch godoc
+//
+//	@Summary		Run several task operations
+//	@Description	Runs up to 100 create, update, delete and status operations in one call, each checked as the single call it stands for would be, ownership included. Created tasks are scheduled for auto-complete as usual.
+//	@Description	In atomic mode every operation is checked against the tasks as they were before the batch and all are saved in one transaction; if one fails nothing is saved and the others report 424. If a task is changed by another request after it was checked, the whole call fails with 409. A task may only be changed by one operation of an atomic batch, and an operation that creates or moves a subtask fails with 409 if another one moves a task above it, before or after the batch.
+//	@Description	In best_effort mode the operations run in order and each succeeds or fails on its own.
+//	@Description	The response is 200 when every operation succeeded and 207 otherwise, with a result per operation. A batch counts against its own rate limit as well as the one for /tasks.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			batch	body		api.BatchRequest	true	"Operations to run"
+//	@Success		200		{object}	api.BatchResponse
+//	@Success		207		{object}	api.BatchResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		409		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/batch [post]
+func (h *TaskHandler) Batch(c *gin.Context) {
+	var req api.BatchRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+
+	ops := make([]service.BatchOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = batchOp(op)
+	}
+	results, err := h.service.Batch(c.Request.Context(), userID, role, ops, req.Mode == "atomic")
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	resp := api.BatchResponse{Results: make([]api.BatchResult, len(results))}
+	for i, r := range results {
+		res := api.BatchResult{Index: i, Op: ops[i].Op, Status: http.StatusOK, Task: r.Task}
+		if ops[i].Op == service.OpCreate {
+			res.Status = http.StatusCreated
+		}
+		if r.Err != nil {
+			res.Error = middleware.NewProblem(r.Err)
+			res.Error.RequestID = c.GetString("request_id")
+			res.Status = res.Error.Status
+			res.Task = nil
+			// Attached for the access log only; the response below is
+			// written first, so it is not rendered as a problem.
+			if res.Status >= http.StatusInternalServerError {
+				_ = c.Error(r.Err)
+			}
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results[i] = res
+	}
+
+	status := http.StatusOK
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, resp)
+}
+
+// batchOp converts an API batch operation for the service.
+func batchOp(op api.BatchOperation) service.BatchOp {
+	out := service.BatchOp{
+		Op:     op.Op,
+		TaskID: op.ID,
+		Update: service.TaskUpdate{
+			Title:       op.Title,
+			Description: op.Description,
+			Status:      op.Status,
+			Tags:        op.Tags,
+			ParentID:    op.ParentID,
+		},
+	}
+	if op.IfMatch != "" {
+		out.IfMatch = []string{op.IfMatch}
+	}
+	if op.Op == service.OpCreate {
+		now := time.Now()
+		out.Task = &models.Task{
+			ID:        uuid.NewString(),
+			Status:    models.StatusPending,
+			ParentID:  op.ParentID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if op.Title != nil {
+			out.Task.Title = *op.Title
+		}
+		if op.Description != nil {
+			out.Task.Description = *op.Description
+		}
+		if op.Tags != nil {
+			out.Task.Tags = *op.Tags
+		}
+	}
+	return out
+}
+
+// GetAllTask godoc
+//
+//	@Summary		List tasks
+//	@Description	Lists the caller's tasks, newest first, one page at a time. Admins see every task.
+//	@Description	Repeat tag to filter by several tags; match chooses whether a task needs any or all of them.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			limit	query		int			false	"Page size"	minimum(1)	maximum(500)	default(100)
+//	@Param			cursor	query		string		false	"next_cursor from the previous page"
+//	@Param			tag		query		[]string	false	"Only tasks with this tag"	collectionFormat(multi)
+//	@Param			match	query		string		false	"Whether tasks need any or all of the tags"	Enums(any, all)	default(any)
+//	@Param			series_id	query		string		false	"Only the occurrences of this recurring task"
+//	@Success		200		{object}	api.TaskListResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks [get]
 func (h *TaskHandler) GetAllTask(c *gin.Context) {
 	// Extract auth context (set by JWT middleware)
 	userID := c.GetString("user_id")
 	role := c.GetString("role")
 
-	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "unauthorized",
-		})
+	page, err := parsePage(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
 	// Call service layer
-	tasks, err := h.service.GetAllTasks(userID, role)
+	tasks, more, err := h.service.GetAllTasks(c.Request.Context(), userID, role, filter, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to fetch tasks",
-		})
+		middleware.Fail(c, err)
 		return
 	}
 
 	// Success
-	c.JSON(http.StatusOK, gin.H{
-		"count": len(tasks),
-		"tasks": tasks,
-	})
+	resp := api.TaskListResponse{
+		Count: len(tasks),
+		Tasks: tasks,
+	}
+	if more {
+		resp.NextCursor = encodeCursor(page.Offset + len(tasks))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseTaskFilter reads the tag, match and series_id query parameters.
+func parseTaskFilter(c *gin.Context) (service.TaskFilter, error) {
+	filter := service.TaskFilter{Tags: c.QueryArray("tag"), SeriesID: c.Query("series_id")}
+	switch c.DefaultQuery("match", "any") {
+	case "any":
+	case "all":
+		filter.MatchAll = true
+	default:
+		return service.TaskFilter{}, apperr.Validation(apperr.FieldError{Field: "match", Code: "oneof", Message: "must be one of: any all"})
+	}
+	return filter, nil
+}
+
+// recurrence converts an API recurrence for the service; nil stays nil.
+func recurrence(r *api.Recurrence) *service.Recurrence {
+	if r == nil {
+		return nil
+	}
+	rec := &service.Recurrence{RRule: r.RRule, Timezone: r.Timezone}
+	if r.StartsAt != nil {
+		rec.StartsAt = *r.StartsAt
+	}
+	return rec
 }
 
+// GetByID godoc
+//
+//	@Summary		Get a task
+//	@Description	Returns one task. Users may only read their own tasks.
+//	@Description	The ETag header identifies this version of the task; sending it back in If-None-Match returns 304 with no body while the task is unchanged.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id				path		string	true	"Task ID"
+//	@Param			If-None-Match	header		string	false	"ETag of the copy the client has"
+//	@Success		200				{object}	api.Task
+//	@Header			200				{string}	ETag	"Entity tag of the task"
+//	@Success		304				"Task unchanged"
+//	@Failure		401				{object}	api.Problem
+//	@Failure		403	{object}	api.Problem
+//	@Failure		404	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/tasks/{id} [get]
 func (h *TaskHandler) GetByID(c *gin.Context) {
 	taskID := c.Param("id")
 	userID := c.GetString("user_id")
 	role := c.GetString("role")
 
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task id required"})
+		middleware.Fail(c, errTaskIDRequired)
 		return
 	}
 
-	task, err := h.service.GetTaskByID(taskID, userID, role)
+	task, err := h.service.GetTaskByID(c.Request.Context(), taskID, userID, role)
 	if err != nil {
-		if err.Error() == "forbidden" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
-			return
-		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.Header("ETag", task.ETag())
+	if notModified(c, task.ETag()) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// GetSubtasks godoc
+//
+//	@Summary		List subtasks
+//	@Description	Lists the direct subtasks of a task, newest first, one page at a time. Users may only read their own tasks.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Task ID"
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(100)
+//	@Param			cursor	query		string	false	"next_cursor from the previous page"
+//	@Success		200		{object}	api.TaskListResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		403		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/{id}/subtasks [get]
+func (h *TaskHandler) GetSubtasks(c *gin.Context) {
+	taskID := c.Param("id")
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+
+	page, err := parsePage(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	tasks, more, err := h.service.GetSubtasks(c.Request.Context(), taskID, userID, role, page)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	resp := api.TaskListResponse{
+		Count: len(tasks),
+		Tasks: tasks,
+	}
+	if more {
+		resp.NextCursor = encodeCursor(page.Offset + len(tasks))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHistory godoc
+//
+//	@Summary		Get task history
+//	@Description	Lists the changes made to a task, oldest first, one page at a time: who made each one (a user, an admin, or a system actor such as system:auto-complete), when, and the fields before and after. Users may only read their own tasks.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Task ID"
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(100)
+//	@Param			cursor	query		string	false	"next_cursor from the previous page"
+//	@Success		200		{object}	api.TaskHistoryResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		403		{object}	api.Problem
+//	@Failure		404		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/{id}/history [get]
+func (h *TaskHandler) GetHistory(c *gin.Context) {
+	taskID := c.Param("id")
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+
+	page, err := parsePage(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	events, more, err := h.service.GetHistory(c.Request.Context(), taskID, userID, role, page)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	resp := api.TaskHistoryResponse{
+		Count:  len(events),
+		Events: events,
+	}
+	if more {
+		resp.NextCursor = encodeCursor(page.Offset + len(events))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDependencies godoc
+//
+//	@Summary		List dependencies
+//	@Description	Lists the tasks blocking a task and the tasks it blocks. Users may only read their own tasks.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Task ID"
+//	@Success		200	{object}	api.DependencyListResponse
+//	@Failure		401	{object}	api.Problem
+//	@Failure		403	{object}	api.Problem
+//	@Failure		404	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/tasks/{id}/dependencies [get]
+func (h *TaskHandler) GetDependencies(c *gin.Context) {
+	deps, err := h.service.GetDependencies(c.Request.Context(),
+		c.Param("id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DependencyListResponse{
+		BlockedBy: deps.BlockedBy,
+		Blocks:    deps.Blocks,
+	})
+}
+
+// AddDependency godoc
+//
+//	@Summary		Add a dependency
+//	@Description	Marks a task as blocked by another task of the same owner. A task cannot be started or completed while a task blocking it is open.
+//	@Description	An edge that would make tasks block each other in a loop is refused with 400.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string					true	"ID of the blocked task"
+//	@Param			dependency	body		api.DependencyRequest	true	"Blocking task"
+//	@Success		201			{object}	api.Dependency
+//	@Failure		400			{object}	api.Problem
+//	@Failure		401			{object}	api.Problem
+//	@Failure		403			{object}	api.Problem
+//	@Failure		404			{object}	api.Problem
+//	@Failure		409			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/tasks/{id}/dependencies [post]
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	var req api.DependencyRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	dep, err := h.service.AddDependency(c.Request.Context(),
+		c.Param("id"), req.BlockerID, c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dep)
+}
+
+// RemoveDependency godoc
+//
+//	@Summary		Remove a dependency
+//	@Description	Stops a task from being blocked by another. Users may only change their own tasks.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"ID of the blocked task"
+//	@Param			blocker_id	path		string	true	"ID of the blocking task"
+//	@Success		200			{object}	api.MessageResponse
+//	@Failure		401			{object}	api.Problem
+//	@Failure		403			{object}	api.Problem
+//	@Failure		404			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/tasks/{id}/dependencies/{blocker_id} [delete]
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	err := h.service.RemoveDependency(c.Request.Context(),
+		c.Param("id"), c.Param("blocker_id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.MessageResponse{
+		Message: "dependency removed successfully",
+	})
+}
+
+// Update godoc
+//
+//	@Summary		Update a task
+//	@Description	Changes any of a task's title, description, status, tags and parent. Users may only update their own tasks.
+//	@Description	Completing a task with open subtasks is refused with 409 unless the subtask policy allows it.
+//	@Description	Starting or completing a task while a task blocking it is open is refused with 409.
+//	@Description	For an occurrence of a recurring task, scope=future also applies title, description and tags to its series and later open occurrences, and allows changing the recurrence. Completing an occurrence creates the next one unless another is still open.
+//	@Description	With If-Match, the update is refused with 412 unless the task still has one of the given ETags.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string					true	"Task ID"
+//	@Param			scope		query		string					false	"Occurrences to change"	Enums(this, future)	default(this)
+//	@Param			If-Match	header		string					false	"ETag the task must still have"
+//	@Param			task		body		api.UpdateTaskRequest	true	"Fields to change"
+//	@Success		200			{object}	api.Task
+//	@Header			200			{string}	ETag	"Entity tag of the updated task"
+//	@Failure		400			{object}	api.Problem
+//	@Failure		401			{object}	api.Problem
+//	@Failure		403			{object}	api.Problem
+//	@Failure		404			{object}	api.Problem
+//	@Failure		409			{object}	api.Problem
+//	@Failure		412			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/tasks/{id} [patch]
+func (h *TaskHandler) Update(c *gin.Context) {
+	taskID := c.Param("id")
+	userID := c.GetString("user_id")
+	role := c.GetString("role")
+
+	var req api.UpdateTaskRequest
+	if err := bindJSON(c, &req); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	task, err := h.service.UpdateTask(c.Request.Context(), taskID, userID, role, service.TaskUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+		Tags:        req.Tags,
+		ParentID:    req.ParentID,
+		Scope:       c.Query("scope"),
+		Recurrence:  recurrence(req.Recurrence),
+		IfMatch:     ifMatch(c),
+	})
+	if err != nil {
+		middleware.Fail(c, err)
 		return
 	}
 
+	c.Header("ETag", task.ETag())
 	c.JSON(http.StatusOK, task)
 }
 
+// Delete godoc
+//
+//	@Summary		Delete a task
+//	@Description	Moves a task to the trash, where it can be restored until it is purged after tasks.trash_retention_days. Users may only delete their own tasks.
+//	@Description	Its subtasks are deleted too, moved up a level, or keep the task from being deleted (409), as the subtask policy says.
+//	@Description	With If-Match, the delete is refused with 412 unless the task still has one of the given ETags.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		string	true	"Task ID"
+//	@Param			If-Match	header		string	false	"ETag the task must still have"
+//	@Success		200			{object}	api.MessageResponse
+//	@Failure		401			{object}	api.Problem
+//	@Failure		403			{object}	api.Problem
+//	@Failure		404			{object}	api.Problem
+//	@Failure		409			{object}	api.Problem
+//	@Failure		412			{object}	api.Problem
+//	@Failure		429			{object}	api.Problem
+//	@Router			/tasks/{id} [delete]
 func (h *TaskHandler) Delete(c *gin.Context) {
 	taskID := c.Param("id")
 	userID := c.GetString("user_id")
 	role := c.GetString("role")
 
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "task id required"})
+		middleware.Fail(c, errTaskIDRequired)
 		return
 	}
 
-	err := h.service.DeleteTask(taskID, userID, role)
+	err := h.service.DeleteTask(c.Request.Context(), taskID, userID, role, ifMatch(c))
 	if err != nil {
-		if err.Error() == "forbidden" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
-			return
-		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		middleware.Fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "task deleted successfully",
+	c.JSON(http.StatusOK, api.MessageResponse{
+		Message: "task deleted successfully",
 	})
 }
+
+// GetTrash godoc
+//
+//	@Summary		List deleted tasks
+//	@Description	Lists the tasks in the trash, most recently deleted first, one page at a time. Users see only their own tasks; admins see everyone's.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(100)
+//	@Param			cursor	query		string	false	"next_cursor from the previous page"
+//	@Success		200		{object}	api.TaskListResponse
+//	@Failure		400		{object}	api.Problem
+//	@Failure		401		{object}	api.Problem
+//	@Failure		429		{object}	api.Problem
+//	@Router			/tasks/trash [get]
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	tasks, more, err := h.service.GetTrash(c.Request.Context(), c.GetString("user_id"), c.GetString("role"), page)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	resp := api.TaskListResponse{
+		Count: len(tasks),
+		Tasks: tasks,
+	}
+	if more {
+		resp.NextCursor = encodeCursor(page.Offset + len(tasks))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Restore godoc
+//
+//	@Summary		Restore a deleted task
+//	@Description	Takes a task out of the trash, together with the subtasks that were deleted with it. Users may only restore their own tasks.
+//	@Description	A subtask whose parent is still in the trash cannot be restored on its own (409).
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Task ID"
+//	@Success		200	{object}	api.Task
+//	@Failure		401	{object}	api.Problem
+//	@Failure		403	{object}	api.Problem
+//	@Failure		404	{object}	api.Problem
+//	@Failure		409	{object}	api.Problem
+//	@Failure		429	{object}	api.Problem
+//	@Router			/tasks/{id}/restore [post]
+func (h *TaskHandler) Restore(c *gin.Context) {
+	task, err := h.service.RestoreTask(c.Request.Context(), c.Param("id"), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
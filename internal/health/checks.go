@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DatabaseCheck pings the database and fails when it errors or is slower
+// than maxLatency.
+func DatabaseCheck(db *sql.DB, maxLatency time.Duration) CheckFunc {
+	return func(ctx context.Context) Component {
+		start := time.Now()
+		err := db.PingContext(ctx)
+		latency := time.Since(start)
+
+		comp := Component{
+			Status:  StatusOK,
+			Details: map[string]any{"latency_ms": latency.Milliseconds()},
+		}
+		switch {
+		case err != nil:
+			comp.Status = StatusFail
+			comp.Error = err.Error()
+		case latency > maxLatency:
+			comp.Status = StatusFail
+			comp.Error = fmt.Sprintf("ping took %s (limit %s)", latency, maxLatency)
+		}
+		return comp
+	}
+}
+
+// MigrationsCheck fails while schema migrations are still pending.
+func MigrationsCheck(pending func(ctx context.Context) (int, error)) CheckFunc {
+	return func(ctx context.Context) Component {
+		n, err := pending(ctx)
+		if err != nil {
+			return Component{Status: StatusFail, Error: err.Error()}
+		}
+		comp := Component{Status: StatusOK, Details: map[string]any{"pending": n}}
+		if n > 0 {
+			comp.Status = StatusFail
+			comp.Error = fmt.Sprintf("%d migration(s) pending", n)
+		}
+		return comp
+	}
+}
+
+// WorkerCheck fails when fewer worker goroutines are running than were
+// started.
+func WorkerCheck(status func() (alive, started int)) CheckFunc {
+	return func(context.Context) Component {
+		alive, started := status()
+		comp := Component{
+			Status:  StatusOK,
+			Details: map[string]any{"alive": alive, "started": started},
+		}
+		if started == 0 || alive < started {
+			comp.Status = StatusFail
+			comp.Error = fmt.Sprintf("%d of %d workers running", alive, started)
+		}
+		return comp
+	}
+}
+
+// QueueCheck fails when the queue is at least maxSaturation full.
+func QueueCheck(depth, capacity func() int, maxSaturation float64) CheckFunc {
+	return func(context.Context) Component {
+		d, c := depth(), capacity()
+		saturation := 0.0
+		if c > 0 {
+			saturation = float64(d) / float64(c)
+		}
+		comp := Component{
+			Status: StatusOK,
+			Details: map[string]any{
+				"depth":      d,
+				"capacity":   c,
+				"saturation": saturation,
+			},
+		}
+		if saturation >= maxSaturation {
+			comp.Status = StatusFail
+			comp.Error = fmt.Sprintf("queue %.0f%% full", saturation*100)
+		}
+		return comp
+	}
+}
@@ -0,0 +1,101 @@
+// Package health implements the liveness and readiness reports served on
+// /healthz and /readyz.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Component is the result of one readiness check. Details carry
+// check-specific values such as latency or queue depth.
+type Component struct {
+	Status  Status         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// CheckFunc reports the state of one component.
+type CheckFunc func(ctx context.Context) Component
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker runs the registered checks. Once shutdown has started it reports
+// not ready regardless of the checks, so load balancers drain traffic.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+	timeout      time.Duration
+}
+
+// NewChecker returns a Checker that gives each check at most timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, fn: fn})
+}
+
+// SetShuttingDown makes every later readiness report fail.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs all checks concurrently.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Components: map[string]Component{}}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			result := check.fn(checkCtx)
+			mu.Lock()
+			report.Components[check.name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if c.shuttingDown.Load() {
+		report.Components["shutdown"] = Component{Status: StatusFail, Error: "server is shutting down"}
+	}
+	for _, comp := range report.Components {
+		if comp.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
@@ -0,0 +1,82 @@
+// Package logging configures the process-wide slog logger: JSON output,
+// a runtime-adjustable level, request IDs taken from the context and
+// automatic redaction of secrets.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Level is the active log level. It can be changed at runtime.
+var Level = new(slog.LevelVar)
+
+// Setup installs a JSON logger writing to w as the slog default and routes
+// the standard library logger through it.
+func Setup(w io.Writer, level string) {
+	SetLevel(level)
+	slog.SetDefault(New(w))
+}
+
+// New returns a JSON logger that honours Level, adds request IDs from the
+// context and redacts secrets.
+func New(w io.Writer) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       Level,
+		ReplaceAttr: redactAttr,
+	})
+	return slog.New(contextHandler{h})
+}
+
+// SetLevel parses debug, info, warn or error; anything else means info.
+func SetLevel(level string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		l = slog.LevelInfo
+	}
+	Level.Set(l)
+}
+
+type ctxKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and trace IDs from the record's
+// context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+	RegisterSecret("super-secret-jwt-key")
+
+	logger.Info("connecting",
+		"target", "taskuser:taskpass@tcp(mysql:3306)/taskdb",
+		"password", "hunter2",
+		"header", "Bearer abc.def.ghi",
+		"error", errors.New("signing with super-secret-jwt-key failed"),
+	)
+
+	out := buf.String()
+	for _, leaked := range []string{"taskpass", "hunter2", "abc.def.ghi", "super-secret-jwt-key"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log line leaks %q: %s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "taskuser:[REDACTED]@tcp(mysql:3306)/taskdb") {
+		t.Errorf("DSN not masked as expected: %s", out)
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	logger.InfoContext(ctx, "hello")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if line["request_id"] != "req-123" {
+		t.Errorf("request_id = %v, want req-123", line["request_id"])
+	}
+}
+
+func TestShortSecretsRedacted(t *testing.T) {
+	RegisterSecret("Qz")
+	if got := Redact("key=Qz"); got != "key="+redacted {
+		t.Errorf("Redact = %q, a short secret must still be redacted", got)
+	}
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute names whose values are never logged.
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "dsn", "api_key", "apikey",
+}
+
+var (
+	// user:password@ in DSNs and URLs.
+	dsnCredentials = regexp.MustCompile(`([A-Za-z0-9_.\-]+):([^@\s/]+)@`)
+	bearerToken    = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-_.~+/]+=*`)
+	jwtToken       = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// RegisterSecret makes every later log line replace occurrences of s, such
+// as the configured DB password or JWT secret. Empty strings are ignored.
+func RegisterSecret(s string) {
+	if s == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, s)
+}
+
+// Redact masks credentials and known secrets in s.
+func Redact(s string) string {
+	s = dsnCredentials.ReplaceAllString(s, "$1:"+redacted+"@")
+	s = bearerToken.ReplaceAllString(s, "${1}"+redacted)
+	s = jwtToken.ReplaceAllString(s, redacted)
+
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
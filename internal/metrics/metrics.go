@@ -0,0 +1,181 @@
+// Package metrics defines the Prometheus metrics the server exports on
+// /metrics. Every method is safe to call on a nil *Metrics, which turns
+// instrumentation off (used by unit tests that build services directly).
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "taskapi"
+
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	jobsProcessed prometheus.Counter
+	jobsFailed    prometheus.Counter
+	schedulingLag prometheus.Histogram
+	loginAttempts *prometheus.CounterVec
+
+	configReloads       *prometheus.CounterVec
+	configLastReload    prometheus.Gauge
+	configRestartNeeded prometheus.Gauge
+}
+
+// New creates the metrics on a fresh registry, including Go runtime and
+// process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		jobsProcessed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "autocomplete_jobs_processed_total",
+			Help:      "Auto-complete jobs that ran their update successfully.",
+		}),
+		jobsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "autocomplete_jobs_failed_total",
+			Help:      "Auto-complete jobs whose update returned an error.",
+		}),
+		schedulingLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "autocomplete_scheduling_lag_seconds",
+			Help:      "Time a job spent in the queue before a worker picked it up.",
+			Buckets:   []float64{.001, .01, .1, .5, 1, 5, 15, 60, 300},
+		}),
+		loginAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_login_attempts_total",
+			Help:      "Login attempts by result (success or failure).",
+		}, []string{"result"}),
+		configReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "config_reloads_total",
+			Help:      "Config reloads by result (success or failure).",
+		}, []string{"result"}),
+		configLastReload: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Unix time of the last successful config reload.",
+		}),
+		configRestartNeeded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "config_restart_required",
+			Help:      "1 when the loaded config has changes that only apply after a restart.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.jobsProcessed,
+		m.jobsFailed,
+		m.schedulingLag,
+		m.loginAttempts,
+		m.configReloads,
+		m.configLastReload,
+		m.configRestartNeeded,
+	)
+	return m
+}
+
+// RegisterDB exports sql.DB.Stats() as taskapi_db_* metrics.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	if m == nil || db == nil {
+		return
+	}
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterQueueDepth exports the current length of the auto-complete queue.
+func (m *Metrics) RegisterQueueDepth(depth func() int) {
+	if m == nil {
+		return
+	}
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "autocomplete_queue_depth",
+		Help:      "Jobs waiting in the auto-complete queue.",
+	}, func() float64 { return float64(depth()) }))
+}
+
+func (m *Metrics) ObserveHTTP(method, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+func (m *Metrics) JobProcessed() {
+	if m == nil {
+		return
+	}
+	m.jobsProcessed.Inc()
+}
+
+func (m *Metrics) JobFailed() {
+	if m == nil {
+		return
+	}
+	m.jobsFailed.Inc()
+}
+
+func (m *Metrics) ObserveSchedulingLag(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.schedulingLag.Observe(d.Seconds())
+}
+
+func (m *Metrics) LoginAttempt(success bool) {
+	if m == nil {
+		return
+	}
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.loginAttempts.WithLabelValues(result).Inc()
+}
+
+// ConfigReload records the outcome of a reload. restartRequired reports
+// whether the new config holds changes that were not applied.
+func (m *Metrics) ConfigReload(success, restartRequired bool) {
+	if m == nil {
+		return
+	}
+	if !success {
+		m.configReloads.WithLabelValues("failure").Inc()
+		return
+	}
+	m.configReloads.WithLabelValues("success").Inc()
+	m.configLastReload.SetToCurrentTime()
+	if restartRequired {
+		m.configRestartNeeded.Set(1)
+	} else {
+		m.configRestartNeeded.Set(0)
+	}
+}
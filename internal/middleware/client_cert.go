@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/x509"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMethodClientCert is stored as "auth_method" when a request was
+// authenticated by its TLS client certificate; JWTMiddleware then lets it
+// through without a token.
+const AuthMethodClientCert = "mtls"
+
+// ClientIdentity is the user and role a client certificate acts as.
+type ClientIdentity struct {
+	UserID string
+	Role   string
+}
+
+// ClientCert authenticates requests that carry a verified client
+// certificate whose subject (common name, DNS SAN or URI SAN) is in
+// identities. Other requests pass through unchanged to JWT auth.
+func ClientCert(identities map[string]ClientIdentity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := c.Request.TLS
+		if len(identities) == 0 || state == nil || len(state.VerifiedChains) == 0 {
+			c.Next()
+			return
+		}
+
+		if id, ok := lookupIdentity(state.VerifiedChains[0][0], identities); ok {
+			c.Set("user_id", id.UserID)
+			c.Set("role", id.Role)
+			c.Set("auth_method", AuthMethodClientCert)
+		}
+		c.Next()
+	}
+}
+
+func lookupIdentity(cert *x509.Certificate, identities map[string]ClientIdentity) (ClientIdentity, bool) {
+	subjects := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		subjects = append(subjects, u.String())
+	}
+	for _, s := range subjects {
+		if id, ok := identities[s]; ok && s != "" {
+			return id, true
+		}
+	}
+	return ClientIdentity{}, false
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Authorization, Content-Type, If-Match, If-None-Match, " + RequestIDHeader
+	corsExposeHeaders = RequestIDHeader + ", ETag, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After"
+	corsMaxAge        = "600"
+)
+
+// CORS answers cross-origin requests from an allow-list of origins that
+// can be swapped while the server is running.
+type CORS struct {
+	origins atomic.Pointer[map[string]bool]
+}
+
+func NewCORS(origins []string) *CORS {
+	c := &CORS{}
+	c.SetOrigins(origins)
+	return c
+}
+
+// SetOrigins replaces the allowed origins. "*" allows any origin; an empty
+// list turns CORS off.
+func (c *CORS) SetOrigins(origins []string) {
+	set := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		set[strings.TrimSuffix(o, "/")] = true
+	}
+	c.origins.Store(&set)
+}
+
+func (c *CORS) allowed(origin string) bool {
+	set := *c.origins.Load()
+	return set["*"] || set[origin]
+}
+
+// Handler sets the CORS response headers for allowed origins and answers
+// preflight requests itself. Requests from other origins get no CORS
+// headers, so browsers block them; preflights from them get 403.
+func (c *CORS) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+		ctx.Writer.Header().Add("Vary", "Origin")
+
+		preflight := ctx.Request.Method == http.MethodOptions &&
+			ctx.GetHeader("Access-Control-Request-Method") != ""
+		if !c.allowed(origin) {
+			if preflight {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Access-Control-Allow-Origin", origin)
+		if preflight {
+			ctx.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			ctx.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			ctx.Header("Access-Control-Max-Age", corsMaxAge)
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		ctx.Header("Access-Control-Expose-Headers", corsExposeHeaders)
+		ctx.Next()
+	}
+}
@@ -1,20 +1,26 @@
 package middleware
 
 import (
-	"net/http"
+	"fmt"
 	"strings"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 func JWTMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if c.GetString("auth_method") == AuthMethodClientCert {
+			c.Next()
+			return
+		}
+
 		header := c.GetHeader("Authorization")
 
 		if header == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
-			c.Abort()
+			Fail(c, fmt.Errorf("missing token: %w", apperr.ErrUnauthorized))
 			return
 		}
 
@@ -25,12 +31,17 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			Fail(c, fmt.Errorf("invalid token: %w", apperr.ErrUnauthorized))
 			return
 		}
 
 		claims := token.Claims.(jwt.MapClaims)
+		// Refresh tokens only buy new tokens. Tokens issued before the typ
+		// claim existed are access tokens.
+		if claims["typ"] == service.TokenTypeRefresh {
+			Fail(c, fmt.Errorf("refresh token used as access token: %w", apperr.ErrUnauthorized))
+			return
+		}
 
 		c.Set("user_id", claims["user_id"])
 		c.Set("role", claims["role"])
@@ -43,10 +54,7 @@ func AdminOnly() gin.HandlerFunc {
 		role := c.GetString("role")
 
 		if role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "admin access required",
-			})
-			c.Abort()
+			Fail(c, fmt.Errorf("admin access required: %w", apperr.ErrForbidden))
 			return
 		}
 
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request count and latency per route and status.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Keep label cardinality bounded for unknown paths.
+			route = "unmatched"
+		}
+		m.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAccess lets a scrape through when the peer address is inside one
+// of allowed, or when token is set and sent as a bearer token. The peer
+// address is the TCP remote address, never X-Forwarded-For.
+func MetricsAccess(allowed []*net.IPNet, token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" {
+			got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+			for _, n := range allowed {
+				if n.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		Fail(c, fmt.Errorf("metrics access denied: %w", apperr.ErrForbidden))
+	}
+}
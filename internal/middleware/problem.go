@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is the de-facto status (nginx) for requests
+// the client abandoned before a response was written.
+const StatusClientClosedRequest = 499
+
+// problemKinds maps domain errors to a status and a stable code. The first
+// match wins; anything unmatched is a 500 "internal".
+var problemKinds = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{apperr.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{apperr.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{apperr.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{apperr.ErrNotFound, http.StatusNotFound, "not_found"},
+	{apperr.ErrConflict, http.StatusConflict, "conflict"},
+	{apperr.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+	{apperr.ErrFailedDependency, http.StatusFailedDependency, "failed_dependency"},
+	{apperr.ErrTooLarge, http.StatusRequestEntityTooLarge, "too_large"},
+	{apperr.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	{apperr.ErrQuotaExceeded, http.StatusInsufficientStorage, "quota_exceeded"},
+	{apperr.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout"},
+	{context.Canceled, StatusClientClosedRequest, "client_closed_request"},
+}
+
+// NewProblem builds the problem for err. Details of unexpected errors are
+// not exposed; they are only logged.
+func NewProblem(err error) *apperr.Problem {
+	p := &apperr.Problem{Status: http.StatusInternalServerError, Code: "internal", Detail: "internal server error"}
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			p.Status, p.Code, p.Detail = k.status, k.code, err.Error()
+			break
+		}
+	}
+	switch p.Code {
+	case "timeout":
+		p.Detail = "request timed out"
+	case "client_closed_request":
+		p.Detail = "request cancelled"
+	}
+
+	var ve *apperr.ValidationError
+	if errors.As(err, &ve) {
+		p.Errors = ve.Fields
+	}
+	p.Type = "urn:task-api:problem:" + p.Code
+	p.Title = http.StatusText(p.Status)
+	if p.Status == StatusClientClosedRequest {
+		p.Title = "Client Closed Request"
+	}
+	return p
+}
+
+// Problems renders the last error a handler or middleware attached with
+// c.Error as an application/problem+json response, unless a response was
+// already written. Register it before anything that may fail a request.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		p := NewProblem(last.Err)
+		p.Instance = c.Request.URL.Path
+		p.RequestID = c.GetString("request_id")
+		c.Render(p.Status, problemRender{p})
+	}
+}
+
+// Fail attaches err to the request for Problems to render and stops the
+// handler chain.
+func Fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+type problemRender struct{ p *apperr.Problem }
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.p)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", apperr.ProblemContentType)
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit applies the named policy. Requests are keyed by the user ID set
+// by JWTMiddleware when present, otherwise by client IP, so place it after
+// JWTMiddleware on protected groups. If the store is unavailable the
+// request is let through rather than failing the API.
+func RateLimit(l *ratelimit.Limiter, policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID := c.GetString("user_id"); userID != "" {
+			key = "user:" + userID
+		}
+
+		res, p, err := l.Allow(c.Request.Context(), policy, key)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "rate limit store unavailable, allowing request",
+				"policy", policy, "error", err)
+			c.Next()
+			return
+		}
+		if res.Limit == 0 {
+			// Policy disabled.
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", p.String())
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			Fail(c, fmt.Errorf("rate limit exceeded: %w", apperr.ErrRateLimited))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,42 @@
+// Package models holds the domain types the repositories store and the
+// services work on. They are the wire types of pkg/api, so the server and
+// its clients share them.
+package models
+
+import "github.com/CashInvoice-Golang-Assignment/pkg/api"
+
+type (
+	Task          = api.Task
+	TaskStatus    = api.TaskStatus
+	TaskEvent     = api.TaskEvent
+	TaskEventType = api.TaskEventType
+	FieldChange   = api.FieldChange
+	Series        = api.Series
+	Comment       = api.Comment
+	Tag           = api.Tag
+	Dependency    = api.Dependency
+	Attachment    = api.Attachment
+	User          = api.User
+)
+
+const (
+	StatusPending    = api.StatusPending
+	StatusInProgress = api.StatusInProgress
+	StatusCompleted  = api.StatusCompleted
+)
+
+const (
+	EventCreated       = api.EventCreated
+	EventUpdated       = api.EventUpdated
+	EventStatusChanged = api.EventStatusChanged
+	EventDeleted       = api.EventDeleted
+	EventRestored      = api.EventRestored
+)
+
+const (
+	ActorUser         = api.ActorUser
+	ActorAdmin        = api.ActorAdmin
+	ActorAutoComplete = api.ActorAutoComplete
+	ActorSubtasks     = api.ActorSubtasks
+	ActorRecurrence   = api.ActorRecurrence
+)
@@ -1,23 +0,0 @@
-package models
-
-import (
-	"time"
-)
-
-type TaskStatus string
-
-const (
-	StatusPending    TaskStatus = "pending"
-	StatusInProgress TaskStatus = "in_progress"
-	StatusCompleted  TaskStatus = "completed"
-)
-
-type Task struct {
-	ID          string     `db:"id" json:"id"`
-	Title       string     `db:"title" json:"title"`
-	Description string     `db:"description" json:"description"`
-	Status      TaskStatus `db:"status" json:"status"`
-	UserID      string     `db:"user_id" json:"-"`
-	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
-}
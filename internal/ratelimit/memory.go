@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// refill is how long the bucket's own policy takes to fill it from
+	// empty, so a sweep never drops a bucket that is still draining.
+	refill time.Duration
+}
+
+// MemoryStore keeps buckets in process. Full buckets that have been idle
+// are dropped periodically so memory stays bounded by active clients.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	ops     int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+// Compile-time check
+var _ Store = (*MemoryStore)(nil)
+
+const sweepEvery = 1024
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops++
+	if s.ops%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(p.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = refill(b.tokens, now.Sub(b.last), p)
+	b.last = now
+	b.refill = secondsToDuration(float64(p.Burst) / p.rate())
+
+	if b.tokens < 1 {
+		return result(false, b.tokens, p), nil
+	}
+	b.tokens--
+	return result(true, b.tokens, p), nil
+}
+
+// sweep removes buckets that would have refilled completely by now, each
+// judged by the policy it was last taken from.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.refill {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable
+// bucket storage: in-process by default, or Redis so replicas share limits.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/clock"
+)
+
+// Route group policy names. PolicyBatch applies to POST /tasks/batch on
+// top of PolicyTasks, since one batch does the work of many requests.
+const (
+	PolicyAuth  = "auth"
+	PolicyTasks = "tasks"
+	PolicyBatch = "batch"
+)
+
+// Policy allows PerMinute requests per minute on average, with bursts of up
+// to Burst requests.
+type Policy struct {
+	PerMinute int
+	Burst     int
+}
+
+func (p Policy) rate() float64 { return float64(p.PerMinute) / 60 }
+
+// String renders the policy for the RateLimit-Policy header.
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=60;burst=%d", p.PerMinute, p.Burst)
+}
+
+// Result describes the bucket after a request was counted against it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+	// RetryAfter is how long until the next request would be allowed. It is
+	// zero when Allowed is true.
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Take removes one token from key's bucket if
+// one is available.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error)
+}
+
+// Limiter applies named policies (one per route group) on top of a Store.
+// Policies can be replaced while the server is running.
+type Limiter struct {
+	store Store
+	clock clock.Clock
+
+	mu       sync.RWMutex
+	policies map[string]Policy
+}
+
+func NewLimiter(store Store, policies map[string]Policy, clk clock.Clock) *Limiter {
+	l := &Limiter{store: store, clock: clk, policies: map[string]Policy{}}
+	for name, p := range policies {
+		l.policies[name] = p
+	}
+	return l
+}
+
+// SetPolicy replaces (or adds) the named policy.
+func (l *Limiter) SetPolicy(name string, p Policy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policies[name] = p
+}
+
+// SetPolicies replaces every policy at once. Groups missing from policies
+// are no longer limited.
+func (l *Limiter) SetPolicies(policies map[string]Policy) {
+	next := make(map[string]Policy, len(policies))
+	for name, p := range policies {
+		next[name] = p
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policies = next
+}
+
+// Policy returns the named policy and whether it exists.
+func (l *Limiter) Policy(name string) (Policy, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	p, ok := l.policies[name]
+	return p, ok
+}
+
+// Allow counts one request by key against the named policy. Keys are
+// namespaced by policy so groups never share buckets.
+func (l *Limiter) Allow(ctx context.Context, policy, key string) (Result, Policy, error) {
+	p, ok := l.Policy(policy)
+	if !ok || p.PerMinute <= 0 || p.Burst <= 0 {
+		return Result{Allowed: true}, p, nil
+	}
+	res, err := l.store.Take(ctx, policy+":"+key, p, l.clock.Now())
+	return res, p, err
+}
+
+// refill returns the token count after elapsed time, capped at the burst.
+func refill(tokens float64, elapsed time.Duration, p Policy) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(p.Burst), tokens+elapsed.Seconds()*p.rate())
+}
+
+// result builds a Result for a bucket holding tokens after the take.
+func result(allowed bool, tokens float64, p Policy) Result {
+	r := Result{
+		Allowed:    allowed,
+		Limit:      p.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((float64(p.Burst) - tokens) / p.rate()),
+	}
+	if !allowed {
+		r.RetryAfter = secondsToDuration((1 - tokens) / p.rate())
+	}
+	return r
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
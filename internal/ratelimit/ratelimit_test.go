@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func stores(t *testing.T) map[string]Store {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"redis":  NewRedisStore(client, "test:"),
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	p := Policy{PerMinute: 60, Burst: 3}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i := 0; i < 3; i++ {
+				res, err := store.Take(ctx, "k", p, start)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !res.Allowed || res.Remaining != 2-i {
+					t.Fatalf("take %d: %+v", i, res)
+				}
+			}
+
+			res, _ := store.Take(ctx, "k", p, start)
+			if res.Allowed {
+				t.Fatal("fourth request within burst window allowed")
+			}
+			if res.RetryAfter != time.Second {
+				t.Errorf("RetryAfter = %s, want 1s", res.RetryAfter)
+			}
+
+			other, _ := store.Take(ctx, "other", p, start)
+			if !other.Allowed {
+				t.Error("separate key shares the bucket")
+			}
+
+			res, _ = store.Take(ctx, "k", p, start.Add(time.Second))
+			if !res.Allowed || res.Remaining != 0 {
+				t.Errorf("after refill: %+v", res)
+			}
+
+			res, _ = store.Take(ctx, "k", p, start.Add(time.Hour))
+			if !res.Allowed || res.Remaining != 2 || res.ResetAfter != time.Second {
+				t.Errorf("after idle: %+v", res)
+			}
+		})
+	}
+}
+
+func TestMemorySweepKeepsDrainingBuckets(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	slow := Policy{PerMinute: 1, Burst: 1}
+	fast := Policy{PerMinute: 60, Burst: 1}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if res, _ := store.Take(ctx, "slow", slow, start); !res.Allowed {
+		t.Fatalf("first slow take: %+v", res)
+	}
+	// Enough fast requests to trigger a sweep, long after a fast bucket
+	// would have refilled but well before the slow one has.
+	later := start.Add(10 * time.Second)
+	for range sweepEvery {
+		store.Take(ctx, "fast", fast, later)
+	}
+	if res, _ := store.Take(ctx, "slow", slow, later); res.Allowed {
+		t.Error("sweep dropped a slow bucket that was still draining")
+	}
+}
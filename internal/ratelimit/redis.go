@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes from a bucket stored as a hash
+// {tokens, ts_ms}. Time comes from the caller so every replica applies the
+// same clock the limiter was given. Returns {allowed, tokens*1000}.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+
+local elapsed = math.max(0, now - ts) / 1000
+tokens = math.min(burst, tokens + elapsed * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, math.floor(tokens * 1000)}
+`)
+
+// RedisStore keeps buckets in Redis (or anything speaking its protocol and
+// Lua scripting), so all replicas share the same limits.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Compile-time check
+var _ Store = (*RedisStore)(nil)
+
+func (s *RedisStore) Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error) {
+	// Keep idle buckets only as long as they take to refill.
+	ttl := secondsToDuration(float64(p.Burst)/p.rate()) + time.Second
+
+	vals, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		strconv.FormatFloat(p.rate(), 'f', -1, 64),
+		p.Burst,
+		now.UnixMilli(),
+		ttl.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	tokens := float64(vals[1]) / 1000
+	return result(vals[0] == 1, tokens, p), nil
+}
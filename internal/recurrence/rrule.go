@@ -0,0 +1,307 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules
+// that recurring tasks use: FREQ=DAILY, WEEKLY or MONTHLY, with INTERVAL,
+// BYDAY and either COUNT or UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// Day is one BYDAY entry. N picks the Nth such weekday of the month,
+// counting from the end when negative; 0 means every one. N is only
+// allowed with FREQ=MONTHLY.
+type Day struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []Day
+	// Count caps the number of occurrences; 0 means no cap.
+	Count int
+	// Until is the last moment an occurrence may fall on; zero means no
+	// end. When UntilLocal is set it was given without a zone and is read
+	// as wall-clock time in the series' timezone.
+	Until      time.Time
+	UntilLocal bool
+}
+
+const (
+	maxInterval = 1000
+	maxCount    = 10000
+	// maxEmptyPeriods bounds the search for the next occurrence, so a
+	// rule such as BYDAY=5FR with a large INTERVAL cannot spin forever.
+	maxEmptyPeriods = 100
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Parse parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE". A leading
+// "RRULE:" is allowed. Parts outside the supported subset are rejected.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rule is empty")
+	}
+
+	r := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	for part := range strings.SplitSeq(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%q is not NAME=VALUE", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				err = errors.New("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			r.Interval, err = parseInt(name, value, 1, maxInterval)
+		case "COUNT":
+			r.Count, err = parseInt(name, value, 1, maxCount)
+		case "UNTIL":
+			r.Until, r.UntilLocal, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "WKST":
+			// Weeks always start on Monday, the RFC default.
+			if strings.ToUpper(value) != "MO" {
+				err = errors.New("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return nil, errors.New("FREQ is required")
+	case r.Count > 0 && !r.Until.IsZero():
+		return nil, errors.New("COUNT and UNTIL cannot both be given")
+	}
+	if r.Freq != Monthly {
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				return nil, errors.New("numbered BYDAY entries such as 1MO need FREQ=MONTHLY")
+			}
+		}
+	}
+	return r, nil
+}
+
+func parseInt(name, value string, lo, hi int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("%s must be a number from %d to %d", name, lo, hi)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, true, nil
+	}
+	// A date alone includes the whole day.
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), true, nil
+	}
+	return time.Time{}, false, errors.New("UNTIL must look like 20250131, 20250131T090000 or 20250131T090000Z")
+}
+
+func parseByDay(value string) ([]Day, error) {
+	var days []Day
+	for entry := range strings.SplitSeq(strings.ToUpper(value), ",") {
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("BYDAY entry %q is not a weekday", entry)
+		}
+		wd, ok := weekdays[entry[len(entry)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("BYDAY entry %q is not a weekday", entry)
+		}
+		d := Day{Weekday: wd}
+		if prefix := entry[:len(entry)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("BYDAY entry %q must be numbered from -5 to 5", entry)
+			}
+			d.N = n
+		}
+		if !slices.Contains(days, d) {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+// String formats r in a canonical form.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = strings.ToUpper(d.Weekday.String()[:2])
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	switch {
+	case r.Count > 0:
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	case r.UntilLocal:
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+	case !r.Until.IsZero():
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences yields the occurrences of r on or after start, in order and
+// in start's location. Every occurrence has start's wall-clock time of
+// day. start itself is only yielded when it matches the rule.
+func (r *Rule) Occurrences(start time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		until := r.Until
+		if r.UntilLocal {
+			until = time.Date(until.Year(), until.Month(), until.Day(),
+				until.Hour(), until.Minute(), until.Second(), 0, start.Location())
+		}
+
+		n := 0
+		for period, empty := 0, 0; empty < maxEmptyPeriods; period++ {
+			found := false
+			for _, t := range r.period(start, period) {
+				if t.Before(start) {
+					continue
+				}
+				if !until.IsZero() && t.After(until) {
+					return
+				}
+				found = true
+				if !yield(t) {
+					return
+				}
+				if n++; r.Count > 0 && n >= r.Count {
+					return
+				}
+			}
+			if found {
+				empty = 0
+			} else {
+				empty++
+			}
+		}
+	}
+}
+
+// After returns the first occurrence of the series starting at start that
+// falls after t, or false when there is none.
+func (r *Rule) After(start, t time.Time) (time.Time, bool) {
+	for occ := range r.Occurrences(start) {
+		if occ.After(t) {
+			return occ, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// period returns the candidate occurrences in the period'th day, week or
+// month of the rule, counting start's as 0, sorted.
+func (r *Rule) period(start time.Time, period int) []time.Time {
+	y, m, d := start.Date()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+	step := period * r.Interval
+
+	var out []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+step)
+		if len(r.ByDay) == 0 || r.hasWeekday(t.Weekday()) {
+			out = append(out, t)
+		}
+
+	case Weekly:
+		// Monday of start's week, then step weeks on.
+		monday := d - (int(start.Weekday())+6)%7 + 7*step
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(y, m, monday+(int(start.Weekday())+6)%7)}
+		}
+		for _, bd := range r.ByDay {
+			out = append(out, at(y, m, monday+(int(bd.Weekday)+6)%7))
+		}
+
+	case Monthly:
+		first := time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, start.Location())
+		fy, fm, _ := first.Date()
+		days := daysIn(fy, fm)
+		if len(r.ByDay) == 0 {
+			if d <= days {
+				out = append(out, at(fy, fm, d))
+			}
+			break
+		}
+		for _, bd := range r.ByDay {
+			for day := 1; day <= days; day++ {
+				if time.Date(fy, fm, day, 0, 0, 0, 0, time.UTC).Weekday() != bd.Weekday {
+					continue
+				}
+				nth, fromEnd := (day-1)/7+1, -((days-day)/7 + 1)
+				if bd.N == 0 || bd.N == nth || bd.N == fromEnd {
+					out = append(out, at(fy, fm, day))
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(out, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(out, time.Time.Equal)
+}
+
+func (r *Rule) hasWeekday(wd time.Weekday) bool {
+	return slices.ContainsFunc(r.ByDay, func(d Day) bool { return d.Weekday == wd })
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
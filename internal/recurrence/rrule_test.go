@@ -0,0 +1,104 @@
+package recurrence
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]string{
+		"FREQ=DAILY":                            "FREQ=DAILY",
+		"RRULE:freq=weekly;byday=mo,we,mo":      "FREQ=WEEKLY;BYDAY=MO,WE",
+		"FREQ=MONTHLY;BYDAY=-1FR;INTERVAL=2":    "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR",
+		"FREQ=DAILY;COUNT=3;WKST=MO":            "FREQ=DAILY;COUNT=3",
+		"FREQ=DAILY;UNTIL=20250110":             "FREQ=DAILY;UNTIL=20250110T235959",
+		"FREQ=WEEKLY;UNTIL=20250110T090000Z":    "FREQ=WEEKLY;UNTIL=20250110T090000Z",
+		"FREQ=MONTHLY;BYDAY=1MO,3MO;INTERVAL=1": "FREQ=MONTHLY;BYDAY=1MO,3MO",
+	} {
+		r, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", in, err)
+			continue
+		}
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;WKST=SU",
+	} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded", in)
+		}
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wednesday 2025-01-15 09:00.
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, berlin)
+
+	for _, tc := range []struct {
+		rule  string
+		start time.Time
+		want  []string
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", start, []string{"2025-01-15", "2025-01-17", "2025-01-19"}},
+		{"FREQ=DAILY;BYDAY=MO,FR;COUNT=3", start, []string{"2025-01-17", "2025-01-20", "2025-01-24"}},
+		{"FREQ=WEEKLY;COUNT=2", start, []string{"2025-01-15", "2025-01-22"}},
+		// Monday of the first week is before start, so it is skipped.
+		{"FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4", start, []string{"2025-01-16", "2025-01-20", "2025-01-23", "2025-01-27"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=WE;UNTIL=20250212", start, []string{"2025-01-15", "2025-01-29", "2025-02-12"}},
+		{"FREQ=MONTHLY;COUNT=3", start, []string{"2025-01-15", "2025-02-15", "2025-03-15"}},
+		// Months without a 31st are skipped.
+		{"FREQ=MONTHLY;COUNT=3", time.Date(2025, 1, 31, 9, 0, 0, 0, berlin), []string{"2025-01-31", "2025-03-31", "2025-05-31"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", start, []string{"2025-01-31", "2025-02-28", "2025-03-28"}},
+		{"FREQ=MONTHLY;BYDAY=1MO,3MO;COUNT=3", start, []string{"2025-01-20", "2025-02-03", "2025-02-17"}},
+	} {
+		r, err := Parse(tc.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for occ := range r.Occurrences(tc.start) {
+			if occ.Hour() != 9 || occ.Location() != berlin {
+				t.Errorf("%s: occurrence %v is not at 09:00 Berlin time", tc.rule, occ)
+			}
+			got = append(got, occ.Format("2006-01-02"))
+			if len(got) > 10 {
+				break
+			}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s from %s = %v, want %v", tc.rule, tc.start.Format("2006-01-02"), got, tc.want)
+		}
+	}
+
+	// Wall-clock time holds across the DST change on 2025-03-30.
+	r, _ := Parse("FREQ=DAILY")
+	next, ok := r.After(time.Date(2025, 3, 29, 9, 0, 0, 0, berlin), time.Date(2025, 3, 29, 9, 0, 0, 0, berlin))
+	if !ok || next.Hour() != 9 || next.Sub(time.Date(2025, 3, 29, 9, 0, 0, 0, berlin)) != 23*time.Hour {
+		t.Errorf("After across DST = %v", next)
+	}
+
+	r, _ = Parse("FREQ=DAILY;COUNT=2")
+	if _, ok := r.After(start, start.AddDate(0, 0, 1)); ok {
+		t.Error("After past the last occurrence returned one")
+	}
+}
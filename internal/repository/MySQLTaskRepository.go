@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
 	"github.com/CashInvoice-Golang-Assignment/internal/models"
 )
 
@@ -19,34 +22,56 @@ func NewMySQLTaskRepository(db *sql.DB) *MySQLTaskRepository {
 // Compile-time check
 var _ TaskRepository = (*MySQLTaskRepository)(nil)
 
-func (r *MySQLTaskRepository) Create(task *models.Task) error {
-	query := `
+const mysqlInsertTag = `
+        INSERT INTO tags (id, user_id, name, created_at)
+        VALUES (?, ?, ?, ?)
+        ON DUPLICATE KEY UPDATE id = id
+    `
+
+const mysqlInsertTask = `
         INSERT INTO tasks (
             id,
             title,
             description,
             status,
             user_id,
+            parent_id,
+            series_id,
+            occurs_at,
             created_at,
             updated_at
-        ) VALUES (?, ?, ?, ?, ?, ?, ?)
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
     `
 
-	_, err := r.db.Exec(
-		query,
+func (r *MySQLTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.create(ctx, tx, task)
+	})
+}
+
+func (r *MySQLTaskRepository) create(ctx context.Context, tx *sql.Tx, task *models.Task) error {
+	_, err := tx.ExecContext(
+		ctx,
+		mysqlInsertTask,
 		task.ID,
 		task.Title,
 		task.Description,
 		task.Status,
 		task.UserID,
+		task.ParentID,
+		task.SeriesID,
+		nullTime(task.OccursAt),
 		task.CreatedAt,
 		task.UpdatedAt,
 	)
-
-	return err
+	if err != nil {
+		return conflictOnDuplicate(err, "task already exists")
+	}
+	task.Version = 1
+	return replaceTaskTags(ctx, tx, mysqlInsertTag, task, task.CreatedAt)
 }
 
-func (r *MySQLTaskRepository) GetByID(id string) (*models.Task, error) {
+func (r *MySQLTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
 	query := `
         SELECT 
             id,
@@ -54,73 +79,63 @@ func (r *MySQLTaskRepository) GetByID(id string) (*models.Task, error) {
             description,
             status,
             user_id,
+            parent_id,
+            series_id,
+            occurs_at,
             created_at,
-            updated_at
+            updated_at,
+            deleted_at,
+            version
         FROM tasks
         WHERE id = ?
+          AND deleted_at IS NULL
     `
 
 	var task models.Task
 	var status string
+	var occursAt, deletedAt sql.NullString
 	var createdAtStr, updatedAtStr string
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&task.ID,
 		&task.Title,
 		&task.Description,
 		&status,
 		&task.UserID,
+		&task.ParentID,
+		&task.SeriesID,
+		&occursAt,
 		&createdAtStr,
 		&updatedAtStr,
+		&deletedAt,
+		&task.Version,
 	)
-	task.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
-	task.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, errors.New("task not found")
+		return nil, fmt.Errorf("task %w", apperr.ErrNotFound)
 	}
 
 	if err != nil {
 		return nil, err
 	}
+	task.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
+	task.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr)
+	task.Status = models.TaskStatus(status)
+	task.OccursAt = parseNullTime(occursAt)
+	task.DeletedAt = parseNullTime(deletedAt)
 
-	return &task, nil
-}
-
-func (r *MySQLTaskRepository) GetAll(userID string, isAdmin bool) ([]models.Task, error) {
-	var (
-		rows *sql.Rows
-		err  error
-	)
-
-	if isAdmin {
-		rows, err = r.db.Query(`
-            SELECT 
-                id,
-                title,
-                description,
-                status,
-                user_id,
-                created_at,
-                updated_at
-            FROM tasks
-            ORDER BY created_at DESC
-        `)
-	} else {
-		rows, err = r.db.Query(`
-            SELECT 
-                id,
-                title,
-                description,
-                status,
-                user_id,
-                created_at,
-                updated_at
-            FROM tasks
-            WHERE user_id = ?
-            ORDER BY created_at DESC
-        `, userID)
+	tasks := []models.Task{task}
+	if err := loadTaskTags(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadTaskProgress(ctx, r.db, tasks); err != nil {
+		return nil, err
 	}
+	return &tasks[0], nil
+}
 
+func (r *MySQLTaskRepository) List(ctx context.Context, q TaskQuery) ([]models.Task, error) {
+	query, args := listTasksQuery(q)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +146,7 @@ func (r *MySQLTaskRepository) GetAll(userID string, isAdmin bool) ([]models.Task
 	for rows.Next() {
 		var task models.Task
 		var status string
+		var occursAt, deletedAt sql.NullString
 		var createdAtStr, updatedAtStr string
 
 		err := rows.Scan(
@@ -139,8 +155,13 @@ func (r *MySQLTaskRepository) GetAll(userID string, isAdmin bool) ([]models.Task
 			&task.Description,
 			&status,
 			&task.UserID,
+			&task.ParentID,
+			&task.SeriesID,
+			&occursAt,
 			&createdAtStr,
 			&updatedAtStr,
+			&deletedAt,
+			&task.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -148,39 +169,79 @@ func (r *MySQLTaskRepository) GetAll(userID string, isAdmin bool) ([]models.Task
 		task.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
 		task.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAtStr)
 		task.Status = models.TaskStatus(status)
+		task.OccursAt = parseNullTime(occursAt)
+		task.DeletedAt = parseNullTime(deletedAt)
 		tasks = append(tasks, task)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
+	if err := loadTaskTags(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadTaskProgress(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
 	return tasks, nil
 }
 
-func (r *MySQLTaskRepository) Delete(id string) error {
-	result, err := r.db.Exec(
-		"DELETE FROM tasks WHERE id = ?",
-		id,
+func (r *MySQLTaskRepository) Update(ctx context.Context, task *models.Task) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.update(ctx, tx, task)
+	})
+}
+
+func (r *MySQLTaskRepository) update(ctx context.Context, tx *sql.Tx, task *models.Task) error {
+	result, err := tx.ExecContext(
+		ctx,
+		`
+        UPDATE tasks
+        SET title = ?, description = ?, status = ?, parent_id = ?, updated_at = ?, version = version + 1
+        WHERE id = ?
+          AND version = ?
+          AND deleted_at IS NULL
+        `,
+		task.Title,
+		task.Description,
+		task.Status,
+		task.ParentID,
+		task.UpdatedAt,
+		task.ID,
+		task.Version,
 	)
-	if err != nil {
+	if err := expectVersion(ctx, tx, task, result, err); err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	return replaceTaskTags(ctx, tx, mysqlInsertTag, task, task.UpdatedAt)
+}
 
-	if rows == 0 {
-		return errors.New("task not found")
-	}
+func (r *MySQLTaskRepository) Apply(ctx context.Context, b TaskBatch) error {
+	return applyBatch(ctx, r.db, b, r.create, r.update)
+}
 
-	return nil
+func (r *MySQLTaskRepository) Delete(ctx context.Context, task *models.Task, at time.Time) error {
+	return trashTask(ctx, r.db, task, at)
 }
 
-func (r *MySQLTaskRepository) UpdateStatus(id string, status string) error {
-	result, err := r.db.Exec(
+func (r *MySQLTaskRepository) Restore(ctx context.Context, id string) error {
+	return restoreTask(ctx, r.db, id)
+}
+
+func (r *MySQLTaskRepository) Purge(ctx context.Context, id string) error {
+	return purgeTask(ctx, r.db, id)
+}
+
+func (r *MySQLTaskRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	result, err := r.db.ExecContext(
+		ctx,
 		`
         UPDATE tasks
-        SET status = ?, updated_at = NOW()
+        SET status = ?, updated_at = NOW(), version = version + 1
         WHERE id = ?
+          AND deleted_at IS NULL
         `,
 		status,
 		id,
@@ -195,28 +256,11 @@ func (r *MySQLTaskRepository) UpdateStatus(id string, status string) error {
 	}
 
 	if rows == 0 {
-		return errors.New("task not found")
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
 	}
 
 	return nil
 }
-func (r *MySQLTaskRepository) AutoCompleteIfPending(id string) error {
-	result, err := r.db.Exec(`
-        UPDATE tasks
-        SET status = 'completed', updated_at = NOW()
-        WHERE id = ?
-          AND status IN ('pending', 'in_progress')
-    `, id)
-
-	if err != nil {
-		return err
-	}
-
-	rows, _ := result.RowsAffected()
-
-	if rows == 0 {
-		return nil
-	}
-
-	return nil
+func (r *MySQLTaskRepository) AutoCompleteIfPending(ctx context.Context, id string) (models.TaskStatus, error) {
+	return autoCompleteIfPending(ctx, r.db, id)
 }
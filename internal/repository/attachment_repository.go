@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// AttachmentQuery selects a page of one task's attachments, oldest first.
+type AttachmentQuery struct {
+	TaskID string
+	// Limit caps the number of attachments returned; 0 means no cap.
+	Limit  int
+	Offset int
+}
+
+// AttachmentRepository stores the metadata of task attachments. Their
+// content is kept in a blob.Store.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *models.Attachment) error
+	GetByID(ctx context.Context, id string) (*models.Attachment, error)
+	List(ctx context.Context, q AttachmentQuery) ([]models.Attachment, error)
+	Delete(ctx context.Context, id string) error
+	// DeleteByTask removes every attachment of a task. A task without
+	// attachments is not an error.
+	DeleteByTask(ctx context.Context, taskID string) error
+	// Usage returns the total size of the attachments counted against
+	// userID.
+	Usage(ctx context.Context, userID string) (int64, error)
+}
+
+// SQLAttachmentRepository implements AttachmentRepository for MySQL and
+// SQLite.
+type SQLAttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewSQLAttachmentRepository(db *sql.DB) *SQLAttachmentRepository {
+	return &SQLAttachmentRepository{db: db}
+}
+
+// Compile-time check
+var _ AttachmentRepository = (*SQLAttachmentRepository)(nil)
+
+const selectAttachments = `
+        SELECT id, task_id, user_id, uploader_id, name, size, content_type, checksum, created_at
+        FROM task_attachments`
+
+func (r *SQLAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	// Both dialects accept this layout for created_at.
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO task_attachments (
+            id,
+            task_id,
+            user_id,
+            uploader_id,
+            name,
+            size,
+            content_type,
+            checksum,
+            created_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+		attachment.ID,
+		attachment.TaskID,
+		attachment.UserID,
+		attachment.UploaderID,
+		attachment.Name,
+		attachment.Size,
+		attachment.ContentType,
+		attachment.Checksum,
+		attachment.CreatedAt.UTC().Format(sqliteTimeLayout),
+	)
+
+	return conflictOnDuplicate(err, "attachment already exists")
+}
+
+func (r *SQLAttachmentRepository) GetByID(ctx context.Context, id string) (*models.Attachment, error) {
+	row := r.db.QueryRowContext(ctx, selectAttachments+`
+        WHERE id = ?
+    `, id)
+
+	attachment, err := scanAttachment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("attachment %w", apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (r *SQLAttachmentRepository) List(ctx context.Context, q AttachmentQuery) ([]models.Attachment, error) {
+	query := selectAttachments + `
+        WHERE task_id = ?
+        ORDER BY created_at, id`
+	args := []any{q.TaskID}
+	if q.Limit > 0 {
+		query += `
+        LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *SQLAttachmentRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM task_attachments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("attachment %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *SQLAttachmentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM task_attachments WHERE task_id = ?", taskID)
+	return err
+}
+
+func (r *SQLAttachmentRepository) Usage(ctx context.Context, userID string) (int64, error) {
+	var usage int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(size), 0) FROM task_attachments WHERE user_id = ?", userID,
+	).Scan(&usage)
+	return usage, err
+}
+
+// scanAttachment reads a row in selectAttachments' column order. Both
+// drivers return timestamps as text in sqliteTimeLayout.
+func scanAttachment(s rowScanner) (*models.Attachment, error) {
+	var attachment models.Attachment
+	var createdAtStr string
+
+	err := s.Scan(
+		&attachment.ID,
+		&attachment.TaskID,
+		&attachment.UserID,
+		&attachment.UploaderID,
+		&attachment.Name,
+		&attachment.Size,
+		&attachment.ContentType,
+		&attachment.Checksum,
+		&createdAtStr,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAtStr)
+	return &attachment, nil
+}
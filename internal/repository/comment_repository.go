@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// CommentQuery selects a page of one task's comments, oldest first.
+type CommentQuery struct {
+	TaskID string
+	// Limit caps the number of comments returned; 0 means no cap.
+	Limit  int
+	Offset int
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	GetByID(ctx context.Context, id string) (*models.Comment, error)
+	List(ctx context.Context, q CommentQuery) ([]models.Comment, error)
+	// Update overwrites the comment's body, edited flag and updated_at.
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id string) error
+	// DeleteByTask removes every comment on a task. A task without
+	// comments is not an error.
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+// listCommentsQuery builds the SELECT for q. The SQL is the same for MySQL
+// and SQLite.
+func listCommentsQuery(q CommentQuery) (string, []any) {
+	query := `
+        SELECT id, task_id, author_id, body, edited, created_at, updated_at
+        FROM task_comments
+        WHERE task_id = ?
+        ORDER BY created_at, id`
+	args := []any{q.TaskID}
+	if q.Limit > 0 {
+		query += `
+        LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+	return query, args
+}
+
+// scanComment reads a row in listCommentsQuery's column order. Both
+// drivers return timestamps as text in sqliteTimeLayout.
+func scanComment(s rowScanner) (*models.Comment, error) {
+	var comment models.Comment
+	var createdAtStr, updatedAtStr string
+
+	err := s.Scan(
+		&comment.ID,
+		&comment.TaskID,
+		&comment.AuthorID,
+		&comment.Body,
+		&comment.Edited,
+		&createdAtStr,
+		&updatedAtStr,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAtStr)
+	comment.UpdatedAt, _ = time.Parse(sqliteTimeLayout, updatedAtStr)
+	return &comment, nil
+}
+
+func scanComments(rows *sql.Rows) ([]models.Comment, error) {
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+
+	return comments, rows.Err()
+}
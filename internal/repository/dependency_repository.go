@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// DependencyRepository stores the "blocked by" edges between tasks. It
+// does not check that the tasks exist or that the edges form no cycle;
+// DependencyService does.
+type DependencyRepository interface {
+	// Add fails with apperr.ErrConflict when the edge already exists.
+	Add(ctx context.Context, dep *models.Dependency) error
+	Remove(ctx context.Context, taskID, blockerID string) error
+	// BlockersOf returns the edges from any of taskIDs to their blockers.
+	BlockersOf(ctx context.Context, taskIDs ...string) ([]models.Dependency, error)
+	// DependentsOf returns the edges to blockerID from the tasks it blocks.
+	DependentsOf(ctx context.Context, blockerID string) ([]models.Dependency, error)
+	// DeleteByTask removes every edge to or from taskID.
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+// SQLDependencyRepository implements DependencyRepository for MySQL and
+// SQLite.
+type SQLDependencyRepository struct {
+	db *sql.DB
+}
+
+func NewSQLDependencyRepository(db *sql.DB) *SQLDependencyRepository {
+	return &SQLDependencyRepository{db: db}
+}
+
+// Compile-time check
+var _ DependencyRepository = (*SQLDependencyRepository)(nil)
+
+func (r *SQLDependencyRepository) Add(ctx context.Context, dep *models.Dependency) error {
+	// Both dialects accept this layout for created_at.
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO task_dependencies (task_id, blocker_id, created_at)
+        VALUES (?, ?, ?)
+    `, dep.TaskID, dep.BlockerID, dep.CreatedAt.UTC().Format(sqliteTimeLayout))
+
+	return conflictOnDuplicate(err, "dependency already exists")
+}
+
+func (r *SQLDependencyRepository) Remove(ctx context.Context, taskID, blockerID string) error {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM task_dependencies
+        WHERE task_id = ? AND blocker_id = ?
+    `, taskID, blockerID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("dependency %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *SQLDependencyRepository) BlockersOf(ctx context.Context, taskIDs ...string) ([]models.Dependency, error) {
+	if len(taskIDs) == 0 {
+		return []models.Dependency{}, nil
+	}
+	args := make([]any, len(taskIDs))
+	for i, id := range taskIDs {
+		args[i] = id
+	}
+
+	return r.list(ctx, `WHERE task_id IN (`+placeholders(len(args))+`)`, args...)
+}
+
+func (r *SQLDependencyRepository) DependentsOf(ctx context.Context, blockerID string) ([]models.Dependency, error) {
+	return r.list(ctx, `WHERE blocker_id = ?`, blockerID)
+}
+
+func (r *SQLDependencyRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	_, err := r.db.ExecContext(ctx, `
+        DELETE FROM task_dependencies
+        WHERE task_id = ? OR blocker_id = ?
+    `, taskID, taskID)
+
+	return err
+}
+
+func (r *SQLDependencyRepository) list(ctx context.Context, where string, args ...any) ([]models.Dependency, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT task_id, blocker_id, created_at
+        FROM task_dependencies
+        `+where+`
+        ORDER BY created_at, task_id, blocker_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := []models.Dependency{}
+	for rows.Next() {
+		var dep models.Dependency
+		var createdAtStr string
+		if err := rows.Scan(&dep.TaskID, &dep.BlockerID, &createdAtStr); err != nil {
+			return nil, err
+		}
+		dep.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAtStr)
+		deps = append(deps, dep)
+	}
+
+	return deps, rows.Err()
+}
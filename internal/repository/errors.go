@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrDupEntry = 1062
+
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// conflictOnDuplicate turns a unique or primary key violation from either
+// driver into apperr.ErrConflict, described by what. Other errors are
+// returned unchanged.
+func conflictOnDuplicate(err error, what string) error {
+	if err == nil {
+		return nil
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == mysqlErrDupEntry {
+		return fmt.Errorf("%s: %w", what, apperr.ErrConflict)
+	}
+	// modernc.org/sqlite errors expose the extended result code.
+	var liteErr interface{ Code() int }
+	if errors.As(err, &liteErr) {
+		switch liteErr.Code() {
+		case sqliteConstraintPrimaryKey, sqliteConstraintUnique:
+			return fmt.Errorf("%s: %w", what, apperr.ErrConflict)
+		}
+	}
+	return err
+}
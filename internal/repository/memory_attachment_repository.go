@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemoryAttachmentRepository is the in-memory counterpart of
+// SQLAttachmentRepository.
+type MemoryAttachmentRepository struct {
+	mu          sync.RWMutex
+	attachments map[string]models.Attachment
+}
+
+func NewMemoryAttachmentRepository() *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{attachments: map[string]models.Attachment{}}
+}
+
+// Compile-time check
+var _ AttachmentRepository = (*MemoryAttachmentRepository)(nil)
+
+func (r *MemoryAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attachments[attachment.ID]; ok {
+		return fmt.Errorf("attachment already exists: %w", apperr.ErrConflict)
+	}
+	r.attachments[attachment.ID] = *attachment
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) GetByID(ctx context.Context, id string) (*models.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return nil, fmt.Errorf("attachment %w", apperr.ErrNotFound)
+	}
+	return &attachment, nil
+}
+
+func (r *MemoryAttachmentRepository) List(ctx context.Context, q AttachmentQuery) ([]models.Attachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachments := []models.Attachment{}
+	for _, attachment := range r.attachments {
+		if attachment.TaskID == q.TaskID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	// Same order as the SQL repository: oldest first, ties by ID.
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return attachments[i].ID < attachments[j].ID
+	})
+
+	attachments = attachments[min(q.Offset, len(attachments)):]
+	if q.Limit > 0 && len(attachments) > q.Limit {
+		attachments = attachments[:q.Limit]
+	}
+	return attachments, nil
+}
+
+func (r *MemoryAttachmentRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attachments[id]; !ok {
+		return fmt.Errorf("attachment %w", apperr.ErrNotFound)
+	}
+	delete(r.attachments, id)
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, attachment := range r.attachments {
+		if attachment.TaskID == taskID {
+			delete(r.attachments, id)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryAttachmentRepository) Usage(ctx context.Context, userID string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var usage int64
+	for _, attachment := range r.attachments {
+		if attachment.UserID == userID {
+			usage += attachment.Size
+		}
+	}
+	return usage, nil
+}
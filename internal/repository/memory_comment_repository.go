@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemoryCommentRepository is the in-memory counterpart of
+// MySQLCommentRepository.
+type MemoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments map[string]models.Comment
+}
+
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{comments: map[string]models.Comment{}}
+}
+
+// Compile-time check
+var _ CommentRepository = (*MemoryCommentRepository)(nil)
+
+func (r *MemoryCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[comment.ID]; ok {
+		return fmt.Errorf("comment already exists: %w", apperr.ErrConflict)
+	}
+	r.comments[comment.ID] = *comment
+	return nil
+}
+
+func (r *MemoryCommentRepository) GetByID(ctx context.Context, id string) (*models.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+	return &comment, nil
+}
+
+func (r *MemoryCommentRepository) List(ctx context.Context, q CommentQuery) ([]models.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range r.comments {
+		if comment.TaskID == q.TaskID {
+			comments = append(comments, comment)
+		}
+	}
+	// Same order as the SQL repositories: oldest first, ties by ID.
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+
+	comments = comments[min(q.Offset, len(comments)):]
+	if q.Limit > 0 && len(comments) > q.Limit {
+		comments = comments[:q.Limit]
+	}
+	return comments, nil
+}
+
+func (r *MemoryCommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.comments[comment.ID]
+	if !ok {
+		return fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+	existing.Body = comment.Body
+	existing.Edited = comment.Edited
+	existing.UpdatedAt = comment.UpdatedAt
+	r.comments[comment.ID] = existing
+	return nil
+}
+
+func (r *MemoryCommentRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+func (r *MemoryCommentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, comment := range r.comments {
+		if comment.TaskID == taskID {
+			delete(r.comments, id)
+		}
+	}
+	return nil
+}
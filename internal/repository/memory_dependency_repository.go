@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemoryDependencyRepository is the in-memory counterpart of
+// SQLDependencyRepository. It keeps its edges in a MemoryTaskRepository so
+// AutoCompleteIfPending can see them.
+type MemoryDependencyRepository struct {
+	tasks *MemoryTaskRepository
+}
+
+func NewMemoryDependencyRepository(tasks *MemoryTaskRepository) *MemoryDependencyRepository {
+	return &MemoryDependencyRepository{tasks: tasks}
+}
+
+// Compile-time check
+var _ DependencyRepository = (*MemoryDependencyRepository)(nil)
+
+func (r *MemoryDependencyRepository) Add(ctx context.Context, dep *models.Dependency) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	blockers := r.tasks.deps[dep.TaskID]
+	if _, ok := blockers[dep.BlockerID]; ok {
+		return fmt.Errorf("dependency already exists: %w", apperr.ErrConflict)
+	}
+	if blockers == nil {
+		blockers = map[string]time.Time{}
+		r.tasks.deps[dep.TaskID] = blockers
+	}
+	blockers[dep.BlockerID] = dep.CreatedAt
+	return nil
+}
+
+func (r *MemoryDependencyRepository) Remove(ctx context.Context, taskID, blockerID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	if _, ok := r.tasks.deps[taskID][blockerID]; !ok {
+		return fmt.Errorf("dependency %w", apperr.ErrNotFound)
+	}
+	delete(r.tasks.deps[taskID], blockerID)
+	return nil
+}
+
+func (r *MemoryDependencyRepository) BlockersOf(ctx context.Context, taskIDs ...string) ([]models.Dependency, error) {
+	return r.list(ctx, func(taskID, _ string) bool { return slices.Contains(taskIDs, taskID) })
+}
+
+func (r *MemoryDependencyRepository) DependentsOf(ctx context.Context, blockerID string) ([]models.Dependency, error) {
+	return r.list(ctx, func(_, id string) bool { return id == blockerID })
+}
+
+func (r *MemoryDependencyRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	delete(r.tasks.deps, taskID)
+	for _, blockers := range r.tasks.deps {
+		delete(blockers, taskID)
+	}
+	return nil
+}
+
+func (r *MemoryDependencyRepository) list(ctx context.Context, match func(taskID, blockerID string) bool) ([]models.Dependency, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.tasks.mu.RLock()
+	defer r.tasks.mu.RUnlock()
+
+	deps := []models.Dependency{}
+	for taskID, blockers := range r.tasks.deps {
+		for blockerID, createdAt := range blockers {
+			if match(taskID, blockerID) {
+				deps = append(deps, models.Dependency{TaskID: taskID, BlockerID: blockerID, CreatedAt: createdAt})
+			}
+		}
+	}
+	// Same order as SQLDependencyRepository.
+	sort.Slice(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if a.TaskID != b.TaskID {
+			return a.TaskID < b.TaskID
+		}
+		return a.BlockerID < b.BlockerID
+	})
+	return deps, nil
+}
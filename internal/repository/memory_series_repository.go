@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemorySeriesRepository keeps series in a map. Like MemoryTaskRepository
+// it is safe for concurrent use and meant for tests.
+type MemorySeriesRepository struct {
+	mu     sync.Mutex
+	series map[string]models.Series
+}
+
+func NewMemorySeriesRepository() *MemorySeriesRepository {
+	return &MemorySeriesRepository{series: map[string]models.Series{}}
+}
+
+// Compile-time check
+var _ SeriesRepository = (*MemorySeriesRepository)(nil)
+
+// Times are kept to the second, like the SQL columns, so Advance compares
+// the same values the SQL repository would.
+func storedSeries(s models.Series) models.Series {
+	s.Tags = slices.Clone(s.Tags)
+	if s.Tags == nil {
+		s.Tags = []string{}
+	}
+	s.StartsAt = s.StartsAt.UTC().Truncate(time.Second)
+	if s.NextAt != nil {
+		next := s.NextAt.UTC().Truncate(time.Second)
+		s.NextAt = &next
+	}
+	return s
+}
+
+func (r *MemorySeriesRepository) Create(ctx context.Context, s *models.Series) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.series[s.ID]; ok {
+		return fmt.Errorf("series already exists: %w", apperr.ErrConflict)
+	}
+	r.series[s.ID] = storedSeries(*s)
+	return nil
+}
+
+func (r *MemorySeriesRepository) GetByID(ctx context.Context, id string) (*models.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.series[id]
+	if !ok {
+		return nil, fmt.Errorf("series %w", apperr.ErrNotFound)
+	}
+	s = storedSeries(s)
+	return &s, nil
+}
+
+func (r *MemorySeriesRepository) Update(ctx context.Context, s *models.Series) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.series[s.ID]
+	if !ok {
+		return fmt.Errorf("series %w", apperr.ErrNotFound)
+	}
+	updated := storedSeries(*s)
+	updated.UserID = existing.UserID
+	updated.CreatedAt = existing.CreatedAt
+	r.series[s.ID] = updated
+	return nil
+}
+
+func (r *MemorySeriesRepository) Advance(ctx context.Context, id string, from time.Time, to *time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.series[id]
+	if !ok || s.NextAt == nil || !s.NextAt.Equal(from.Truncate(time.Second)) {
+		return false, nil
+	}
+	s.NextAt = to
+	r.series[id] = storedSeries(s)
+	return true, nil
+}
+
+func (r *MemorySeriesRepository) Due(ctx context.Context, now time.Time, limit int) ([]models.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := []models.Series{}
+	for _, s := range r.series {
+		if s.NextAt != nil && !s.NextAt.After(now) {
+			due = append(due, storedSeries(s))
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAt.Equal(*due[j].NextAt) {
+			return due[i].NextAt.Before(*due[j].NextAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *MemorySeriesRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.series[id]; !ok {
+		return fmt.Errorf("series %w", apperr.ErrNotFound)
+	}
+	delete(r.series, id)
+	return nil
+}
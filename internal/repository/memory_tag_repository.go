@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemoryTagRepository is the in-memory counterpart of SQLTagRepository. It
+// works on the tags of a MemoryTaskRepository and a MemorySeriesRepository.
+type MemoryTagRepository struct {
+	tasks  *MemoryTaskRepository
+	series *MemorySeriesRepository
+}
+
+func NewMemoryTagRepository(tasks *MemoryTaskRepository, series *MemorySeriesRepository) *MemoryTagRepository {
+	return &MemoryTagRepository{tasks: tasks, series: series}
+}
+
+// Compile-time check
+var _ TagRepository = (*MemoryTagRepository)(nil)
+
+func (r *MemoryTagRepository) List(ctx context.Context, userID string) ([]models.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.tasks.mu.RLock()
+	defer r.tasks.mu.RUnlock()
+
+	tags := []models.Tag{}
+	for _, tag := range r.tasks.tags[userID] {
+		tags = append(tags, r.counted(tag))
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (r *MemoryTagRepository) Get(ctx context.Context, userID, name string) (*models.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.tasks.mu.RLock()
+	defer r.tasks.mu.RUnlock()
+
+	tag, ok := r.tasks.tags[userID][name]
+	if !ok {
+		return nil, fmt.Errorf("tag %q %w", name, apperr.ErrNotFound)
+	}
+	tag = r.counted(tag)
+	return &tag, nil
+}
+
+func (r *MemoryTagRepository) Rename(ctx context.Context, userID, name, newName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	tags := r.tasks.tags[userID]
+	tag, ok := tags[name]
+	if !ok {
+		return fmt.Errorf("tag %q %w", name, apperr.ErrNotFound)
+	}
+	if name == newName {
+		return nil
+	}
+	if _, ok := tags[newName]; ok {
+		return fmt.Errorf("tag %q already exists: %w", newName, apperr.ErrConflict)
+	}
+
+	delete(tags, name)
+	tag.Name = newName
+	tags[newName] = tag
+	r.retag(userID, name, newName)
+	return nil
+}
+
+func (r *MemoryTagRepository) Merge(ctx context.Context, userID, from, into string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	tags := r.tasks.tags[userID]
+	for _, name := range []string{from, into} {
+		if _, ok := tags[name]; !ok {
+			return fmt.Errorf("tag %q %w", name, apperr.ErrNotFound)
+		}
+	}
+
+	delete(tags, from)
+	r.retag(userID, from, into)
+	return nil
+}
+
+func (r *MemoryTagRepository) Delete(ctx context.Context, userID, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.tasks.mu.Lock()
+	defer r.tasks.mu.Unlock()
+
+	if _, ok := r.tasks.tags[userID][name]; !ok {
+		return fmt.Errorf("tag %q %w", name, apperr.ErrNotFound)
+	}
+	delete(r.tasks.tags[userID], name)
+	r.retag(userID, name, "")
+	return nil
+}
+
+// counted fills in tag.TaskCount. r.tasks.mu must be held.
+func (r *MemoryTagRepository) counted(tag models.Tag) models.Tag {
+	tag.TaskCount = 0
+	for _, task := range r.tasks.tasks {
+		if task.DeletedAt == nil && task.UserID == tag.UserID && slices.Contains(task.Tags, tag.Name) {
+			tag.TaskCount++
+		}
+	}
+	return tag
+}
+
+// retag replaces from with to on every one of the user's tasks and series,
+// or removes it when to is empty. r.tasks.mu must be held for writing.
+func (r *MemoryTagRepository) retag(userID, from, to string) {
+	for id, task := range r.tasks.tasks {
+		if task.UserID != userID || !slices.Contains(task.Tags, from) {
+			continue
+		}
+		task.Version++
+		r.tasks.tasks[id] = withTags(task, retagged(task.Tags, from, to))
+	}
+
+	r.series.mu.Lock()
+	defer r.series.mu.Unlock()
+	for id, s := range r.series.series {
+		if s.UserID == userID && slices.Contains(s.Tags, from) {
+			s.Tags = retagged(s.Tags, from, to)
+			r.series.series[id] = s
+		}
+	}
+}
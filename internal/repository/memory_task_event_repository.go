@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemoryTaskEventRepository is the in-memory counterpart of
+// SQLTaskEventRepository.
+type MemoryTaskEventRepository struct {
+	mu     sync.RWMutex
+	events []models.TaskEvent
+	nextID int64
+}
+
+func NewMemoryTaskEventRepository() *MemoryTaskEventRepository {
+	return &MemoryTaskEventRepository{}
+}
+
+// Compile-time check
+var _ TaskEventRepository = (*MemoryTaskEventRepository)(nil)
+
+func (r *MemoryTaskEventRepository) Create(ctx context.Context, event *models.TaskEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	event.ID = r.nextID
+	stored := *event
+	stored.Changes = slices.Clone(event.Changes)
+	r.events = append(r.events, stored)
+	return nil
+}
+
+func (r *MemoryTaskEventRepository) List(ctx context.Context, q TaskEventQuery) ([]models.TaskEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// r.events is in ID order already.
+	events := []models.TaskEvent{}
+	for _, event := range r.events {
+		if event.TaskID == q.TaskID {
+			events = append(events, event)
+		}
+	}
+
+	events = events[min(q.Offset, len(events)):]
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[:q.Limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryTaskEventRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = slices.DeleteFunc(r.events, func(event models.TaskEvent) bool {
+		return event.TaskID == taskID
+	})
+	return nil
+}
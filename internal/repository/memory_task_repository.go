@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/google/uuid"
+)
+
+// MemoryTaskRepository keeps tasks in a map. It is safe for concurrent use
+// and is intended for tests and throwaway local runs.
+type MemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[string]models.Task
+	// tags holds each user's tags by name. Tasks carry tag names, so only
+	// tags with no tasks need this, but keeping every tag here mirrors the
+	// tags table.
+	tags map[string]map[string]models.Tag
+	// deps maps each blocked task's ID to its blockers' IDs and the time
+	// each edge was added.
+	deps map[string]map[string]time.Time
+}
+
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks: map[string]models.Task{},
+		tags:  map[string]map[string]models.Tag{},
+		deps:  map[string]map[string]time.Time{},
+	}
+}
+
+// Compile-time check
+var _ TaskRepository = (*MemoryTaskRepository)(nil)
+
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(task)
+}
+
+// create is Create with r.mu held.
+func (r *MemoryTaskRepository) create(task *models.Task) error {
+	if _, ok := r.tasks[task.ID]; ok {
+		return fmt.Errorf("task already exists: %w", apperr.ErrConflict)
+	}
+	if task.SeriesID != nil && task.OccursAt != nil {
+		for _, t := range r.tasks {
+			if t.SeriesID != nil && *t.SeriesID == *task.SeriesID && t.OccursAt != nil && t.OccursAt.Equal(*task.OccursAt) {
+				return fmt.Errorf("task already exists: %w", apperr.ErrConflict)
+			}
+		}
+	}
+	r.addTags(task.UserID, task.Tags, task.CreatedAt)
+	stored := withTags(*task, task.Tags)
+	stored.ParentID = cloneString(task.ParentID)
+	stored.SeriesID = cloneString(task.SeriesID)
+	if task.OccursAt != nil {
+		at := *task.OccursAt
+		stored.OccursAt = &at
+	}
+	stored.Progress = nil
+	stored.DeletedAt = nil
+	stored.Version = 1
+	r.tasks[task.ID] = stored
+	task.Version = 1
+	return nil
+}
+
+func (r *MemoryTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.live(id)
+	if !ok {
+		return nil, fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	tasks := []models.Task{withTags(task, task.Tags)}
+	r.loadProgress(tasks)
+	return &tasks[0], nil
+}
+
+func (r *MemoryTaskRepository) List(ctx context.Context, q TaskQuery) ([]models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if (task.DeletedAt != nil) == q.Trashed &&
+			(q.TrashedBefore.IsZero() || task.DeletedAt != nil && task.DeletedAt.Before(q.TrashedBefore)) &&
+			(q.UserID == "" || task.UserID == q.UserID) &&
+			(q.IDs == nil || slices.Contains(q.IDs, task.ID)) &&
+			(q.ParentID == "" || task.ParentID != nil && *task.ParentID == q.ParentID) &&
+			(q.SeriesID == "" || task.SeriesID != nil && *task.SeriesID == q.SeriesID) &&
+			matchTags(task.Tags, q.Tags, q.MatchAll) {
+			tasks = append(tasks, withTags(task, task.Tags))
+		}
+	}
+	// Same order as the SQL repositories: newest first, or most recently
+	// trashed first, ties by ID.
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i].CreatedAt, tasks[j].CreatedAt
+		if q.Trashed {
+			a, b = *tasks[i].DeletedAt, *tasks[j].DeletedAt
+		}
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return tasks[i].ID > tasks[j].ID
+	})
+
+	tasks = tasks[min(q.Offset, len(tasks)):]
+	if q.Limit > 0 && len(tasks) > q.Limit {
+		tasks = tasks[:q.Limit]
+	}
+	r.loadProgress(tasks)
+	return tasks, nil
+}
+
+func (r *MemoryTaskRepository) Update(ctx context.Context, task *models.Task) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(task)
+}
+
+// update is Update with r.mu held.
+func (r *MemoryTaskRepository) update(task *models.Task) error {
+	existing, ok := r.live(task.ID)
+	if !ok {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	if existing.Version != task.Version {
+		return fmt.Errorf("task was changed by another request: %w", apperr.ErrConflict)
+	}
+	task.Version++
+	existing.Version = task.Version
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.Status = task.Status
+	existing.ParentID = cloneString(task.ParentID)
+	existing.UpdatedAt = task.UpdatedAt
+	r.addTags(existing.UserID, task.Tags, task.UpdatedAt)
+	r.tasks[task.ID] = withTags(existing, task.Tags)
+	return nil
+}
+
+func (r *MemoryTaskRepository) Delete(ctx context.Context, task *models.Task, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.trash(task, at)
+}
+
+// trash is Delete with r.mu held.
+func (r *MemoryTaskRepository) trash(task *models.Task, at time.Time) error {
+	existing, ok := r.live(task.ID)
+	if !ok {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	if existing.Version != task.Version {
+		return fmt.Errorf("task was changed by another request: %w", apperr.ErrConflict)
+	}
+	// Stored like the SQL repositories store it.
+	at = at.UTC().Truncate(time.Second)
+	task.Version++
+	existing.DeletedAt = &at
+	existing.Version = task.Version
+	r.tasks[task.ID] = existing
+	return nil
+}
+
+// Apply makes the writes under one lock, and puts the tasks and tags back
+// as they were when one fails.
+func (r *MemoryTaskRepository) Apply(ctx context.Context, b TaskBatch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := maps.Clone(r.tasks)
+	tags := make(map[string]map[string]models.Tag, len(r.tags))
+	for userID, byName := range r.tags {
+		tags[userID] = maps.Clone(byName)
+	}
+	restore := b.saveVersions()
+
+	err := func() error {
+		for _, task := range b.Create {
+			if err := r.create(task); err != nil {
+				return err
+			}
+		}
+		for _, task := range b.Update {
+			if err := r.update(task); err != nil {
+				return err
+			}
+		}
+		for _, task := range b.Delete {
+			if err := r.trash(task, b.DeletedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		r.tasks, r.tags = tasks, tags
+		restore()
+	}
+	return err
+}
+
+func (r *MemoryTaskRepository) Restore(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	task.DeletedAt = nil
+	task.Version++
+	r.tasks[id] = task
+	return nil
+}
+
+func (r *MemoryTaskRepository) Purge(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[id]; !ok {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+func (r *MemoryTaskRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.live(id)
+	if !ok {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	task.Status = models.TaskStatus(status)
+	task.UpdatedAt = time.Now()
+	task.Version++
+	r.tasks[id] = task
+	return nil
+}
+
+func (r *MemoryTaskRepository) AutoCompleteIfPending(ctx context.Context, id string) (models.TaskStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.live(id)
+	if !ok {
+		return "", nil
+	}
+	for _, st := range r.tasks {
+		if st.DeletedAt == nil && st.ParentID != nil && *st.ParentID == id && st.Status != models.StatusCompleted {
+			return "", nil
+		}
+	}
+	for blockerID := range r.deps[id] {
+		if blocker, ok := r.live(blockerID); ok && blocker.Status != models.StatusCompleted {
+			return "", nil
+		}
+	}
+	if task.Status != models.StatusPending && task.Status != models.StatusInProgress {
+		return "", nil
+	}
+	from := task.Status
+	task.Status = models.StatusCompleted
+	task.UpdatedAt = time.Now()
+	task.Version++
+	r.tasks[id] = task
+	return from, nil
+}
+
+// live returns the task with the given ID unless it is missing or in the
+// trash. r.mu must be held.
+func (r *MemoryTaskRepository) live(id string) (models.Task, bool) {
+	task, ok := r.tasks[id]
+	return task, ok && task.DeletedAt == nil
+}
+
+// withTags returns task with its own sorted copy of tags, so callers and
+// the map never share a backing array.
+func withTags(task models.Task, tags []string) models.Task {
+	task.Tags = slices.Clone(tags)
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+	slices.Sort(task.Tags)
+	return task
+}
+
+// loadProgress fills in Progress on each task. r.mu must be held.
+func (r *MemoryTaskRepository) loadProgress(tasks []models.Task) {
+	// Reading the map cannot fail.
+	_ = rollUpProgress(tasks, func(parentIDs []string) ([]subtask, error) {
+		var subtasks []subtask
+		for _, task := range r.tasks {
+			if task.DeletedAt == nil && task.ParentID != nil && slices.Contains(parentIDs, *task.ParentID) {
+				subtasks = append(subtasks, subtask{ID: task.ID, ParentID: *task.ParentID, Status: task.Status})
+			}
+		}
+		return subtasks, nil
+	})
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func matchTags(have, want []string, all bool) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, name := range want {
+		found := slices.Contains(have, name)
+		if found && !all {
+			return true
+		}
+		if !found && all {
+			return false
+		}
+	}
+	return all
+}
+
+// addTags registers any of names the user does not have yet. r.mu must be
+// held for writing.
+func (r *MemoryTaskRepository) addTags(userID string, names []string, createdAt time.Time) {
+	if r.tags[userID] == nil {
+		r.tags[userID] = map[string]models.Tag{}
+	}
+	for _, name := range names {
+		if _, ok := r.tags[userID][name]; !ok {
+			r.tags[userID][name] = models.Tag{ID: uuid.NewString(), UserID: userID, Name: name, CreatedAt: createdAt}
+		}
+	}
+}
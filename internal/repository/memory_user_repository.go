@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// MemoryUserRepository is the in-memory counterpart of MySQLUserRepository.
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	byEmail map[string]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{byEmail: map[string]models.User{}}
+}
+
+// Compile-time check
+var _ UserRepository = (*MemoryUserRepository)(nil)
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.byEmail[email]
+	if !ok {
+		return nil, fmt.Errorf("user %w", apperr.ErrNotFound)
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.byEmail {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user %w", apperr.ErrNotFound)
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byEmail[user.Email]; ok {
+		return fmt.Errorf("user already exists: %w", apperr.ErrConflict)
+	}
+	r.byEmail[user.Email] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]models.User, 0, len(r.byEmail))
+	for _, user := range r.byEmail {
+		user.Password = ""
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Email < users[j].Email })
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for email, user := range r.byEmail {
+		if user.ID == id {
+			delete(r.byEmail, email)
+			return nil
+		}
+	}
+	return fmt.Errorf("user %w", apperr.ErrNotFound)
+}
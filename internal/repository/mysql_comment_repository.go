@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+type MySQLCommentRepository struct {
+	db *sql.DB
+}
+
+func NewMySQLCommentRepository(db *sql.DB) *MySQLCommentRepository {
+	return &MySQLCommentRepository{db: db}
+}
+
+// Compile-time check
+var _ CommentRepository = (*MySQLCommentRepository)(nil)
+
+func (r *MySQLCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO task_comments (
+            id,
+            task_id,
+            author_id,
+            body,
+            edited,
+            created_at,
+            updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?)
+    `,
+		comment.ID,
+		comment.TaskID,
+		comment.AuthorID,
+		comment.Body,
+		comment.Edited,
+		comment.CreatedAt,
+		comment.UpdatedAt,
+	)
+
+	return conflictOnDuplicate(err, "comment already exists")
+}
+
+func (r *MySQLCommentRepository) GetByID(ctx context.Context, id string) (*models.Comment, error) {
+	row := r.db.QueryRowContext(ctx, `
+        SELECT id, task_id, author_id, body, edited, created_at, updated_at
+        FROM task_comments
+        WHERE id = ?
+    `, id)
+
+	comment, err := scanComment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func (r *MySQLCommentRepository) List(ctx context.Context, q CommentQuery) ([]models.Comment, error) {
+	query, args := listCommentsQuery(q)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+func (r *MySQLCommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE task_comments
+        SET body = ?, edited = ?, updated_at = ?
+        WHERE id = ?
+    `,
+		comment.Body,
+		comment.Edited,
+		comment.UpdatedAt,
+		comment.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *MySQLCommentRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM task_comments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *MySQLCommentRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM task_comments WHERE task_id = ?", taskID)
+	return err
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// SeriesRepository stores recurring tasks. The tasks created for each
+// occurrence are stored through TaskRepository.
+type SeriesRepository interface {
+	Create(ctx context.Context, s *models.Series) error
+	GetByID(ctx context.Context, id string) (*models.Series, error)
+	// Update writes every field of s except its owner and creation time.
+	Update(ctx context.Context, s *models.Series) error
+	// Advance moves the series' NextAt from from to to, which may be nil.
+	// It reports false, without error, when NextAt is no longer from,
+	// meaning another caller has already claimed that occurrence.
+	Advance(ctx context.Context, id string, from time.Time, to *time.Time) (bool, error)
+	// Due returns up to limit series whose NextAt is at or before now,
+	// earliest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]models.Series, error)
+	// Delete removes the series. Its occurrences are left alone.
+	Delete(ctx context.Context, id string) error
+}
+
+// SQLSeriesRepository implements SeriesRepository for MySQL and SQLite.
+// Times are written as UTC text, which both dialects accept.
+type SQLSeriesRepository struct {
+	db *sql.DB
+}
+
+func NewSQLSeriesRepository(db *sql.DB) *SQLSeriesRepository {
+	return &SQLSeriesRepository{db: db}
+}
+
+// Compile-time check
+var _ SeriesRepository = (*SQLSeriesRepository)(nil)
+
+const selectSeries = `
+        SELECT id, user_id, title, description, tags, rrule, timezone, starts_at, next_at, created_at, updated_at
+        FROM task_series`
+
+// Tag names cannot contain commas, so the tags column is a plain list.
+const seriesTagSep = ","
+
+func (r *SQLSeriesRepository) Create(ctx context.Context, s *models.Series) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO task_series (
+            id, user_id, title, description, tags, rrule, timezone, starts_at, next_at, created_at, updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+		s.ID,
+		s.UserID,
+		s.Title,
+		s.Description,
+		strings.Join(s.Tags, seriesTagSep),
+		s.RRule,
+		s.Timezone,
+		nullTime(&s.StartsAt),
+		nullTime(s.NextAt),
+		nullTime(&s.CreatedAt),
+		nullTime(&s.UpdatedAt),
+	)
+	return conflictOnDuplicate(err, "series already exists")
+}
+
+func (r *SQLSeriesRepository) GetByID(ctx context.Context, id string) (*models.Series, error) {
+	s, err := scanSeries(r.db.QueryRowContext(ctx, selectSeries+`
+        WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("series %w", apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *SQLSeriesRepository) Update(ctx context.Context, s *models.Series) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE task_series
+        SET title = ?, description = ?, tags = ?, rrule = ?, timezone = ?, starts_at = ?, next_at = ?, updated_at = ?
+        WHERE id = ?
+    `,
+		s.Title,
+		s.Description,
+		strings.Join(s.Tags, seriesTagSep),
+		s.RRule,
+		s.Timezone,
+		nullTime(&s.StartsAt),
+		nullTime(s.NextAt),
+		nullTime(&s.UpdatedAt),
+		s.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("series %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *SQLSeriesRepository) Advance(ctx context.Context, id string, from time.Time, to *time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE task_series
+        SET next_at = ?
+        WHERE id = ? AND next_at = ?
+    `, nullTime(to), id, nullTime(&from))
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *SQLSeriesRepository) Due(ctx context.Context, now time.Time, limit int) ([]models.Series, error) {
+	rows, err := r.db.QueryContext(ctx, selectSeries+`
+        WHERE next_at IS NOT NULL AND next_at <= ?
+        ORDER BY next_at, id
+        LIMIT ?`, nullTime(&now), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []models.Series{}
+	for rows.Next() {
+		s, err := scanSeries(rows)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, *s)
+	}
+
+	return series, rows.Err()
+}
+
+func (r *SQLSeriesRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM task_series
+        WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("series %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanSeries(row rowScanner) (*models.Series, error) {
+	var s models.Series
+	var tags, startsAt, createdAt, updatedAt string
+	var nextAt sql.NullString
+
+	err := row.Scan(&s.ID, &s.UserID, &s.Title, &s.Description, &tags, &s.RRule, &s.Timezone,
+		&startsAt, &nextAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Tags = []string{}
+	if tags != "" {
+		s.Tags = strings.Split(tags, seriesTagSep)
+	}
+	s.StartsAt, _ = time.Parse(sqliteTimeLayout, startsAt)
+	s.NextAt = parseNullTime(nextAt)
+	s.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAt)
+	s.UpdatedAt, _ = time.Parse(sqliteTimeLayout, updatedAt)
+	return &s, nil
+}
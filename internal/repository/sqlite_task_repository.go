@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// sqliteTimeLayout matches the TEXT format the SQLite migrations store
+// timestamps in, which is also what datetime('now') produces.
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+type SQLiteTaskRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteTaskRepository(db *sql.DB) *SQLiteTaskRepository {
+	return &SQLiteTaskRepository{db: db}
+}
+
+// Compile-time check
+var _ TaskRepository = (*SQLiteTaskRepository)(nil)
+
+const sqliteInsertTag = `
+        INSERT INTO tags (id, user_id, name, created_at)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT (user_id, name) DO NOTHING
+    `
+
+func (r *SQLiteTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.create(ctx, tx, task)
+	})
+}
+
+func (r *SQLiteTaskRepository) create(ctx context.Context, tx *sql.Tx, task *models.Task) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO tasks (
+            id,
+            title,
+            description,
+            status,
+            user_id,
+            parent_id,
+            series_id,
+            occurs_at,
+            created_at,
+            updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+		task.ID,
+		task.Title,
+		task.Description,
+		task.Status,
+		task.UserID,
+		task.ParentID,
+		task.SeriesID,
+		nullTime(task.OccursAt),
+		task.CreatedAt.UTC().Format(sqliteTimeLayout),
+		task.UpdatedAt.UTC().Format(sqliteTimeLayout),
+	)
+	if err != nil {
+		return conflictOnDuplicate(err, "task already exists")
+	}
+	task.Version = 1
+	return replaceTaskTags(ctx, tx, sqliteInsertTag, task, task.CreatedAt.UTC().Format(sqliteTimeLayout))
+}
+
+func (r *SQLiteTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	row := r.db.QueryRowContext(ctx, `
+        SELECT id, title, description, status, user_id, parent_id, series_id, occurs_at, created_at, updated_at, deleted_at, version
+        FROM tasks
+        WHERE id = ?
+          AND deleted_at IS NULL
+    `, id)
+
+	task, err := scanSQLiteTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{*task}
+	if err := loadTaskTags(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadTaskProgress(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+func (r *SQLiteTaskRepository) List(ctx context.Context, q TaskQuery) ([]models.Task, error) {
+	query, args := listTasksQuery(q)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanSQLiteTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := loadTaskTags(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadTaskProgress(ctx, r.db, tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *SQLiteTaskRepository) Update(ctx context.Context, task *models.Task) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return r.update(ctx, tx, task)
+	})
+}
+
+func (r *SQLiteTaskRepository) update(ctx context.Context, tx *sql.Tx, task *models.Task) error {
+	result, err := tx.ExecContext(ctx, `
+        UPDATE tasks
+        SET title = ?, description = ?, status = ?, parent_id = ?, updated_at = ?, version = version + 1
+        WHERE id = ?
+          AND version = ?
+          AND deleted_at IS NULL
+    `,
+		task.Title,
+		task.Description,
+		task.Status,
+		task.ParentID,
+		task.UpdatedAt.UTC().Format(sqliteTimeLayout),
+		task.ID,
+		task.Version,
+	)
+	if err := expectVersion(ctx, tx, task, result, err); err != nil {
+		return err
+	}
+
+	return replaceTaskTags(ctx, tx, sqliteInsertTag, task, task.UpdatedAt.UTC().Format(sqliteTimeLayout))
+}
+
+func (r *SQLiteTaskRepository) Apply(ctx context.Context, b TaskBatch) error {
+	return applyBatch(ctx, r.db, b, r.create, r.update)
+}
+
+func (r *SQLiteTaskRepository) Delete(ctx context.Context, task *models.Task, at time.Time) error {
+	return trashTask(ctx, r.db, task, at)
+}
+
+func (r *SQLiteTaskRepository) Restore(ctx context.Context, id string) error {
+	return restoreTask(ctx, r.db, id)
+}
+
+func (r *SQLiteTaskRepository) Purge(ctx context.Context, id string) error {
+	return purgeTask(ctx, r.db, id)
+}
+
+func (r *SQLiteTaskRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE tasks
+        SET status = ?, updated_at = datetime('now'), version = version + 1
+        WHERE id = ?
+          AND deleted_at IS NULL
+    `, status, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *SQLiteTaskRepository) AutoCompleteIfPending(ctx context.Context, id string) (models.TaskStatus, error) {
+	return autoCompleteIfPending(ctx, r.db, id)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSQLiteTask(s rowScanner) (*models.Task, error) {
+	var task models.Task
+	var status string
+	var occursAt, deletedAt sql.NullString
+	var createdAtStr, updatedAtStr string
+
+	err := s.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&status,
+		&task.UserID,
+		&task.ParentID,
+		&task.SeriesID,
+		&occursAt,
+		&createdAtStr,
+		&updatedAtStr,
+		&deletedAt,
+		&task.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	task.Status = models.TaskStatus(status)
+	task.OccursAt = parseNullTime(occursAt)
+	task.DeletedAt = parseNullTime(deletedAt)
+	task.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAtStr)
+	task.UpdatedAt, _ = time.Parse(sqliteTimeLayout, updatedAtStr)
+	return &task, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+// Compile-time check
+var _ UserRepository = (*SQLiteUserRepository)(nil)
+
+func (r *SQLiteUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, email, password, role
+        FROM users
+        WHERE email = ?
+    `, email).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %w", apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx, `
+        SELECT id, email, password, role
+        FROM users
+        WHERE id = ?
+    `, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %w", apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *models.User) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO users (id, email, password, role)
+         VALUES (?, ?, ?, ?)`,
+		user.ID,
+		user.Email,
+		user.Password,
+		user.Role,
+	)
+	return conflictOnDuplicate(err, "user already exists")
+}
+
+func (r *SQLiteUserRepository) List(ctx context.Context) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, email, role
+        FROM users
+        ORDER BY email
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *SQLiteUserRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %w", apperr.ErrNotFound)
+	}
+
+	return nil
+}
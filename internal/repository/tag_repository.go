@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// TagRepository manages a user's tags. Tags are put on tasks through
+// TaskRepository; this covers the tags themselves. Renaming, merging or
+// deleting a tag is a write to each task that carries it, so it
+// increments their versions. It also applies to the tags of the user's
+// series, which their future occurrences are created with.
+type TagRepository interface {
+	// List returns the user's tags ordered by name, with task counts.
+	List(ctx context.Context, userID string) ([]models.Tag, error)
+	Get(ctx context.Context, userID, name string) (*models.Tag, error)
+	// Rename fails with apperr.ErrConflict when the user already has a
+	// tag called newName; Merge is the way to combine two tags.
+	Rename(ctx context.Context, userID, name, newName string) error
+	// Merge moves every task tagged from onto into, then deletes from.
+	Merge(ctx context.Context, userID, from, into string) error
+	Delete(ctx context.Context, userID, name string) error
+}
+
+// SQLTagRepository implements TagRepository for MySQL and SQLite, whose
+// dialects agree on everything it needs.
+type SQLTagRepository struct {
+	db *sql.DB
+}
+
+func NewSQLTagRepository(db *sql.DB) *SQLTagRepository {
+	return &SQLTagRepository{db: db}
+}
+
+// Compile-time check
+var _ TagRepository = (*SQLTagRepository)(nil)
+
+// selectTags counts only live tasks; trashed ones keep their tags for a
+// restore but are not shown.
+const selectTags = `
+        SELECT t.id, t.user_id, t.name, t.created_at, COUNT(k.id)
+        FROM tags t
+        LEFT JOIN task_tags tt ON tt.tag_id = t.id
+        LEFT JOIN tasks k ON k.id = tt.task_id AND k.deleted_at IS NULL`
+
+const groupTags = `
+        GROUP BY t.id, t.user_id, t.name, t.created_at
+        ORDER BY t.name`
+
+func (r *SQLTagRepository) List(ctx context.Context, userID string) ([]models.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, selectTags+`
+        WHERE t.user_id = ?`+groupTags, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *SQLTagRepository) Get(ctx context.Context, userID, name string) (*models.Tag, error) {
+	row := r.db.QueryRowContext(ctx, selectTags+`
+        WHERE t.user_id = ? AND t.name = ?`+groupTags, userID, name)
+
+	tag, err := scanTag(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("tag %q %w", name, apperr.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (r *SQLTagRepository) Rename(ctx context.Context, userID, name, newName string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		id, err := tagID(ctx, tx, userID, name)
+		if err != nil || name == newName {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE tags SET name = ? WHERE id = ?", newName, id); err != nil {
+			return conflictOnDuplicate(err, fmt.Sprintf("tag %q already exists", newName))
+		}
+		if err := retagSeries(ctx, tx, userID, name, newName); err != nil {
+			return err
+		}
+		return bumpTagged(ctx, tx, id)
+	})
+}
+
+func (r *SQLTagRepository) Merge(ctx context.Context, userID, from, into string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		fromID, err := tagID(ctx, tx, userID, from)
+		if err != nil {
+			return err
+		}
+		intoID, err := tagID(ctx, tx, userID, into)
+		if err != nil {
+			return err
+		}
+
+		// Tasks that already have both keep a single row.
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO task_tags (task_id, tag_id)
+            SELECT task_id, ?
+            FROM task_tags
+            WHERE tag_id = ?
+              AND task_id NOT IN (SELECT task_id FROM task_tags WHERE tag_id = ?)
+        `, intoID, fromID, intoID); err != nil {
+			return err
+		}
+		if err := retagSeries(ctx, tx, userID, from, into); err != nil {
+			return err
+		}
+		return deleteTag(ctx, tx, fromID)
+	})
+}
+
+func (r *SQLTagRepository) Delete(ctx context.Context, userID, name string) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		id, err := tagID(ctx, tx, userID, name)
+		if err != nil {
+			return err
+		}
+		if err := retagSeries(ctx, tx, userID, name, ""); err != nil {
+			return err
+		}
+		return deleteTag(ctx, tx, id)
+	})
+}
+
+func tagID(ctx context.Context, tx *sql.Tx, userID, name string) (string, error) {
+	var id string
+	err := tx.QueryRowContext(ctx, "SELECT id FROM tags WHERE user_id = ? AND name = ?", userID, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("tag %q %w", name, apperr.ErrNotFound)
+	}
+	return id, err
+}
+
+// bumpTagged increments the version of every task tagged with tag id.
+func bumpTagged(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, `
+        UPDATE tasks
+        SET version = version + 1
+        WHERE id IN (SELECT task_id FROM task_tags WHERE tag_id = ?)
+    `, id)
+	return err
+}
+
+func deleteTag(ctx context.Context, tx *sql.Tx, id string) error {
+	if err := bumpTagged(ctx, tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_tags WHERE tag_id = ?", id); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = ?", id)
+	return err
+}
+
+// retagSeries replaces from with to in the tags of the user's series, or
+// removes it when to is empty.
+func retagSeries(ctx context.Context, tx *sql.Tx, userID, from, to string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, tags FROM task_series WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	type change struct{ id, tags string }
+	var changes []change
+	for rows.Next() {
+		var id, tags string
+		if err := rows.Scan(&id, &tags); err != nil {
+			rows.Close()
+			return err
+		}
+		if names := strings.Split(tags, seriesTagSep); tags != "" && slices.Contains(names, from) {
+			changes = append(changes, change{id, strings.Join(retagged(names, from, to), seriesTagSep)})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if _, err := tx.ExecContext(ctx, "UPDATE task_series SET tags = ? WHERE id = ?", c.tags, c.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retagged returns a copy of tags with from replaced by to, or removed
+// when to is empty, keeping each name once.
+func retagged(tags []string, from, to string) []string {
+	tags = slices.DeleteFunc(slices.Clone(tags), func(name string) bool { return name == from })
+	if to != "" && !slices.Contains(tags, to) {
+		tags = append(tags, to)
+	}
+	return tags
+}
+
+func scanTag(s rowScanner) (*models.Tag, error) {
+	var tag models.Tag
+	var createdAtStr string
+
+	if err := s.Scan(&tag.ID, &tag.UserID, &tag.Name, &createdAtStr, &tag.TaskCount); err != nil {
+		return nil, err
+	}
+
+	tag.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAtStr)
+	return &tag, nil
+}
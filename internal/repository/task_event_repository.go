@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+// TaskEventQuery selects a page of one task's history, oldest first.
+type TaskEventQuery struct {
+	TaskID string
+	// Limit caps the number of events returned; 0 means no cap.
+	Limit  int
+	Offset int
+}
+
+// TaskEventRepository stores the history of tasks. Events are only ever
+// added, and removed together when their task is purged.
+type TaskEventRepository interface {
+	// Create stores event and sets its ID.
+	Create(ctx context.Context, event *models.TaskEvent) error
+	List(ctx context.Context, q TaskEventQuery) ([]models.TaskEvent, error)
+	// DeleteByTask removes a task's whole history. A task without events
+	// is not an error.
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+// SQLTaskEventRepository implements TaskEventRepository for MySQL and
+// SQLite. Changes are stored as a JSON array.
+type SQLTaskEventRepository struct {
+	db *sql.DB
+}
+
+func NewSQLTaskEventRepository(db *sql.DB) *SQLTaskEventRepository {
+	return &SQLTaskEventRepository{db: db}
+}
+
+// Compile-time check
+var _ TaskEventRepository = (*SQLTaskEventRepository)(nil)
+
+func (r *SQLTaskEventRepository) Create(ctx context.Context, event *models.TaskEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	// Both dialects accept this layout for created_at.
+	result, err := r.db.ExecContext(ctx, `
+        INSERT INTO task_events (task_id, type, actor, actor_id, changes, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `,
+		event.TaskID,
+		event.Type,
+		event.Actor,
+		event.ActorID,
+		changes,
+		event.CreatedAt.UTC().Format(sqliteTimeLayout),
+	)
+	if err != nil {
+		return err
+	}
+
+	event.ID, err = result.LastInsertId()
+	return err
+}
+
+func (r *SQLTaskEventRepository) List(ctx context.Context, q TaskEventQuery) ([]models.TaskEvent, error) {
+	query := `
+        SELECT id, task_id, type, actor, actor_id, changes, created_at
+        FROM task_events
+        WHERE task_id = ?
+        ORDER BY id`
+	args := []any{q.TaskID}
+	if q.Limit > 0 {
+		query += `
+        LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.TaskEvent{}
+	for rows.Next() {
+		var event models.TaskEvent
+		var changes, createdAtStr string
+		err := rows.Scan(
+			&event.ID,
+			&event.TaskID,
+			&event.Type,
+			&event.Actor,
+			&event.ActorID,
+			&changes,
+			&createdAtStr,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &event.Changes); err != nil {
+			return nil, err
+		}
+		event.CreatedAt, _ = time.Parse(sqliteTimeLayout, createdAtStr)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *SQLTaskEventRepository) DeleteByTask(ctx context.Context, taskID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM task_events WHERE task_id = ?", taskID)
+	return err
+}
@@ -1,12 +1,576 @@
 package repository
 
-import "github.com/CashInvoice-Golang-Assignment/internal/models"
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/google/uuid"
+)
+
+// TaskQuery selects a page of tasks, newest first.
+type TaskQuery struct {
+	// UserID restricts the result to one owner's tasks. Empty means every
+	// user's tasks.
+	UserID string
+	// ParentID restricts the result to the direct subtasks of one task.
+	ParentID string
+	// SeriesID restricts the result to the occurrences of one recurring
+	// task.
+	SeriesID string
+	// IDs restricts the result to these tasks. Nil means no restriction;
+	// an empty slice matches nothing.
+	IDs []string
+	// Tags restricts the result to tasks with any of these tags, or all of
+	// them when MatchAll is set. Names must already be normalized.
+	Tags     []string
+	MatchAll bool
+	// Trashed selects tasks in the trash instead of live ones, most
+	// recently trashed first. TrashedBefore, when set, keeps only those
+	// trashed before it.
+	Trashed       bool
+	TrashedBefore time.Time
+	// Limit caps the number of tasks returned; 0 means no cap.
+	Limit  int
+	Offset int
+}
+
+// TaskRepository stores tasks together with their tags: Create and Update
+// write task.Tags, and GetByID and List fill it in along with
+// task.Progress.
+//
+// Deleted tasks go to the trash first. Apart from List with
+// TaskQuery.Trashed, Restore and Purge, every method ignores trashed
+// tasks as if they did not exist, and they do not count towards the
+// progress of their parent or block the tasks they block.
+//
+// Every write to a task increments its version, starting from 1.
+//
+// A series has at most one task per occurrence, trashed or not: Create
+// fails with apperr.ErrConflict for a second task with the same SeriesID
+// and OccursAt, as it does for a duplicate ID.
 type TaskRepository interface {
-	Create(task *models.Task) error
-	GetByID(id string) (*models.Task, error)
-	GetAll(userID string, isAdmin bool) ([]models.Task, error)
-	Delete(id string) error
-	UpdateStatus(id string, status string) error
-	AutoCompleteIfPending(id string) error
+	Create(ctx context.Context, task *models.Task) error
+	GetByID(ctx context.Context, id string) (*models.Task, error)
+	List(ctx context.Context, q TaskQuery) ([]models.Task, error)
+	// Update overwrites the task's title, description, status, parent,
+	// tags and updated_at, provided the stored version is still
+	// task.Version, and then increments task.Version. It fails with
+	// apperr.ErrConflict when the task was changed since it was read.
+	Update(ctx context.Context, task *models.Task) error
+	// Delete moves one task to the trash, marking it deleted at at,
+	// provided the stored version is still task.Version, and then
+	// increments task.Version. It fails with apperr.ErrConflict like
+	// Update. It leaves the task's subtasks alone, and keeps its tags,
+	// comments and dependencies for Restore; the service decides what
+	// happens to them.
+	Delete(ctx context.Context, task *models.Task, at time.Time) error
+	// Restore takes a task out of the trash.
+	Restore(ctx context.Context, id string) error
+	// Purge deletes a task, trashed or not, for good along with its tag
+	// assignments. Its comments and dependencies are the service's job.
+	Purge(ctx context.Context, id string) error
+	UpdateStatus(ctx context.Context, id string, status string) error
+	// AutoCompleteIfPending completes the task unless it is already
+	// completed, or has subtasks or blockers that are not. It returns the
+	// status the task was completed from, or "" when it was left alone.
+	AutoCompleteIfPending(ctx context.Context, id string) (models.TaskStatus, error)
+	// Apply saves every write in b in one transaction, or none of them
+	// when one fails. The writes behave as Create, Update and Delete do.
+	Apply(ctx context.Context, b TaskBatch) error
+}
+
+// TaskBatch is a set of writes Apply saves together. Each task appears in
+// it at most once.
+type TaskBatch struct {
+	Create []*models.Task
+	Update []*models.Task
+	// Delete moves these tasks to the trash, all marked deleted at
+	// DeletedAt.
+	Delete    []*models.Task
+	DeletedAt time.Time
+}
+
+// applyBatch implements Apply for both dialects, with create and update
+// writing one task inside the transaction. The versions of b.Update and
+// b.Delete are put back when the transaction is rolled back.
+func applyBatch(ctx context.Context, db *sql.DB, b TaskBatch, create, update func(context.Context, *sql.Tx, *models.Task) error) error {
+	restore := b.saveVersions()
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		for _, task := range b.Create {
+			if err := create(ctx, tx, task); err != nil {
+				return err
+			}
+		}
+		for _, task := range b.Update {
+			if err := update(ctx, tx, task); err != nil {
+				return err
+			}
+		}
+		for _, task := range b.Delete {
+			if err := trashTask(ctx, tx, task, b.DeletedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		restore()
+	}
+	return err
+}
+
+// saveVersions remembers the versions of the tasks b updates and deletes,
+// and returns a func that puts them back.
+func (b TaskBatch) saveVersions() func() {
+	tasks := slices.Concat(b.Update, b.Delete)
+	versions := make([]int64, len(tasks))
+	for i, task := range tasks {
+		versions[i] = task.Version
+	}
+	return func() {
+		for i, task := range tasks {
+			task.Version = versions[i]
+		}
+	}
+}
+
+// listTasksQuery builds the SELECT for q. The SQL is the same for MySQL
+// and SQLite.
+func listTasksQuery(q TaskQuery) (string, []any) {
+	query := `
+        SELECT id, title, description, status, user_id, parent_id, series_id, occurs_at, created_at, updated_at, deleted_at, version
+        FROM tasks`
+	where := []string{"deleted_at IS NULL"}
+	var args []any
+	if q.Trashed {
+		where[0] = "deleted_at IS NOT NULL"
+		if !q.TrashedBefore.IsZero() {
+			where = append(where, "deleted_at < ?")
+			args = append(args, q.TrashedBefore.UTC().Format(sqliteTimeLayout))
+		}
+	}
+	if q.UserID != "" {
+		where = append(where, "user_id = ?")
+		args = append(args, q.UserID)
+	}
+	if q.ParentID != "" {
+		where = append(where, "parent_id = ?")
+		args = append(args, q.ParentID)
+	}
+	if q.SeriesID != "" {
+		where = append(where, "series_id = ?")
+		args = append(args, q.SeriesID)
+	}
+	if q.IDs != nil {
+		if len(q.IDs) == 0 {
+			where = append(where, "1 = 0")
+		} else {
+			where = append(where, "id IN ("+placeholders(len(q.IDs))+")")
+			for _, id := range q.IDs {
+				args = append(args, id)
+			}
+		}
+	}
+	if len(q.Tags) > 0 {
+		match := `id IN (
+            SELECT tt.task_id
+            FROM task_tags tt
+            JOIN tags t ON t.id = tt.tag_id
+            WHERE t.name IN (` + placeholders(len(q.Tags)) + `)`
+		for _, tag := range q.Tags {
+			args = append(args, tag)
+		}
+		// A task has each tag name at most once, so matching every name
+		// means matching len(q.Tags) rows.
+		if q.MatchAll {
+			match += `
+            GROUP BY tt.task_id
+            HAVING COUNT(*) = ?`
+			args = append(args, len(q.Tags))
+		}
+		where = append(where, match+`
+        )`)
+	}
+	query += `
+        WHERE ` + strings.Join(where, `
+          AND `)
+	if q.Trashed {
+		query += `
+        ORDER BY deleted_at DESC, id DESC`
+	} else {
+		query += `
+        ORDER BY created_at DESC, id DESC`
+	}
+	if q.Limit > 0 {
+		query += `
+        LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+	return query, args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// nullTime formats t for a nullable time column. Both dialects accept
+// this layout.
+func nullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// parseNullTime reads a nullable time column written by nullTime.
+func parseNullTime(s sql.NullString) *time.Time {
+	if !s.Valid {
+		return nil
+	}
+	t, err := time.Parse(sqliteTimeLayout, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// sqlQuerier is satisfied by both *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// loadTaskTags fills in Tags on each task with one query. Tasks without
+// tags get an empty slice, so they encode as [] rather than null.
+func loadTaskTags(ctx context.Context, q sqlQuerier, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	args := make([]any, len(tasks))
+	for i, task := range tasks {
+		args[i] = task.ID
+	}
+
+	rows, err := q.QueryContext(ctx, `
+        SELECT tt.task_id, t.name
+        FROM task_tags tt
+        JOIN tags t ON t.id = tt.tag_id
+        WHERE tt.task_id IN (`+placeholders(len(args))+`)
+        ORDER BY t.name
+    `, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byTask := map[string][]string{}
+	for rows.Next() {
+		var taskID, name string
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return err
+		}
+		byTask[taskID] = append(byTask[taskID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		tasks[i].Tags = byTask[tasks[i].ID]
+		if tasks[i].Tags == nil {
+			tasks[i].Tags = []string{}
+		}
+	}
+	return nil
+}
+
+// replaceTaskTags makes task's owner tags exactly task.Tags, creating
+// missing tags with insertTag, which takes (id, user_id, name, created_at)
+// and must ignore a tag that already exists. createdAt is in the driver's
+// timestamp format.
+func replaceTaskTags(ctx context.Context, tx sqlQuerier, insertTag string, task *models.Task, createdAt any) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_tags WHERE task_id = ?", task.ID); err != nil {
+		return err
+	}
+	if len(task.Tags) == 0 {
+		return nil
+	}
+
+	args := []any{task.ID, task.UserID}
+	for _, name := range task.Tags {
+		if _, err := tx.ExecContext(ctx, insertTag, uuid.NewString(), task.UserID, name, createdAt); err != nil {
+			return err
+		}
+		args = append(args, name)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO task_tags (task_id, tag_id)
+        SELECT ?, id
+        FROM tags
+        WHERE user_id = ?
+          AND name IN (`+placeholders(len(task.Tags))+`)
+    `, args...)
+	return err
+}
+
+// subtask is the part of a task progress is computed from.
+type subtask struct {
+	ID       string
+	ParentID string
+	Status   models.TaskStatus
+}
+
+// rollUpProgress sets Progress on each task that has subtasks. children
+// returns the direct subtasks of the given tasks; the tree is walked one
+// level per call, so the number of calls is bounded by the tree depth.
+// Each subtask counts once towards each listed task, so parent rows that
+// loop back end the walk instead of going round forever.
+func rollUpProgress(tasks []models.Task, children func(parentIDs []string) ([]subtask, error)) error {
+	total := make([]int, len(tasks))
+	done := make([]int, len(tasks))
+
+	// level maps each task of the current level to the indexes of the
+	// listed tasks it descends from. A listed task can itself be below
+	// another listed task, so one ID may count towards several of them.
+	type below struct {
+		id string
+		i  int
+	}
+	seen := map[below]bool{}
+	level := make(map[string][]int, len(tasks))
+	for i, task := range tasks {
+		level[task.ID] = append(level[task.ID], i)
+		seen[below{task.ID, i}] = true
+	}
+	for len(level) > 0 {
+		ids := make([]string, 0, len(level))
+		for id := range level {
+			ids = append(ids, id)
+		}
+		subtasks, err := children(ids)
+		if err != nil {
+			return err
+		}
+
+		next := map[string][]int{}
+		for _, st := range subtasks {
+			for _, i := range level[st.ParentID] {
+				if seen[below{st.ID, i}] {
+					continue
+				}
+				seen[below{st.ID, i}] = true
+				total[i]++
+				if st.Status == models.StatusCompleted {
+					done[i]++
+				}
+				next[st.ID] = append(next[st.ID], i)
+			}
+		}
+		level = next
+	}
+
+	for i := range tasks {
+		tasks[i].Progress = nil
+		if total[i] > 0 {
+			p := done[i] * 100 / total[i]
+			tasks[i].Progress = &p
+		}
+	}
+	return nil
+}
+
+// loadTaskProgress fills in Progress on each task from the tasks table.
+func loadTaskProgress(ctx context.Context, q sqlQuerier, tasks []models.Task) error {
+	return rollUpProgress(tasks, func(parentIDs []string) ([]subtask, error) {
+		args := make([]any, len(parentIDs))
+		for i, id := range parentIDs {
+			args[i] = id
+		}
+		rows, err := q.QueryContext(ctx, `
+        SELECT id, parent_id, status
+        FROM tasks
+        WHERE parent_id IN (`+placeholders(len(args))+`)
+          AND deleted_at IS NULL
+    `, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var subtasks []subtask
+		for rows.Next() {
+			var st subtask
+			if err := rows.Scan(&st.ID, &st.ParentID, &st.Status); err != nil {
+				return nil, err
+			}
+			subtasks = append(subtasks, st)
+		}
+		return subtasks, rows.Err()
+	})
+}
+
+// trashTask moves a live task to the trash if it is still at
+// task.Version.
+func trashTask(ctx context.Context, q sqlQuerier, task *models.Task, at time.Time) error {
+	result, err := q.ExecContext(ctx, `
+        UPDATE tasks
+        SET deleted_at = ?, version = version + 1
+        WHERE id = ?
+          AND version = ?
+          AND deleted_at IS NULL
+    `, at.UTC().Format(sqliteTimeLayout), task.ID, task.Version)
+	return expectVersion(ctx, q, task, result, err)
+}
+
+// restoreTask takes a task out of the trash.
+func restoreTask(ctx context.Context, q sqlQuerier, id string) error {
+	result, err := q.ExecContext(ctx, `
+        UPDATE tasks
+        SET deleted_at = NULL, version = version + 1
+        WHERE id = ?
+          AND deleted_at IS NOT NULL
+    `, id)
+	return expectRow(result, err)
+}
+
+// purgeTask deletes a task and its tag assignments for good.
+func purgeTask(ctx context.Context, db *sql.DB, id string) error {
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?", id)
+		if err := expectRow(result, err); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM task_tags WHERE task_id = ?", id)
+		return err
+	})
+}
+
+// expectVersion checks the result of an update of task guarded by its
+// version. When it changed no row it tells a task that was changed in the
+// meantime, reported as apperr.ErrConflict, from one that is gone. On
+// success task.Version is moved on to match the row.
+func expectVersion(ctx context.Context, tx sqlQuerier, task *models.Task, result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		task.Version++
+		return nil
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT 1 FROM tasks WHERE id = ? AND deleted_at IS NULL", task.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	return fmt.Errorf("task was changed by another request: %w", apperr.ErrConflict)
+}
+
+// expectRow turns the result of a statement that should have changed one
+// task into an error when it changed none.
+func expectRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %w", apperr.ErrNotFound)
+	}
+	return nil
+}
+
+// isHeldOpen reports whether the worker must leave id alone because a
+// direct subtask or a blocker of it is not completed.
+func isHeldOpen(ctx context.Context, q sqlQuerier, id string) (bool, error) {
+	rows, err := q.QueryContext(ctx, `
+        SELECT 1
+        FROM tasks
+        WHERE parent_id = ?
+          AND status <> 'completed'
+          AND deleted_at IS NULL
+        UNION ALL
+        SELECT 1
+        FROM task_dependencies d
+        JOIN tasks b ON b.id = d.blocker_id
+        WHERE d.task_id = ?
+          AND b.status <> 'completed'
+          AND b.deleted_at IS NULL
+        LIMIT 1
+    `, id, id)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	open := rows.Next()
+	return open, rows.Err()
+}
+
+// autoCompleteIfPending implements AutoCompleteIfPending for both
+// dialects. The status is read first and the update only applies while it
+// still holds, so the status returned is the one that was replaced.
+func autoCompleteIfPending(ctx context.Context, q sqlQuerier, id string) (models.TaskStatus, error) {
+	open, err := isHeldOpen(ctx, q, id)
+	if err != nil || open {
+		return "", err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+        SELECT status
+        FROM tasks
+        WHERE id = ?
+          AND status IN ('pending', 'in_progress')
+          AND deleted_at IS NULL
+    `, id)
+	if err != nil {
+		return "", err
+	}
+	// Closed before the update rather than deferred, as SQLite may have
+	// only the one connection.
+	var from models.TaskStatus
+	for rows.Next() {
+		if err := rows.Scan(&from); err != nil {
+			rows.Close()
+			return "", err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || from == "" {
+		return "", err
+	}
+
+	result, err := q.ExecContext(ctx, `
+        UPDATE tasks
+        SET status = 'completed', updated_at = ?, version = version + 1
+        WHERE id = ?
+          AND status = ?
+          AND deleted_at IS NULL
+    `, time.Now().UTC().Format(sqliteTimeLayout), id, from)
+	if err != nil {
+		return "", err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return "", err
+	}
+	return from, nil
 }
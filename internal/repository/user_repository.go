@@ -1,15 +1,22 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
 	"github.com/CashInvoice-Golang-Assignment/internal/models"
 )
 
 type UserRepository interface {
-	GetByEmail(email string) (*models.User, error)
-	Create(user *models.User) error
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	// List returns every user ordered by email, without password hashes.
+	List(ctx context.Context) ([]models.User, error)
+	Delete(ctx context.Context, id string) error
 }
 
 type MySQLUserRepository struct {
@@ -20,7 +27,10 @@ func NewMySQLUserRepository(db *sql.DB) *MySQLUserRepository {
 	return &MySQLUserRepository{db: db}
 }
 
-func (r *MySQLUserRepository) GetByEmail(email string) (*models.User, error) {
+// Compile-time check
+var _ UserRepository = (*MySQLUserRepository)(nil)
+
+func (r *MySQLUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
         SELECT id, email, password, role
         FROM users
@@ -28,7 +38,7 @@ func (r *MySQLUserRepository) GetByEmail(email string) (*models.User, error) {
     `
 
 	var user models.User
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -36,14 +46,37 @@ func (r *MySQLUserRepository) GetByEmail(email string) (*models.User, error) {
 	)
 
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, errors.New("user not found")
+		return nil, fmt.Errorf("user %w", apperr.ErrNotFound)
 	}
 
 	return &user, err
 }
 
-func (r *MySQLUserRepository) Create(user *models.User) error {
-	_, err := r.db.Exec(
+func (r *MySQLUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	query := `
+        SELECT id, email, password, role
+        FROM users
+        WHERE id = ?
+    `
+
+	var user models.User
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %w", apperr.ErrNotFound)
+	}
+
+	return &user, err
+}
+
+func (r *MySQLUserRepository) Create(ctx context.Context, user *models.User) error {
+	_, err := r.db.ExecContext(
+		ctx,
 		`INSERT INTO users (id, email, password, role)
          VALUES (?, ?, ?, ?)`,
 		user.ID,
@@ -51,5 +84,45 @@ func (r *MySQLUserRepository) Create(user *models.User) error {
 		user.Password,
 		user.Role,
 	)
-	return err
+	return conflictOnDuplicate(err, "user already exists")
+}
+
+func (r *MySQLUserRepository) List(ctx context.Context) ([]models.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, email, role
+        FROM users
+        ORDER BY email
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *MySQLUserRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %w", apperr.ErrNotFound)
+	}
+
+	return nil
 }
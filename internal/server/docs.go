@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/CashInvoice-Golang-Assignment/docs"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// docsHandler serves the OpenAPI spec at /docs/openapi.json and Swagger UI
+// for it under /docs/.
+func docsHandler() gin.HandlerFunc {
+	// Relative to /docs/index.html.
+	ui := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("openapi.json"))
+	return func(c *gin.Context) {
+		switch c.Param("any") {
+		case "/":
+			c.Redirect(http.StatusFound, "/docs/index.html")
+		case "/openapi.json":
+			c.Data(http.StatusOK, "application/json", docs.OpenAPI)
+		default:
+			ui(c)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/config"
+	"github.com/CashInvoice-Golang-Assignment/internal/logging"
+)
+
+// ReloadResult lists, by config file key, what a reload changed.
+type ReloadResult struct {
+	// Applied settings are live as soon as Reload returns.
+	Applied []string
+	// RestartRequired settings differ from the running config but only
+	// take effect after a restart.
+	RestartRequired []string
+}
+
+// Reload applies the runtime-safe settings of cfg: log level, rate limits,
+// auto-complete delay, worker count and CORS origins. cfg must already be
+// validated. Other changes are reported, not applied, and keep being
+// reported by later reloads until the process restarts.
+func (s *Server) Reload(cfg *config.Config) ReloadResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	applied, restart := config.Changes(s.cfg, cfg)
+
+	logging.SetLevel(cfg.Log.Level)
+	s.Limiter.SetPolicies(rateLimitPolicies(cfg))
+	s.worker.SetDelay(time.Duration(cfg.Worker.AutoCompleteMinutes) * time.Minute)
+	s.worker.SetCount(cfg.Worker.Count)
+	s.cors.SetOrigins(cfg.CORS.AllowedOrigins)
+
+	// Keep the running values of restart-only settings so they are
+	// compared against what is actually in effect next time.
+	next := *s.cfg
+	next.Log = cfg.Log
+	next.RateLimit.Enabled = cfg.RateLimit.Enabled
+	next.RateLimit.Auth = cfg.RateLimit.Auth
+	next.RateLimit.Tasks = cfg.RateLimit.Tasks
+	next.Worker.AutoCompleteMinutes = cfg.Worker.AutoCompleteMinutes
+	next.Worker.Count = cfg.Worker.Count
+	next.CORS = cfg.CORS
+	s.cfg = &next
+
+	s.Metrics.ConfigReload(true, len(restart) > 0)
+	slog.Info("config reloaded", "applied", applied, "restart_required", restart)
+	return ReloadResult{Applied: applied, RestartRequired: restart}
+}
+
+// ReloadFailed records a reload whose config could not be loaded; the
+// running config stays in effect.
+func (s *Server) ReloadFailed(err error) {
+	s.Metrics.ConfigReload(false, false)
+	slog.Error("config reload failed, keeping current config", "error", err)
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/blob"
+	"github.com/CashInvoice-Golang-Assignment/internal/clock"
+	"github.com/CashInvoice-Golang-Assignment/internal/config"
+	"github.com/CashInvoice-Golang-Assignment/internal/handler"
+	"github.com/CashInvoice-Golang-Assignment/internal/health"
+	"github.com/CashInvoice-Golang-Assignment/internal/metrics"
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/CashInvoice-Golang-Assignment/internal/ratelimit"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/CashInvoice-Golang-Assignment/internal/tracing"
+	"github.com/CashInvoice-Golang-Assignment/internal/worker"
+	"github.com/CashInvoice-Golang-Assignment/pkg/database"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// trashPurgeInterval is how often tasks past tasks.trash_retention_days
+// are purged from the trash. Retention is counted in days, so once an
+// hour is plenty.
+const trashPurgeInterval = time.Hour
+
+// Deps are the storage and time dependencies the server is built on.
+// main.go passes MySQL or SQLite repositories and the real clock; tests
+// pass in-memory repositories, a fake clock and a blob store in a
+// temporary directory.
+type Deps struct {
+	TaskRepo    repository.TaskRepository
+	UserRepo    repository.UserRepository
+	CommentRepo repository.CommentRepository
+	EventRepo   repository.TaskEventRepository
+	TagRepo     repository.TagRepository
+	DepRepo     repository.DependencyRepository
+	SeriesRepo  repository.SeriesRepository
+	// AttachmentRepo holds the metadata of task attachments and BlobStore
+	// their content.
+	AttachmentRepo repository.AttachmentRepository
+	BlobStore      blob.Store
+	Clock          clock.Clock
+
+	// DB, when set, has its connection pool stats exported on /metrics.
+	DB *sql.DB
+
+	// RateLimitStore holds the token buckets. Nil means in-process.
+	RateLimitStore ratelimit.Store
+}
+
+// Server holds the wired gin router together with the auto-complete
+// worker that consumes the task queue.
+type Server struct {
+	Router  *gin.Engine
+	Metrics *metrics.Metrics
+	Health  *health.Checker
+	Limiter *ratelimit.Limiter
+
+	queue  chan worker.Job
+	wg     *sync.WaitGroup
+	worker *worker.AutoCompleteWorker
+	// jobs run periodically: the recurrence generator, which creates the
+	// occurrences of recurring tasks as they come due, and the trash purge.
+	jobs []*worker.Periodic
+	cors *middleware.CORS
+
+	// mu guards cfg, the config last applied by New or Reload.
+	mu  sync.Mutex
+	cfg *config.Config
+}
+
+func New(cfg *config.Config, deps Deps) *Server {
+	if deps.Clock == nil {
+		deps.Clock = clock.Real{}
+	}
+
+	taskQueue := make(chan worker.Job, cfg.Worker.QueueSize)
+	wg := &sync.WaitGroup{}
+
+	m := metrics.New()
+	m.RegisterDB(deps.DB, cfg.DB.Driver)
+	m.RegisterQueueDepth(func() int { return len(taskQueue) })
+
+	timeouts := service.Timeouts{Read: cfg.DB.ReadTimeout, Write: cfg.DB.WriteTimeout}
+	taskService := service.NewTaskService(deps.TaskRepo, deps.CommentRepo, deps.EventRepo, deps.DepRepo, deps.SeriesRepo, taskQueue, timeouts, service.SubtaskPolicy{
+		MaxDepth:                 cfg.Tasks.MaxDepth,
+		AutoCompleteParent:       cfg.Tasks.AutoCompleteParent,
+		CompleteWithOpenSubtasks: cfg.Tasks.CompleteWithOpenSubtasks,
+		OnParentDelete:           cfg.Tasks.OnParentDelete,
+	})
+	taskHandler := handler.NewTaskHandler(taskService)
+	commentHandler := handler.NewCommentHandler(service.NewCommentService(deps.TaskRepo, deps.CommentRepo, timeouts))
+	attachmentService := service.NewAttachmentService(deps.TaskRepo, deps.AttachmentRepo, deps.BlobStore, service.AttachmentLimits{
+		MaxSize:      cfg.Attachments.MaxSize,
+		AllowedTypes: cfg.Attachments.AllowedTypes,
+		UserQuota:    cfg.Attachments.UserQuota,
+	}, timeouts)
+	taskService.OnPurge(attachmentService.TaskPurged)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	tagHandler := handler.NewTagHandler(service.NewTagService(deps.TagRepo, timeouts))
+	seriesHandler := handler.NewSeriesHandler(taskService)
+	authService := service.NewAuthService(deps.UserRepo, timeouts, m)
+	authHandler := handler.NewAuthHandler(
+		authService,
+		cfg.Auth.JWTSecret,
+		cfg.Auth.TokenExpiry,
+		cfg.Auth.RefreshExpiry,
+		cfg.Auth.SessionExpiry,
+	)
+
+	delay := time.Duration(cfg.Worker.AutoCompleteMinutes) * time.Minute
+	w := worker.NewAutoCompleteWorker(deps.TaskRepo, taskQueue, delay, wg, deps.Clock, cfg.DB.WriteTimeout, m)
+	w.OnComplete(taskService.TaskAutoCompleted)
+	retention := time.Duration(cfg.Tasks.TrashRetentionDays) * 24 * time.Hour
+	jobs := []*worker.Periodic{
+		worker.NewPeriodic("recurrence", taskService.GenerateDue, cfg.Tasks.RecurrenceInterval, deps.Clock, wg),
+		worker.NewPeriodic("trash purge", func(ctx context.Context, now time.Time) (int, error) {
+			return taskService.PurgeTrash(ctx, now.Add(-retention))
+		}, trashPurgeInterval, deps.Clock, wg),
+	}
+
+	checker := health.NewChecker(2 * time.Second)
+	if deps.DB != nil {
+		checker.Add("database", health.DatabaseCheck(deps.DB, cfg.Health.DBMaxLatency))
+		checker.Add("migrations", health.MigrationsCheck(func(ctx context.Context) (int, error) {
+			return database.PendingMigrations(ctx, deps.DB, cfg.DB.Driver)
+		}))
+	}
+	checker.Add("worker", health.WorkerCheck(w.Health))
+	checker.Add("queue", health.QueueCheck(
+		func() int { return len(taskQueue) },
+		func() int { return cap(taskQueue) },
+		cfg.Health.QueueMaxSaturation,
+	))
+	healthHandler := handler.NewHealthHandler(checker)
+
+	if deps.RateLimitStore == nil {
+		deps.RateLimitStore = ratelimit.NewMemoryStore()
+	}
+	limiter := ratelimit.NewLimiter(deps.RateLimitStore, rateLimitPolicies(cfg), deps.Clock)
+	cors := middleware.NewCORS(cfg.CORS.AllowedOrigins)
+
+	return &Server{
+		Router:  newRouter(cfg, m, limiter, cors, taskHandler, commentHandler, attachmentHandler, tagHandler, seriesHandler, authHandler, healthHandler),
+		Metrics: m,
+		Health:  checker,
+		Limiter: limiter,
+		queue:   taskQueue,
+		wg:      wg,
+		worker:  w,
+		jobs:    jobs,
+		cors:    cors,
+		cfg:     cfg,
+	}
+}
+
+func newRouter(
+	cfg *config.Config,
+	m *metrics.Metrics,
+	limiter *ratelimit.Limiter,
+	cors *middleware.CORS,
+	taskHandler *handler.TaskHandler,
+	commentHandler *handler.CommentHandler,
+	attachmentHandler *handler.AttachmentHandler,
+	tagHandler *handler.TagHandler,
+	seriesHandler *handler.SeriesHandler,
+	authHandler *handler.AuthHandler,
+	healthHandler *handler.HealthHandler,
+) *gin.Engine {
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		slog.Warn("invalid trusted proxies, trusting none", "error", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(tracing.ServiceName,
+		otelgin.WithFilter(func(req *http.Request) bool { return !isProbePath(req.URL.Path) }),
+	))
+	r.Use(middleware.RequestID())
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.Metrics(m))
+	r.Use(cors.Handler())
+	r.Use(middleware.Problems())
+	r.Use(middleware.ClientCert(clientIdentities(cfg.TLS.ClientIdentities)))
+
+	if cfg.Metrics.Enabled {
+		r.GET("/metrics",
+			middleware.MetricsAccess(parseCIDRs(cfg.Metrics.AllowedCIDRs), cfg.Metrics.Token),
+			gin.WrapH(promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})),
+		)
+	}
+
+	r.NoRoute(func(c *gin.Context) {
+		middleware.Fail(c, fmt.Errorf("route %w", apperr.ErrNotFound))
+	})
+
+	if cfg.Docs.Enabled {
+		r.GET("/docs/*any", docsHandler())
+	}
+
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	// Public
+	auth := r.Group("/auth")
+	auth.Use(middleware.RateLimit(limiter, ratelimit.PolicyAuth))
+	auth.POST("/login", authHandler.Login)
+	auth.POST("/register", authHandler.Register)
+	auth.POST("/refresh", authHandler.Refresh)
+
+	// Protected
+	tasks := r.Group("/tasks")
+	tasks.Use(middleware.JWTMiddleware(cfg.Auth.JWTSecret))
+	tasks.Use(middleware.RateLimit(limiter, ratelimit.PolicyTasks))
+	tasks.POST("", taskHandler.Create)
+	tasks.GET("", taskHandler.GetAllTask)
+	tasks.POST("/batch", middleware.RateLimit(limiter, ratelimit.PolicyBatch), taskHandler.Batch)
+	tasks.GET("/trash", taskHandler.GetTrash)
+	tasks.GET("/:id", taskHandler.GetByID)
+	tasks.PATCH("/:id", taskHandler.Update)
+	tasks.DELETE("/:id", taskHandler.Delete)
+	tasks.POST("/:id/restore", taskHandler.Restore)
+	tasks.GET("/:id/subtasks", taskHandler.GetSubtasks)
+	tasks.GET("/:id/history", taskHandler.GetHistory)
+	tasks.GET("/:id/dependencies", taskHandler.GetDependencies)
+	tasks.POST("/:id/dependencies", taskHandler.AddDependency)
+	tasks.DELETE("/:id/dependencies/:blocker_id", taskHandler.RemoveDependency)
+	tasks.GET("/:id/comments", commentHandler.List)
+	tasks.POST("/:id/comments", commentHandler.Create)
+	tasks.PATCH("/:id/comments/:comment_id", commentHandler.Update)
+	tasks.DELETE("/:id/comments/:comment_id", commentHandler.Delete)
+	tasks.GET("/:id/attachments", attachmentHandler.List)
+	tasks.POST("/:id/attachments", attachmentHandler.Upload)
+	tasks.GET("/:id/attachments/:attachment_id", attachmentHandler.Download)
+	tasks.DELETE("/:id/attachments/:attachment_id", attachmentHandler.Delete)
+
+	tags := r.Group("/tags")
+	tags.Use(middleware.JWTMiddleware(cfg.Auth.JWTSecret))
+	tags.Use(middleware.RateLimit(limiter, ratelimit.PolicyTasks))
+	tags.GET("", tagHandler.List)
+	tags.PATCH("/:name", tagHandler.Rename)
+	tags.POST("/:name/merge", tagHandler.Merge)
+	tags.DELETE("/:name", tagHandler.Delete)
+
+	series := r.Group("/series")
+	series.Use(middleware.JWTMiddleware(cfg.Auth.JWTSecret))
+	series.Use(middleware.RateLimit(limiter, ratelimit.PolicyTasks))
+	series.GET("/:id", seriesHandler.Get)
+	series.DELETE("/:id", seriesHandler.End)
+
+	// Admin-only group
+	admin := auth.Group("/admin")
+	admin.Use(middleware.JWTMiddleware(cfg.Auth.JWTSecret))
+	admin.Use(middleware.AdminOnly())
+	admin.POST("/register", authHandler.RegisterAdmin)
+	admin.GET("/users", authHandler.ListUsers)
+	admin.DELETE("/users/:id", authHandler.DeleteUser)
+
+	return r
+}
+
+// StartWorkers launches the auto-complete worker goroutines and the
+// periodic jobs. They stop when ctx is cancelled.
+func (s *Server) StartWorkers(ctx context.Context, n int) {
+	s.worker.Start(ctx, n)
+	for _, job := range s.jobs {
+		job.Start(ctx)
+	}
+}
+
+// Close closes the task queue and waits for the workers to exit. Cancel
+// the context passed to StartWorkers first.
+func (s *Server) Close() {
+	close(s.queue)
+	s.wg.Wait()
+}
+
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			slog.Warn("ignoring invalid metrics CIDR", "cidr", c, "error", err)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// clientIdentities indexes the configured mTLS identities by subject.
+func clientIdentities(ids []config.ClientIdentity) map[string]middleware.ClientIdentity {
+	out := make(map[string]middleware.ClientIdentity, len(ids))
+	for _, id := range ids {
+		out[id.Subject] = middleware.ClientIdentity{UserID: id.UserID, Role: id.Role}
+	}
+	return out
+}
+
+// isProbePath reports paths polled by probes and scrapers, which are left
+// out of tracing.
+func isProbePath(path string) bool {
+	switch path {
+	case "/metrics", "/healthz", "/readyz":
+		return true
+	}
+	return false
+}
+
+// rateLimitPolicies maps the config onto per-group policies. A disabled
+// limiter gets zero policies, which let everything through.
+func rateLimitPolicies(cfg *config.Config) map[string]ratelimit.Policy {
+	if !cfg.RateLimit.Enabled {
+		return nil
+	}
+	return map[string]ratelimit.Policy{
+		ratelimit.PolicyAuth:  {PerMinute: cfg.RateLimit.Auth.PerMinute, Burst: cfg.RateLimit.Auth.Burst},
+		ratelimit.PolicyTasks: {PerMinute: cfg.RateLimit.Tasks.PerMinute, Burst: cfg.RateLimit.Tasks.Burst},
+		ratelimit.PolicyBatch: {PerMinute: cfg.RateLimit.Batch.PerMinute, Burst: cfg.RateLimit.Batch.Burst},
+	}
+}
@@ -0,0 +1,1902 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/docs"
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/config"
+	"github.com/CashInvoice-Golang-Assignment/internal/health"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/server/servertest"
+)
+
+type taskList struct {
+	Count int           `json:"count"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+func createTask(t *testing.T, ts *servertest.TestServer, token, title string) models.Task {
+	t.Helper()
+
+	var task models.Task
+	resp := ts.Do(t, http.MethodPost, "/tasks", token, map[string]string{
+		"title":       title,
+		"description": "desc",
+	}, &task)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create task: status %d", resp.StatusCode)
+	}
+	return task
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	ts := servertest.New(t)
+
+	ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	resp := ts.Do(t, http.MethodPost, "/auth/register", "", map[string]string{
+		"email":    "alice@test.com",
+		"password": "password123",
+	}, nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("duplicate register: got %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = ts.Do(t, http.MethodPost, "/auth/login", "", map[string]string{
+		"email":    "alice@test.com",
+		"password": "wrong-password",
+	}, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad password: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp = ts.Do(t, http.MethodPost, "/auth/register", "", map[string]string{
+		"email":    "not-an-email",
+		"password": "password123",
+	}, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid email: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestTaskCRUD(t *testing.T) {
+	ts := servertest.New(t)
+	token := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	resp := ts.Do(t, http.MethodGet, "/tasks", "", nil, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("list without token: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp = ts.Do(t, http.MethodPost, "/tasks", token, map[string]string{}, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("create without title: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	created := createTask(t, ts, token, "write tests")
+	if created.Status != models.StatusPending {
+		t.Errorf("new task status = %q, want %q", created.Status, models.StatusPending)
+	}
+
+	var got models.Task
+	resp = ts.Do(t, http.MethodGet, "/tasks/"+created.ID, token, nil, &got)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get task: status %d", resp.StatusCode)
+	}
+	if got.Title != "write tests" || got.Status != models.StatusPending {
+		t.Errorf("get task = %+v", got)
+	}
+
+	var list taskList
+	ts.Do(t, http.MethodGet, "/tasks", token, nil, &list)
+	if list.Count != 1 || len(list.Tasks) != 1 {
+		t.Fatalf("list tasks: count %d, want 1", list.Count)
+	}
+
+	resp = ts.Do(t, http.MethodDelete, "/tasks/"+created.ID, token, nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete task: status %d", resp.StatusCode)
+	}
+	resp = ts.Do(t, http.MethodGet, "/tasks/"+created.ID, token, nil, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get deleted task: got %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestOwnershipAndAdminAccess(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+	ts.CreateAdmin(t, "admin@test.com", "adminpass123")
+	admin := ts.Login(t, "admin@test.com", "adminpass123")
+
+	task := createTask(t, ts, alice, "alice's task")
+	createTask(t, ts, bob, "bob's task")
+
+	resp := ts.Do(t, http.MethodGet, "/tasks/"+task.ID, bob, nil, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob reads alice's task: got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	resp = ts.Do(t, http.MethodDelete, "/tasks/"+task.ID, bob, nil, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob deletes alice's task: got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	var list taskList
+	ts.Do(t, http.MethodGet, "/tasks", bob, nil, &list)
+	if list.Count != 1 {
+		t.Errorf("bob sees %d tasks, want 1", list.Count)
+	}
+	ts.Do(t, http.MethodGet, "/tasks", admin, nil, &list)
+	if list.Count != 2 {
+		t.Errorf("admin sees %d tasks, want 2", list.Count)
+	}
+
+	resp = ts.Do(t, http.MethodGet, "/tasks/"+task.ID, admin, nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("admin reads alice's task: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	newAdmin := map[string]string{"email": "admin2@test.com", "password": "adminpass123"}
+	resp = ts.Do(t, http.MethodPost, "/auth/admin/register", alice, newAdmin, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("user registers admin: got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	resp = ts.Do(t, http.MethodPost, "/auth/admin/register", admin, newAdmin, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("admin registers admin: got %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+
+	resp = ts.Do(t, http.MethodDelete, "/tasks/"+task.ID, admin, nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("admin deletes alice's task: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestAutoComplete(t *testing.T) {
+	ts := servertest.New(t)
+	token := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	task := createTask(t, ts, token, "finishes itself")
+
+	// Wait for the worker to pick the task up and start its timer.
+	ts.Clock.BlockUntil(1)
+
+	ts.Clock.Advance(servertest.AutoCompleteDelay - time.Second)
+	if got, _ := ts.Tasks.GetByID(context.Background(), task.ID); got.Status != models.StatusPending {
+		t.Fatalf("status before delay = %q, want %q", got.Status, models.StatusPending)
+	}
+
+	ts.Clock.Advance(time.Second)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var got models.Task
+		ts.Do(t, http.MethodGet, "/tasks/"+task.ID, token, nil, &got)
+		if got.Status == models.StatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task not auto-completed, status %q", got.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	ts := servertest.New(t)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/tasks", nil)
+	req.Header.Set("X-Request-ID", "trace-me-42")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "trace-me-42" {
+		t.Errorf("propagated X-Request-ID = %q, want trace-me-42", got)
+	}
+
+	resp = ts.Do(t, http.MethodGet, "/tasks", "", nil, nil)
+	if resp.Header.Get("X-Request-ID") == "" {
+		t.Error("generated X-Request-ID missing")
+	}
+}
+
+func scrapeMetrics(t *testing.T, ts *servertest.TestServer, token string) (int, string) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	ts := servertest.New(t)
+	ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	ts.Do(t, http.MethodPost, "/auth/login", "", map[string]string{
+		"email":    "alice@test.com",
+		"password": "wrong-password",
+	}, nil)
+
+	status, body := scrapeMetrics(t, ts, "")
+	if status != http.StatusOK {
+		t.Fatalf("GET /metrics: status %d", status)
+	}
+	for _, want := range []string{
+		`taskapi_auth_login_attempts_total{result="success"} 1`,
+		`taskapi_auth_login_attempts_total{result="failure"} 1`,
+		`taskapi_http_requests_total{method="POST",route="/auth/login",status="200"} 1`,
+		`taskapi_http_request_duration_seconds_bucket{method="POST",route="/auth/register",status="201"`,
+		`taskapi_autocomplete_queue_depth 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
+
+func TestMetricsAccessRestricted(t *testing.T) {
+	ts := servertest.New(t, func(cfg *config.Config) {
+		cfg.Metrics.AllowedCIDRs = []string{"10.0.0.0/8"}
+		cfg.Metrics.Token = "scrape-token"
+	})
+
+	if status, _ := scrapeMetrics(t, ts, ""); status != http.StatusForbidden {
+		t.Errorf("scrape from disallowed address: got %d, want %d", status, http.StatusForbidden)
+	}
+	if status, _ := scrapeMetrics(t, ts, "scrape-token"); status != http.StatusOK {
+		t.Errorf("scrape with token: got %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	ts := servertest.New(t)
+
+	var live map[string]string
+	resp := ts.Do(t, http.MethodGet, "/healthz", "", nil, &live)
+	if resp.StatusCode != http.StatusOK || live["status"] != "ok" {
+		t.Fatalf("GET /healthz: %d %v", resp.StatusCode, live)
+	}
+
+	var ready health.Report
+	resp = ts.Do(t, http.MethodGet, "/readyz", "", nil, &ready)
+	if resp.StatusCode != http.StatusOK || ready.Status != health.StatusOK {
+		t.Fatalf("GET /readyz: %d %+v", resp.StatusCode, ready)
+	}
+	for _, name := range []string{"worker", "queue"} {
+		if ready.Components[name].Status != health.StatusOK {
+			t.Errorf("component %s = %+v", name, ready.Components[name])
+		}
+	}
+
+	ts.App.Health.SetShuttingDown()
+	resp = ts.Do(t, http.MethodGet, "/readyz", "", nil, &ready)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("readyz during shutdown: got %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	if ready.Components["shutdown"].Status != health.StatusFail {
+		t.Errorf("shutdown component = %+v", ready.Components["shutdown"])
+	}
+	resp = ts.Do(t, http.MethodGet, "/healthz", "", nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz during shutdown: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestRateLimiting(t *testing.T) {
+	ts := servertest.New(t, func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Auth = config.RateLimitPolicy{PerMinute: 60, Burst: 3}
+		cfg.RateLimit.Tasks = config.RateLimitPolicy{PerMinute: 60, Burst: 2}
+		cfg.RateLimit.Batch = config.RateLimitPolicy{PerMinute: 60, Burst: 1}
+	})
+
+	// register + login use two of the three auth tokens.
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	creds := map[string]string{"email": "alice@test.com", "password": "password123"}
+
+	resp := ts.Do(t, http.MethodPost, "/auth/login", "", creds, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("third auth request: got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("RateLimit-Remaining") != "0" || resp.Header.Get("RateLimit-Limit") != "3" {
+		t.Errorf("RateLimit headers: limit %q remaining %q",
+			resp.Header.Get("RateLimit-Limit"), resp.Header.Get("RateLimit-Remaining"))
+	}
+
+	resp = ts.Do(t, http.MethodPost, "/auth/login", "", creds, nil)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("fourth auth request: got %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if resp.Header.Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", resp.Header.Get("Retry-After"))
+	}
+
+	ts.Clock.Advance(time.Second)
+	resp = ts.Do(t, http.MethodPost, "/auth/login", "", creds, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("auth after refill: got %d", resp.StatusCode)
+	}
+
+	// Task limits are per user, not per IP.
+	ts.Clock.Advance(time.Minute)
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+	for i := 0; i < 2; i++ {
+		ts.Do(t, http.MethodGet, "/tasks", alice, nil, nil)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks", alice, nil, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("alice over task limit: got %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks", bob, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("bob has a separate bucket: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// A batch also counts against its own, smaller limit.
+	ts.Clock.Advance(time.Minute)
+	carol := ts.RegisterAndLogin(t, "carol@test.com", "password123")
+	batch := map[string]any{
+		"mode":       "best_effort",
+		"operations": []map[string]any{{"op": "create", "title": "Batched"}},
+	}
+	if resp := ts.Do(t, http.MethodPost, "/tasks/batch", carol, batch, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first batch: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	resp = ts.Do(t, http.MethodPost, "/tasks/batch", carol, batch, nil)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("RateLimit-Limit") != "1" {
+		t.Errorf("second batch: got %d with limit %q, want %d with limit 1",
+			resp.StatusCode, resp.Header.Get("RateLimit-Limit"), http.StatusTooManyRequests)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	var cfg config.Config
+	ts := servertest.New(t, func(c *config.Config) { cfg = *c })
+
+	preflight := func() *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodOptions, ts.URL+"/tasks", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	if resp := preflight(); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("preflight before reload: got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	cfg.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.Worker.Count = 3
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Auth = config.RateLimitPolicy{PerMinute: 60, Burst: 1}
+	cfg.Server.Addr = ":9999"
+	res := ts.App.Reload(&cfg)
+
+	if len(res.RestartRequired) != 1 || res.RestartRequired[0] != "server.addr" {
+		t.Errorf("restart required = %v, want [server.addr]", res.RestartRequired)
+	}
+	if len(res.Applied) != 5 {
+		t.Errorf("applied = %v", res.Applied)
+	}
+
+	resp := preflight()
+	if resp.StatusCode != http.StatusNoContent ||
+		resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("preflight after reload: %d, allow-origin %q",
+			resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+
+	var ready health.Report
+	ts.Do(t, http.MethodGet, "/readyz", "", nil, &ready)
+	if got := ready.Components["worker"].Details["started"]; got != float64(3) {
+		t.Errorf("workers started = %v, want 3", got)
+	}
+
+	creds := map[string]string{"email": "nobody@test.com", "password": "password123"}
+	ts.Do(t, http.MethodPost, "/auth/login", "", creds, nil)
+	if resp := ts.Do(t, http.MethodPost, "/auth/login", "", creds, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("second login after reload: got %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+
+	// The restart-only change is still pending on the next reload.
+	if res := ts.App.Reload(&cfg); len(res.RestartRequired) != 1 || len(res.Applied) != 0 {
+		t.Errorf("second reload = %+v", res)
+	}
+}
+
+func TestProblemResponses(t *testing.T) {
+	ts := servertest.New(t)
+	token := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	cases := []struct {
+		name, method, path, token string
+		body                      any
+		status                    int
+		code                      string
+	}{
+		{"missing task", http.MethodGet, "/tasks/nope", token, nil, http.StatusNotFound, "not_found"},
+		{"unknown route", http.MethodGet, "/nope", "", nil, http.StatusNotFound, "not_found"},
+		{"no token", http.MethodGet, "/tasks", "", nil, http.StatusUnauthorized, "unauthorized"},
+		{"duplicate email", http.MethodPost, "/auth/register", "",
+			map[string]string{"email": "alice@test.com", "password": "password123"}, http.StatusConflict, "conflict"},
+		{"wrong password", http.MethodPost, "/auth/login", "",
+			map[string]string{"email": "alice@test.com", "password": "wrong-password"}, http.StatusUnauthorized, "unauthorized"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p apperr.Problem
+			resp := ts.Do(t, tc.method, tc.path, tc.token, tc.body, &p)
+			if resp.StatusCode != tc.status || p.Status != tc.status || p.Code != tc.code {
+				t.Errorf("got %d %+v, want %d %s", resp.StatusCode, p, tc.status, tc.code)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != apperr.ProblemContentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if p.RequestID == "" || p.RequestID != resp.Header.Get("X-Request-ID") {
+				t.Errorf("request_id = %q, header %q", p.RequestID, resp.Header.Get("X-Request-ID"))
+			}
+		})
+	}
+
+	var p apperr.Problem
+	resp := ts.Do(t, http.MethodPost, "/auth/register", "", map[string]string{"email": "not-an-email", "password": "x"}, &p)
+	if resp.StatusCode != http.StatusBadRequest || p.Code != "validation_failed" {
+		t.Fatalf("invalid register: %d %+v", resp.StatusCode, p)
+	}
+	got := map[string]string{}
+	for _, f := range p.Errors {
+		got[f.Field] = f.Code
+	}
+	if got["email"] != "email" || got["password"] != "min" {
+		t.Errorf("field errors = %+v", p.Errors)
+	}
+}
+
+// undocumentedRoutes are served but deliberately left out of the spec.
+var undocumentedRoutes = map[string]bool{
+	"GET /metrics":   true, // Prometheus text format
+	"GET /docs/*any": true, // the docs themselves
+}
+
+// TestOpenAPISpecMatchesRoutes fails when a route is added, removed or
+// renamed without regenerating docs/openapi.json (go generate ./docs).
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	ts := servertest.New(t, func(c *config.Config) { c.Docs.Enabled = true })
+
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(docs.OpenAPI, &spec); err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+	documented := map[string]bool{}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	// Gin writes path parameters as :id, OpenAPI as {id}.
+	param := regexp.MustCompile(`:(\w+)`)
+	served := map[string]bool{}
+	for _, r := range ts.App.Router.Routes() {
+		key := r.Method + " " + param.ReplaceAllString(r.Path, "{$1}")
+		if !undocumentedRoutes[r.Method+" "+r.Path] {
+			served[key] = true
+		}
+	}
+
+	for key := range served {
+		if !documented[key] {
+			t.Errorf("route %s is not in the OpenAPI spec", key)
+		}
+	}
+	for key := range documented {
+		if !served[key] {
+			t.Errorf("spec documents %s, which is not routed", key)
+		}
+	}
+}
+
+func TestDocsEndpoint(t *testing.T) {
+	ts := servertest.New(t, func(c *config.Config) { c.Docs.Enabled = true })
+
+	resp, err := ts.Client().Get(ts.URL + "/docs/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, docs.OpenAPI) {
+		t.Errorf("GET /docs/openapi.json: %d, %d bytes", resp.StatusCode, len(body))
+	}
+
+	resp, err = ts.Client().Get(ts.URL + "/docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "swagger-ui") {
+		t.Errorf("GET /docs/: %d, want Swagger UI", resp.StatusCode)
+	}
+
+	// Off by default.
+	off := servertest.New(t)
+	resp, err = off.Client().Get(off.URL + "/docs/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("docs disabled: got %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	ts := servertest.New(t)
+	ts.Do(t, http.MethodPost, "/auth/register", "", map[string]string{"email": "a@test.com", "password": "password123"}, nil)
+
+	var tokens struct {
+		Token        string    `json:"token"`
+		RefreshToken string    `json:"refresh_token"`
+		ExpiresAt    time.Time `json:"expires_at"`
+	}
+	resp := ts.Do(t, http.MethodPost, "/auth/login", "", map[string]string{"email": "a@test.com", "password": "password123"}, &tokens)
+	if resp.StatusCode != http.StatusOK || tokens.RefreshToken == "" || time.Until(tokens.ExpiresAt) <= 0 {
+		t.Fatalf("login: %d %+v", resp.StatusCode, tokens)
+	}
+
+	// A refresh token does not authenticate API calls...
+	if resp := ts.Do(t, http.MethodGet, "/tasks", tokens.RefreshToken, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh token as access token: got %d, want 401", resp.StatusCode)
+	}
+	// ...and an access token cannot be refreshed.
+	if resp := ts.Do(t, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": tokens.Token}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh with access token: got %d, want 401", resp.StatusCode)
+	}
+
+	resp = ts.Do(t, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": tokens.RefreshToken}, &tokens)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("refresh: got %d", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks", tokens.Token, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("refreshed access token: got %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	ts := servertest.New(t)
+	token := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	for i := range 3 {
+		createTask(t, ts, token, "task "+strconv.Itoa(i))
+	}
+
+	type page struct {
+		taskList
+		NextCursor string `json:"next_cursor"`
+	}
+	var first, second page
+	ts.Do(t, http.MethodGet, "/tasks?limit=2", token, nil, &first)
+	if first.Count != 2 || first.NextCursor == "" {
+		t.Fatalf("first page: %+v", first)
+	}
+	ts.Do(t, http.MethodGet, "/tasks?limit=2&cursor="+first.NextCursor, token, nil, &second)
+	if second.Count != 1 || second.NextCursor != "" ||
+		second.Tasks[0].ID == first.Tasks[0].ID || second.Tasks[0].ID == first.Tasks[1].ID {
+		t.Errorf("second page: %+v", second)
+	}
+
+	for _, q := range []string{"limit=0", "limit=501", "limit=x", "cursor=bogus"} {
+		if resp := ts.Do(t, http.MethodGet, "/tasks?"+q, token, nil, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", q, resp.StatusCode)
+		}
+	}
+}
+
+func TestAdminUsers(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	ts.CreateAdmin(t, "admin@test.com", "adminpass123")
+	admin := ts.Login(t, "admin@test.com", "adminpass123")
+
+	if resp := ts.Do(t, http.MethodGet, "/auth/admin/users", alice, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("alice lists users: got %d, want 403", resp.StatusCode)
+	}
+
+	var list struct {
+		Count int           `json:"count"`
+		Users []models.User `json:"users"`
+	}
+	ts.Do(t, http.MethodGet, "/auth/admin/users", admin, nil, &list)
+	if list.Count != 2 || list.Users[0].Email != "admin@test.com" || list.Users[1].Email != "alice@test.com" {
+		t.Fatalf("users: %+v", list)
+	}
+	adminID, aliceID := list.Users[0].ID, list.Users[1].ID
+
+	var tokens struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	ts.Do(t, http.MethodPost, "/auth/login", "", map[string]string{"email": "alice@test.com", "password": "password123"}, &tokens)
+
+	if resp := ts.Do(t, http.MethodDelete, "/auth/admin/users/"+adminID, admin, nil, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("admin deletes self: got %d, want 409", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodDelete, "/auth/admin/users/"+aliceID, admin, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete alice: got %d", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodDelete, "/auth/admin/users/"+aliceID, admin, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("delete alice again: got %d, want 404", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodPost, "/auth/login", "", map[string]string{"email": "alice@test.com", "password": "password123"}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("deleted user logs in: got %d, want 401", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": tokens.RefreshToken}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("deleted user refreshes: got %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestComments(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+	ts.CreateAdmin(t, "admin@test.com", "adminpass123")
+	admin := ts.Login(t, "admin@test.com", "adminpass123")
+	task := createTask(t, ts, alice, "alice's task")
+	path := "/tasks/" + task.ID + "/comments"
+
+	var first models.Comment
+	resp := ts.Do(t, http.MethodPost, path, alice, map[string]string{"body": "first"}, &first)
+	if resp.StatusCode != http.StatusCreated || first.Edited || first.TaskID != task.ID {
+		t.Fatalf("add comment: %d %+v", resp.StatusCode, first)
+	}
+	var second models.Comment
+	if resp := ts.Do(t, http.MethodPost, path, admin, map[string]string{"body": "from admin"}, &second); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("admin comments: got %d", resp.StatusCode)
+	}
+
+	// Comments follow the task's visibility.
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if resp := ts.Do(t, method, path, bob, map[string]string{"body": "hi"}, nil); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("bob %s comments: got %d, want 403", method, resp.StatusCode)
+		}
+	}
+	if resp := ts.Do(t, http.MethodPost, path, alice, map[string]string{"body": "  "}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("blank comment: got %d, want 400", resp.StatusCode)
+	}
+
+	type commentPage struct {
+		Count      int              `json:"count"`
+		Comments   []models.Comment `json:"comments"`
+		NextCursor string           `json:"next_cursor"`
+	}
+	var page1, page2 commentPage
+	ts.Do(t, http.MethodGet, path+"?limit=1", alice, nil, &page1)
+	if page1.Count != 1 || page1.NextCursor == "" {
+		t.Fatalf("first page: %+v", page1)
+	}
+	ts.Do(t, http.MethodGet, path+"?limit=1&cursor="+page1.NextCursor, alice, nil, &page2)
+	if page2.Count != 1 || page2.NextCursor != "" || page2.Comments[0].ID == page1.Comments[0].ID {
+		t.Errorf("second page: %+v", page2)
+	}
+
+	// Only the author edits; the task owner may delete any comment.
+	if resp := ts.Do(t, http.MethodPatch, path+"/"+second.ID, alice, map[string]string{"body": "rewritten"}, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("alice edits admin's comment: got %d, want 403", resp.StatusCode)
+	}
+	var edited models.Comment
+	resp = ts.Do(t, http.MethodPatch, path+"/"+first.ID, alice, map[string]string{"body": "first, edited"}, &edited)
+	if resp.StatusCode != http.StatusOK || !edited.Edited || edited.Body != "first, edited" {
+		t.Errorf("edit: %d %+v", resp.StatusCode, edited)
+	}
+	if resp := ts.Do(t, http.MethodDelete, path+"/"+second.ID, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("alice deletes admin's comment: got %d", resp.StatusCode)
+	}
+
+	// A comment is only reachable through its own task.
+	other := createTask(t, ts, alice, "other")
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+other.ID+"/comments/"+first.ID, alice, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("comment via another task: got %d, want 404", resp.StatusCode)
+	}
+
+	// Comments go to the trash with their task; TestTrash covers the
+	// purge that removes them.
+	ts.Do(t, http.MethodDelete, "/tasks/"+task.ID, alice, nil, nil)
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+task.ID+"/comments", alice, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("comments of a trashed task: got %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestTags(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+
+	create := func(token, title string, tags ...string) models.Task {
+		t.Helper()
+		var task models.Task
+		resp := ts.Do(t, http.MethodPost, "/tasks", token, map[string]any{"title": title, "tags": tags}, &task)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create %q: status %d", title, resp.StatusCode)
+		}
+		return task
+	}
+	invoice := create(alice, "invoice", "Billing", " q3 ", "billing")
+	if !slices.Equal(invoice.Tags, []string{"billing", "q3"}) {
+		t.Errorf("tags are not normalized: %q", invoice.Tags)
+	}
+	crash := create(alice, "crash", "bug", "billing")
+	plain := create(alice, "plain")
+	if plain.Tags == nil || len(plain.Tags) != 0 {
+		t.Errorf("untagged task has tags %#v, want []", plain.Tags)
+	}
+	create(bob, "bob's bug", "bug")
+
+	titles := func(query string) []string {
+		t.Helper()
+		var list taskList
+		if resp := ts.Do(t, http.MethodGet, "/tasks?"+query, alice, nil, &list); resp.StatusCode != http.StatusOK {
+			t.Fatalf("list %s: status %d", query, resp.StatusCode)
+		}
+		var got []string
+		for _, task := range list.Tasks {
+			got = append(got, task.Title)
+		}
+		slices.Sort(got)
+		return got
+	}
+	for query, want := range map[string][]string{
+		"tag=billing":                   {"crash", "invoice"},
+		"tag=q3&tag=bug":                {"crash", "invoice"},
+		"tag=billing&tag=bug&match=all": {"crash"},
+		"tag=q3&tag=bug&match=all":      nil,
+		"tag=BUG":                       {"crash"},
+	} {
+		if got := titles(query); !slices.Equal(got, want) {
+			t.Errorf("%s: got %q, want %q", query, got, want)
+		}
+	}
+	for _, query := range []string{"match=some", "tag=no%20spaces"} {
+		if resp := ts.Do(t, http.MethodGet, "/tasks?"+query, alice, nil, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", query, resp.StatusCode)
+		}
+	}
+
+	// Updating tags replaces them; omitting them leaves them alone.
+	var updated models.Task
+	ts.Do(t, http.MethodPatch, "/tasks/"+plain.ID, alice, map[string]any{"tags": []string{"q3"}}, &updated)
+	ts.Do(t, http.MethodPatch, "/tasks/"+plain.ID, alice, map[string]any{"title": "renamed"}, &updated)
+	if !slices.Equal(updated.Tags, []string{"q3"}) {
+		t.Errorf("after update: tags %q", updated.Tags)
+	}
+
+	type tagList struct {
+		Count int          `json:"count"`
+		Tags  []models.Tag `json:"tags"`
+	}
+	var tags tagList
+	ts.Do(t, http.MethodGet, "/tags", alice, nil, &tags)
+	if tags.Count != 3 || tags.Tags[0].Name != "billing" || tags.Tags[0].TaskCount != 2 {
+		t.Fatalf("alice's tags: %+v", tags)
+	}
+
+	// Rename onto an existing tag conflicts; merge combines them.
+	if resp := ts.Do(t, http.MethodPatch, "/tags/q3", alice, map[string]string{"name": "billing"}, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("rename onto existing tag: got %d, want 409", resp.StatusCode)
+	}
+	var tag models.Tag
+	if resp := ts.Do(t, http.MethodPatch, "/tags/bug", alice, map[string]string{"name": "defect"}, &tag); resp.StatusCode != http.StatusOK || tag.Name != "defect" || tag.TaskCount != 1 {
+		t.Errorf("rename: %d %+v", resp.StatusCode, tag)
+	}
+	if resp := ts.Do(t, http.MethodPost, "/tags/q3/merge", alice, map[string]string{"into": "billing"}, &tag); resp.StatusCode != http.StatusOK || tag.TaskCount != 3 {
+		t.Errorf("merge: %d %+v", resp.StatusCode, tag)
+	}
+	var got models.Task
+	ts.Do(t, http.MethodGet, "/tasks/"+invoice.ID, alice, nil, &got)
+	if !slices.Equal(got.Tags, []string{"billing"}) {
+		t.Errorf("invoice after merge: %q", got.Tags)
+	}
+
+	// Tags are per user: bob's "bug" is untouched.
+	ts.Do(t, http.MethodGet, "/tags", bob, nil, &tags)
+	if tags.Count != 1 || tags.Tags[0].Name != "bug" {
+		t.Errorf("bob's tags: %+v", tags)
+	}
+	if resp := ts.Do(t, http.MethodDelete, "/tags/billing", bob, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("bob deletes alice's tag: got %d, want 404", resp.StatusCode)
+	}
+
+	if resp := ts.Do(t, http.MethodDelete, "/tags/defect", alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("delete tag: got %d", resp.StatusCode)
+	}
+	ts.Do(t, http.MethodGet, "/tasks/"+crash.ID, alice, nil, &got)
+	if !slices.Equal(got.Tags, []string{"billing"}) {
+		t.Errorf("crash after deleting defect: %q", got.Tags)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+	ts.CreateAdmin(t, "admin@test.com", "admin-password")
+	admin := ts.Login(t, "admin@test.com", "admin-password")
+
+	// create returns the task when it was created and the status code
+	// either way; problem responses do not decode into a Task.
+	create := func(token, title string, parent *models.Task) (models.Task, int) {
+		t.Helper()
+		body := map[string]any{"title": title}
+		if parent != nil {
+			body["parent_id"] = parent.ID
+		}
+		var raw json.RawMessage
+		resp := ts.Do(t, http.MethodPost, "/tasks", token, body, &raw)
+		var task models.Task
+		if resp.StatusCode == http.StatusCreated {
+			json.Unmarshal(raw, &task)
+		}
+		return task, resp.StatusCode
+	}
+	get := func(id string) models.Task {
+		t.Helper()
+		var task models.Task
+		if resp := ts.Do(t, http.MethodGet, "/tasks/"+id, alice, nil, &task); resp.StatusCode != http.StatusOK {
+			t.Fatalf("get %s: status %d", id, resp.StatusCode)
+		}
+		return task
+	}
+	setStatus := func(id string, status models.TaskStatus) int {
+		t.Helper()
+		return ts.Do(t, http.MethodPatch, "/tasks/"+id, alice, map[string]any{"status": status}, nil).StatusCode
+	}
+
+	release, _ := create(alice, "release", nil)
+	docs, _ := create(alice, "docs", &release)
+	build, _ := create(alice, "build", &release)
+	linux, _ := create(alice, "linux", &build)
+	if linux.ParentID == nil || *linux.ParentID != build.ID {
+		t.Fatalf("linux.parent_id = %v, want %s", linux.ParentID, build.ID)
+	}
+	if release.Progress != nil {
+		t.Errorf("new task has progress %d", *release.Progress)
+	}
+
+	// The default depth is 3, and parents must be the caller's own.
+	if _, status := create(alice, "too deep", &linux); status != http.StatusBadRequest {
+		t.Errorf("fourth level: got %d, want 400", status)
+	}
+	if _, status := create(bob, "bob's", &release); status != http.StatusBadRequest {
+		t.Errorf("subtask of another user's task: got %d, want 400", status)
+	}
+	byAdmin, status := create(admin, "added by admin", &release)
+	if status != http.StatusCreated {
+		t.Fatalf("admin adds a subtask: got %d", status)
+	}
+
+	var list taskList
+	ts.Do(t, http.MethodGet, "/tasks/"+release.ID+"/subtasks", alice, nil, &list)
+	if list.Count != 3 || list.Tasks[0].ID != byAdmin.ID {
+		t.Fatalf("subtasks = %+v", list)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+release.ID+"/subtasks", bob, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob lists alice's subtasks: got %d, want 403", resp.StatusCode)
+	}
+
+	// A parent cannot complete while subtasks are open.
+	if status := setStatus(release.ID, models.StatusCompleted); status != http.StatusConflict {
+		t.Errorf("complete with open subtasks: got %d, want 409", status)
+	}
+
+	// Progress counts every level: 1 of linux, docs, build, byAdmin.
+	setStatus(linux.ID, models.StatusCompleted)
+	if got := get(build.ID); got.Status != models.StatusCompleted || got.Progress == nil || *got.Progress != 100 {
+		t.Errorf("build after its only subtask completed: %s, progress %v", got.Status, got.Progress)
+	}
+	if got := get(release.ID); got.Progress == nil || *got.Progress != 50 {
+		t.Errorf("release progress = %v, want 50", got.Progress)
+	}
+
+	// Completing the last subtasks completes the parent; reopening one
+	// reopens it.
+	setStatus(docs.ID, models.StatusCompleted)
+	setStatus(byAdmin.ID, models.StatusCompleted)
+	if got := get(release.ID); got.Status != models.StatusCompleted || *got.Progress != 100 {
+		t.Errorf("release after all subtasks completed: %s, progress %d", got.Status, *got.Progress)
+	}
+	setStatus(linux.ID, models.StatusPending)
+	if got := get(release.ID); got.Status != models.StatusInProgress {
+		t.Errorf("release after linux reopened: %s, want in_progress", got.Status)
+	}
+	if got := get(build.ID); got.Status != models.StatusInProgress {
+		t.Errorf("build after linux reopened: %s, want in_progress", got.Status)
+	}
+
+	// Moving: no cycles, depth still enforced, "" detaches.
+	move := func(id, parentID string) int {
+		t.Helper()
+		return ts.Do(t, http.MethodPatch, "/tasks/"+id, alice, map[string]any{"parent_id": parentID}, nil).StatusCode
+	}
+	if status := move(release.ID, linux.ID); status != http.StatusBadRequest {
+		t.Errorf("move under own subtask: got %d, want 400", status)
+	}
+	if status := move(build.ID, docs.ID); status != http.StatusBadRequest {
+		t.Errorf("move two-level subtree to depth 3: got %d, want 400", status)
+	}
+	if status := move(linux.ID, ""); status != http.StatusOK || get(linux.ID).ParentID != nil {
+		t.Errorf("detach linux: got %d", status)
+	}
+	if got := get(build.ID); got.Progress != nil {
+		t.Errorf("build kept progress %d after losing its subtask", *got.Progress)
+	}
+	if status := move(linux.ID, build.ID); status != http.StatusOK {
+		t.Errorf("move linux back: got %d", status)
+	}
+
+	// The default policy deletes subtasks with their parent.
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+release.ID, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete release: status %d", resp.StatusCode)
+	}
+	for _, task := range []models.Task{docs, build, linux, byAdmin} {
+		if _, err := ts.Tasks.GetByID(context.Background(), task.ID); err == nil {
+			t.Errorf("%s survived its parent's deletion", task.Title)
+		}
+	}
+}
+
+func TestSubtaskPolicies(t *testing.T) {
+	ts := servertest.New(t, func(cfg *config.Config) {
+		cfg.Tasks.MaxDepth = 2
+		cfg.Tasks.AutoCompleteParent = false
+		cfg.Tasks.CompleteWithOpenSubtasks = true
+		cfg.Tasks.OnParentDelete = "promote"
+	})
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	create := func(title, parentID string) (models.Task, int) {
+		t.Helper()
+		var raw json.RawMessage
+		resp := ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{"title": title, "parent_id": parentID}, &raw)
+		var task models.Task
+		if resp.StatusCode == http.StatusCreated {
+			json.Unmarshal(raw, &task)
+		}
+		return task, resp.StatusCode
+	}
+	get := func(id string) models.Task {
+		t.Helper()
+		var task models.Task
+		ts.Do(t, http.MethodGet, "/tasks/"+id, alice, nil, &task)
+		return task
+	}
+
+	top, _ := create("top", "")
+	if top.ParentID != nil {
+		t.Errorf(`parent_id "" on create: got %v, want a top-level task`, *top.ParentID)
+	}
+	child, _ := create("child", top.ID)
+	other, _ := create("other child", top.ID)
+	if _, status := create("grandchild", child.ID); status != http.StatusBadRequest {
+		t.Errorf("third level with max_depth 2: got %d, want 400", status)
+	}
+
+	// complete_with_open_subtasks lets the parent finish first, and
+	// without auto_complete_parent completing subtasks changes nothing.
+	if resp := ts.Do(t, http.MethodPatch, "/tasks/"+top.ID, alice, map[string]any{"status": "completed"}, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("complete with open subtasks: got %d, want 200", resp.StatusCode)
+	}
+	ts.Do(t, http.MethodPatch, "/tasks/"+top.ID, alice, map[string]any{"status": "pending"}, nil)
+	ts.Do(t, http.MethodPatch, "/tasks/"+child.ID, alice, map[string]any{"status": "completed"}, nil)
+	ts.Do(t, http.MethodPatch, "/tasks/"+other.ID, alice, map[string]any{"status": "completed"}, nil)
+	if got := get(top.ID); got.Status != models.StatusPending || *got.Progress != 100 {
+		t.Errorf("top = %s, progress %d; want pending, 100", got.Status, *got.Progress)
+	}
+
+	// promote moves the subtasks up to the deleted task's parent.
+	ts.Do(t, http.MethodDelete, "/tasks/"+top.ID, alice, nil, nil)
+	if got := get(child.ID); got.ID != child.ID || got.ParentID != nil {
+		t.Errorf("child after its parent was deleted: %+v", got)
+	}
+
+	ts = servertest.New(t, func(cfg *config.Config) { cfg.Tasks.OnParentDelete = "restrict" })
+	alice = ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	top, _ = create("top", "")
+	child, _ = create("child", top.ID)
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+top.ID, alice, nil, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("restrict: delete a parent got %d, want 409", resp.StatusCode)
+	}
+	ts.Do(t, http.MethodDelete, "/tasks/"+child.ID, alice, nil, nil)
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+top.ID, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("restrict: delete a task without subtasks got %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+
+	design := createTask(t, ts, alice, "design")
+	build := createTask(t, ts, alice, "build")
+	ship := createTask(t, ts, alice, "ship")
+	other := createTask(t, ts, bob, "bob's")
+
+	// block adds an edge and returns the status code and, on 400, the
+	// code of the field error.
+	block := func(task, blocker models.Task) (int, string) {
+		t.Helper()
+		var p apperr.Problem
+		resp := ts.Do(t, http.MethodPost, "/tasks/"+task.ID+"/dependencies", alice, map[string]string{"blocker_id": blocker.ID}, &p)
+		if resp.StatusCode == http.StatusBadRequest && len(p.Errors) == 1 {
+			return resp.StatusCode, p.Errors[0].Code
+		}
+		return resp.StatusCode, ""
+	}
+	setStatus := func(id string, status models.TaskStatus) int {
+		t.Helper()
+		return ts.Do(t, http.MethodPatch, "/tasks/"+id, alice, map[string]any{"status": status}, nil).StatusCode
+	}
+
+	if status, _ := block(build, design); status != http.StatusCreated {
+		t.Fatalf("build blocked by design: got %d", status)
+	}
+	if status, _ := block(ship, build); status != http.StatusCreated {
+		t.Fatalf("ship blocked by build: got %d", status)
+	}
+	if status, _ := block(ship, build); status != http.StatusConflict {
+		t.Errorf("duplicate edge: got %d, want 409", status)
+	}
+
+	// Self edges and loops, direct or through other tasks, are refused.
+	for _, tc := range []struct {
+		name          string
+		task, blocker models.Task
+	}{
+		{"self", design, design},
+		{"direct", design, build},
+		{"transitive", design, ship},
+	} {
+		if status, code := block(tc.task, tc.blocker); status != http.StatusBadRequest || code != "cycle" {
+			t.Errorf("%s loop: got %d %q, want 400 cycle", tc.name, status, code)
+		}
+	}
+	if status, code := block(design, other); status != http.StatusBadRequest || code != "exists" {
+		t.Errorf("blocked by another user's task: got %d %q, want 400 exists", status, code)
+	}
+
+	var deps struct {
+		BlockedBy []models.Task `json:"blocked_by"`
+		Blocks    []models.Task `json:"blocks"`
+	}
+	ts.Do(t, http.MethodGet, "/tasks/"+build.ID+"/dependencies", alice, nil, &deps)
+	if len(deps.BlockedBy) != 1 || deps.BlockedBy[0].ID != design.ID || len(deps.Blocks) != 1 || deps.Blocks[0].ID != ship.ID {
+		t.Fatalf("build dependencies = %+v", deps)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+build.ID+"/dependencies", bob, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob reads alice's dependencies: got %d, want 403", resp.StatusCode)
+	}
+
+	// A blocked task can be edited but not started or completed, and the
+	// worker leaves it alone.
+	if status := setStatus(build.ID, models.StatusInProgress); status != http.StatusConflict {
+		t.Errorf("start a blocked task: got %d, want 409", status)
+	}
+	if status := setStatus(build.ID, models.StatusCompleted); status != http.StatusConflict {
+		t.Errorf("complete a blocked task: got %d, want 409", status)
+	}
+	if resp := ts.Do(t, http.MethodPatch, "/tasks/"+build.ID, alice, map[string]any{"title": "build it"}, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("rename a blocked task: got %d", resp.StatusCode)
+	}
+	ts.Tasks.AutoCompleteIfPending(context.Background(), build.ID)
+	if got, _ := ts.Tasks.GetByID(context.Background(), build.ID); got.Status != models.StatusPending {
+		t.Errorf("worker completed a blocked task: %s", got.Status)
+	}
+
+	// Completing the blocker frees the task.
+	setStatus(design.ID, models.StatusCompleted)
+	if status := setStatus(build.ID, models.StatusInProgress); status != http.StatusOK {
+		t.Errorf("start an unblocked task: got %d", status)
+	}
+
+	// Removing an edge, or deleting the blocker, unblocks too.
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+ship.ID+"/dependencies/"+build.ID, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("remove dependency: got %d", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+ship.ID+"/dependencies/"+build.ID, alice, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("remove a missing dependency: got %d, want 404", resp.StatusCode)
+	}
+	block(ship, build)
+	ts.Do(t, http.MethodDelete, "/tasks/"+build.ID, alice, nil, nil)
+	if status := setStatus(ship.ID, models.StatusCompleted); status != http.StatusOK {
+		t.Errorf("complete after the blocker was deleted: got %d", status)
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	ts := servertest.New(t, func(cfg *config.Config) { cfg.Tasks.RecurrenceInterval = time.Second })
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+
+	// Daily at 09:00 Berlin time (08:00 UTC), from the fake clock's start.
+	var first models.Task
+	resp := ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{
+		"title": "standup",
+		"recurrence": map[string]any{
+			"rrule":     "FREQ=DAILY",
+			"timezone":  "Europe/Berlin",
+			"starts_at": "2025-01-01T09:00:00+01:00",
+		},
+	}, &first)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create recurring task: got %d", resp.StatusCode)
+	}
+	if first.SeriesID == nil || first.OccursAt == nil || !first.OccursAt.Equal(time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("first occurrence = %+v", first)
+	}
+	seriesID := *first.SeriesID
+
+	// occurrences lists the series' tasks by occurrence time.
+	occurrences := func() []models.Task {
+		t.Helper()
+		var list taskList
+		ts.Do(t, http.MethodGet, "/tasks?series_id="+seriesID, alice, nil, &list)
+		slices.SortFunc(list.Tasks, func(a, b models.Task) int { return a.OccursAt.Compare(*b.OccursAt) })
+		return list.Tasks
+	}
+	day := func(task models.Task) int { return task.OccursAt.Day() }
+
+	for _, tc := range []struct {
+		name string
+		rec  map[string]any
+		code string
+	}{
+		{"bad rule", map[string]any{"rrule": "FREQ=HOURLY"}, "format"},
+		{"bad timezone", map[string]any{"rrule": "FREQ=DAILY", "timezone": "Mars/Olympus"}, "timezone"},
+	} {
+		var p apperr.Problem
+		resp := ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{"title": "x", "recurrence": tc.rec}, &p)
+		if resp.StatusCode != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Code != tc.code {
+			t.Errorf("%s: got %d %+v, want 400 %s", tc.name, resp.StatusCode, p.Errors, tc.code)
+		}
+	}
+
+	// Completing the only open occurrence creates the next one.
+	ts.Do(t, http.MethodPatch, "/tasks/"+first.ID, alice, map[string]any{"status": models.StatusCompleted}, nil)
+	if got := occurrences(); len(got) != 2 || day(got[1]) != 2 || got[1].Status != models.StatusPending {
+		t.Fatalf("after completing the first occurrence: %+v", got)
+	}
+
+	// The generator catches up on schedule, skipping the missed 3rd.
+	ts.Clock.Advance(3*24*time.Hour + 12*time.Hour)
+	deadline := time.Now().Add(3 * time.Second)
+	for len(occurrences()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("generator created no occurrence: %+v", occurrences())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	got := occurrences()
+	if len(got) != 3 || day(got[2]) != 4 {
+		t.Fatalf("after the generator ran: %+v", got)
+	}
+	second, fourth := got[1], got[2]
+
+	// scope=this changes one occurrence; scope=future also the series and
+	// the later occurrences, but never the earlier ones.
+	ts.Do(t, http.MethodPatch, "/tasks/"+fourth.ID, alice, map[string]any{"title": "only the 4th"}, nil)
+	if resp := ts.Do(t, http.MethodPatch, "/tasks/"+second.ID+"?scope=future", alice, map[string]any{"title": "daily sync"}, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("update scope=future: got %d", resp.StatusCode)
+	}
+	got = occurrences()
+	if got[0].Title != "standup" || got[1].Title != "daily sync" || got[2].Title != "daily sync" {
+		t.Errorf("titles after scope=future = %q %q %q", got[0].Title, got[1].Title, got[2].Title)
+	}
+	var series models.Series
+	ts.Do(t, http.MethodGet, "/series/"+seriesID, alice, nil, &series)
+	if series.Title != "daily sync" || series.RRule != "FREQ=DAILY" || series.NextAt == nil {
+		t.Errorf("series = %+v", series)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/series/"+seriesID, bob, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob reads alice's series: got %d, want 403", resp.StatusCode)
+	}
+
+	// Recurrence changes need scope=future and a recurring task.
+	plain := createTask(t, ts, alice, "plain")
+	for _, tc := range []struct {
+		name, path string
+		body       map[string]any
+	}{
+		{"recurrence without scope", "/tasks/" + second.ID, map[string]any{"recurrence": map[string]any{"rrule": "FREQ=WEEKLY"}}},
+		{"unknown scope", "/tasks/" + second.ID + "?scope=all", map[string]any{"title": "x"}},
+		{"scope=future on a plain task", "/tasks/" + plain.ID + "?scope=future", map[string]any{"title": "x"}},
+	} {
+		if resp := ts.Do(t, http.MethodPatch, tc.path, alice, tc.body, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", tc.name, resp.StatusCode)
+		}
+	}
+
+	// A new rule drops the later open occurrences; the next one follows it.
+	resp = ts.Do(t, http.MethodPatch, "/tasks/"+second.ID+"?scope=future", alice, map[string]any{
+		"recurrence": map[string]any{"rrule": "FREQ=WEEKLY", "timezone": "Europe/Berlin"},
+	}, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("change the rule: got %d", resp.StatusCode)
+	}
+	if got = occurrences(); len(got) != 2 {
+		t.Errorf("occurrences after a rule change = %+v", got)
+	}
+	var trash taskList
+	ts.Do(t, http.MethodGet, "/tasks/trash", alice, nil, &trash)
+	if trash.Count != 1 || trash.Tasks[0].SeriesID == nil || *trash.Tasks[0].SeriesID != seriesID {
+		t.Errorf("trash after a rule change = %+v, want the dropped occurrence", trash.Tasks)
+	}
+	ts.Do(t, http.MethodGet, "/series/"+seriesID, alice, nil, &series)
+	if series.RRule != "FREQ=WEEKLY" || series.NextAt == nil || !series.NextAt.Equal(time.Date(2025, 1, 9, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("series after a rule change = %+v", series)
+	}
+
+	// An ended series creates nothing more.
+	var ended models.Series
+	if resp := ts.Do(t, http.MethodDelete, "/series/"+seriesID, alice, nil, &ended); resp.StatusCode != http.StatusOK || ended.NextAt != nil {
+		t.Fatalf("end series: got %d, next_at %v", resp.StatusCode, ended.NextAt)
+	}
+	ts.Do(t, http.MethodPatch, "/tasks/"+second.ID, alice, map[string]any{"status": models.StatusCompleted}, nil)
+	if got = occurrences(); len(got) != 2 {
+		t.Errorf("an ended series created an occurrence: %+v", got)
+	}
+}
+
+func TestRecurringTaskTags(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	var first models.Task
+	ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{
+		"title":      "standup",
+		"tags":       []string{"team", "daily", "misc"},
+		"recurrence": map[string]any{"rrule": "FREQ=DAILY"},
+	}, &first)
+	ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{"title": "retro", "tags": []string{"meeting"}}, nil)
+
+	// Renaming, merging and deleting tags also changes the series, so the
+	// next occurrence does not bring the old names back.
+	for _, tc := range []struct{ method, path, key, value string }{
+		{http.MethodPatch, "/tags/team", "name", "squad"},
+		{http.MethodPost, "/tags/daily/merge", "into", "meeting"},
+		{http.MethodDelete, "/tags/misc", "", ""},
+	} {
+		if resp := ts.Do(t, tc.method, tc.path, alice, map[string]string{tc.key: tc.value}, nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: got %d", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+	var series models.Series
+	ts.Do(t, http.MethodGet, "/series/"+*first.SeriesID, alice, nil, &series)
+	if !slices.Equal(series.Tags, []string{"squad", "meeting"}) {
+		t.Errorf("series tags = %q", series.Tags)
+	}
+
+	ts.Do(t, http.MethodPatch, "/tasks/"+first.ID, alice, map[string]any{"status": models.StatusCompleted}, nil)
+	var list taskList
+	ts.Do(t, http.MethodGet, "/tasks?series_id="+*first.SeriesID, alice, nil, &list)
+	i := slices.IndexFunc(list.Tasks, func(task models.Task) bool { return task.ID != first.ID })
+	if i < 0 {
+		t.Fatalf("occurrences = %+v, want the next one", list.Tasks)
+	}
+	next := list.Tasks[i].Tags
+	slices.Sort(next)
+	if !slices.Equal(next, []string{"meeting", "squad"}) {
+		t.Errorf("next occurrence tags = %q, want [meeting squad]", next)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+
+	// report > chapter > figure, deleted together under the default
+	// cascade policy.
+	var report, chapter, figure models.Task
+	ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{"title": "report", "tags": []string{"work"}}, &report)
+	ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{"title": "chapter", "parent_id": report.ID}, &chapter)
+	ts.Do(t, http.MethodPost, "/tasks", alice, map[string]any{"title": "figure", "parent_id": chapter.ID}, &figure)
+	review := createTask(t, ts, alice, "review")
+	ts.Do(t, http.MethodPost, "/tasks/"+report.ID+"/comments", alice, map[string]string{"body": "draft"}, nil)
+	ts.Do(t, http.MethodPost, "/tasks/"+review.ID+"/dependencies", alice, map[string]string{"blocker_id": report.ID}, nil)
+
+	if resp := ts.Do(t, http.MethodDelete, "/tasks/"+report.ID, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete: got %d", resp.StatusCode)
+	}
+
+	// Trashed tasks are gone from everything but the trash.
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+chapter.ID, alice, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get a trashed task: got %d, want 404", resp.StatusCode)
+	}
+	var list taskList
+	ts.Do(t, http.MethodGet, "/tasks", alice, nil, &list)
+	if list.Count != 1 || list.Tasks[0].ID != review.ID {
+		t.Errorf("live tasks = %+v, want only review", list.Tasks)
+	}
+	var tags struct {
+		Tags []models.Tag `json:"tags"`
+	}
+	ts.Do(t, http.MethodGet, "/tags", alice, nil, &tags)
+	if len(tags.Tags) != 1 || tags.Tags[0].TaskCount != 0 {
+		t.Errorf("tags = %+v, want work on no live task", tags.Tags)
+	}
+	var trash taskList
+	ts.Do(t, http.MethodGet, "/tasks/trash", alice, nil, &trash)
+	if trash.Count != 3 || trash.Tasks[0].DeletedAt == nil {
+		t.Errorf("trash = %+v, want the 3 deleted tasks", trash.Tasks)
+	}
+	ts.Do(t, http.MethodGet, "/tasks/trash", bob, nil, &trash)
+	if trash.Count != 0 {
+		t.Errorf("bob's trash = %+v, want empty", trash.Tasks)
+	}
+
+	// A trashed blocker no longer blocks, and the worker leaves trashed
+	// tasks alone.
+	if resp := ts.Do(t, http.MethodPatch, "/tasks/"+review.ID, alice, map[string]any{"status": models.StatusInProgress}, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("start a task blocked by a trashed task: got %d", resp.StatusCode)
+	}
+	ts.Tasks.AutoCompleteIfPending(context.Background(), figure.ID)
+	ts.Do(t, http.MethodGet, "/tasks/trash", alice, nil, &trash)
+	for _, task := range trash.Tasks {
+		if task.Status != models.StatusPending {
+			t.Errorf("trashed task %s was completed", task.Title)
+		}
+	}
+
+	for _, tc := range []struct {
+		name, id, token string
+		want            int
+	}{
+		{"a live task", review.ID, alice, http.StatusNotFound},
+		{"another user's task", report.ID, bob, http.StatusForbidden},
+		{"a subtask of a trashed task", chapter.ID, alice, http.StatusConflict},
+	} {
+		if resp := ts.Do(t, http.MethodPost, "/tasks/"+tc.id+"/restore", tc.token, nil, nil); resp.StatusCode != tc.want {
+			t.Errorf("restore %s: got %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+
+	// Restoring brings back the subtree, tags, comments and dependencies.
+	var restored models.Task
+	if resp := ts.Do(t, http.MethodPost, "/tasks/"+report.ID+"/restore", alice, nil, &restored); resp.StatusCode != http.StatusOK {
+		t.Fatalf("restore: got %d", resp.StatusCode)
+	}
+	if restored.DeletedAt != nil || len(restored.Tags) != 1 || restored.Progress == nil {
+		t.Errorf("restored = %+v", restored)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+figure.ID, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("get a restored subtask: got %d", resp.StatusCode)
+	}
+	var comments struct {
+		Count int `json:"count"`
+	}
+	ts.Do(t, http.MethodGet, "/tasks/"+report.ID+"/comments", alice, nil, &comments)
+	if comments.Count != 1 {
+		t.Errorf("comments after restore = %d, want 1", comments.Count)
+	}
+	if resp := ts.Do(t, http.MethodPatch, "/tasks/"+review.ID, alice, map[string]any{"status": models.StatusCompleted}, nil); resp.StatusCode != http.StatusConflict {
+		t.Errorf("complete a task blocked by a restored task: got %d, want 409", resp.StatusCode)
+	}
+	ts.Do(t, http.MethodGet, "/tasks/trash", alice, nil, &trash)
+	if trash.Count != 0 {
+		t.Errorf("trash after restore = %+v", trash.Tasks)
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+	ts.CreateAdmin(t, "admin@test.com", "adminpass123")
+	admin := ts.Login(t, "admin@test.com", "adminpass123")
+
+	task := createTask(t, ts, alice, "draft")
+	ts.Do(t, http.MethodPatch, "/tasks/"+task.ID, admin, map[string]any{"title": "final", "status": models.StatusInProgress}, nil)
+
+	// The worker completes the task once its delay has passed.
+	ts.Clock.BlockUntil(1)
+	ts.Clock.Advance(servertest.AutoCompleteDelay)
+
+	type history struct {
+		Count      int                `json:"count"`
+		Events     []models.TaskEvent `json:"events"`
+		NextCursor string             `json:"next_cursor"`
+	}
+	var h history
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h = history{}
+		ts.Do(t, http.MethodGet, "/tasks/"+task.ID+"/history", alice, nil, &h)
+		if h.Count == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("history = %+v, want 3 events", h.Events)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	created, updated, completed := h.Events[0], h.Events[1], h.Events[2]
+	if created.Type != models.EventCreated || created.Actor != models.ActorUser || created.ActorID == "" {
+		t.Errorf("first event = %+v", created)
+	}
+	if updated.Type != models.EventUpdated || updated.Actor != models.ActorAdmin || len(updated.Changes) != 2 ||
+		updated.Changes[0] != (models.FieldChange{Field: "title", Old: "draft", New: "final"}) {
+		t.Errorf("admin's update = %+v", updated)
+	}
+	if completed.Type != models.EventStatusChanged || completed.Actor != models.ActorAutoComplete || completed.ActorID != "" ||
+		completed.Changes[0] != (models.FieldChange{Field: "status", Old: "in_progress", New: "completed"}) {
+		t.Errorf("auto-complete = %+v", completed)
+	}
+
+	var first, second history
+	ts.Do(t, http.MethodGet, "/tasks/"+task.ID+"/history?limit=2", alice, nil, &first)
+	if first.Count != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	ts.Do(t, http.MethodGet, "/tasks/"+task.ID+"/history?limit=2&cursor="+first.NextCursor, alice, nil, &second)
+	if second.Count != 1 || second.Events[0].ID != completed.ID || second.NextCursor != "" {
+		t.Errorf("second page = %+v", second)
+	}
+
+	// History is visible exactly where the task is.
+	for _, tc := range []struct {
+		name, id, token string
+		want            int
+	}{
+		{"the admin", task.ID, admin, http.StatusOK},
+		{"another user", task.ID, bob, http.StatusForbidden},
+		{"a missing task", "nope", alice, http.StatusNotFound},
+	} {
+		if resp := ts.Do(t, http.MethodGet, "/tasks/"+tc.id+"/history", tc.token, nil, nil); resp.StatusCode != tc.want {
+			t.Errorf("history as %s: got %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+	ts.Do(t, http.MethodDelete, "/tasks/"+task.ID, alice, nil, nil)
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+task.ID+"/history", alice, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("history of a trashed task: got %d, want 404", resp.StatusCode)
+	}
+}
+
+// conditional sends a request with one precondition header and returns
+// the response status and ETag.
+func conditional(t *testing.T, ts *servertest.TestServer, method, path, token, header, etag string, body any) (int, string) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		reader = bytes.NewReader(b)
+	}
+	req, _ := http.NewRequest(method, ts.URL+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(header, etag)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get("ETag")
+}
+
+func TestETags(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+
+	task := createTask(t, ts, alice, "draft")
+	if task.Version != 1 {
+		t.Errorf("new task version = %d, want 1", task.Version)
+	}
+	resp := ts.Do(t, http.MethodGet, "/tasks/"+task.ID, alice, nil, nil)
+	etag := resp.Header.Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag = %q, want \"1\"", etag)
+	}
+
+	// Polling with the current tag costs no body; weak and listed tags
+	// count too.
+	for _, tag := range []string{etag, "W/" + etag, `"7", ` + etag, "*"} {
+		if status, _ := conditional(t, ts, http.MethodGet, "/tasks/"+task.ID, alice, "If-None-Match", tag, nil); status != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: got %d, want 304", tag, status)
+		}
+	}
+	if status, _ := conditional(t, ts, http.MethodGet, "/tasks/"+task.ID, alice, "If-None-Match", `"7"`, nil); status != http.StatusOK {
+		t.Errorf("If-None-Match with another tag: got %d, want 200", status)
+	}
+
+	// An update with the current tag succeeds and moves the version on;
+	// the old tag is then stale.
+	status, newTag := conditional(t, ts, http.MethodPatch, "/tasks/"+task.ID, alice, "If-Match", etag, map[string]string{"title": "final"})
+	if status != http.StatusOK || newTag != `"2"` {
+		t.Fatalf("update with current tag: got %d %q, want 200 \"2\"", status, newTag)
+	}
+	if status, _ := conditional(t, ts, http.MethodPatch, "/tasks/"+task.ID, alice, "If-Match", etag, map[string]string{"title": "lost"}); status != http.StatusPreconditionFailed {
+		t.Errorf("update with stale tag: got %d, want 412", status)
+	}
+	if status, _ := conditional(t, ts, http.MethodDelete, "/tasks/"+task.ID, alice, "If-Match", etag, nil); status != http.StatusPreconditionFailed {
+		t.Errorf("delete with stale tag: got %d, want 412", status)
+	}
+	if status, _ := conditional(t, ts, http.MethodGet, "/tasks/"+task.ID, alice, "If-None-Match", etag, nil); status != http.StatusOK {
+		t.Errorf("If-None-Match with stale tag: got %d, want 200", status)
+	}
+	var got models.Task
+	ts.Do(t, http.MethodGet, "/tasks/"+task.ID, alice, nil, &got)
+	if got.Title != "final" || got.Version != 2 {
+		t.Errorf("task = %q version %d, want final at 2", got.Title, got.Version)
+	}
+
+	// Renaming a tag is a write to the tasks carrying it.
+	ts.Do(t, http.MethodPatch, "/tasks/"+task.ID, alice, map[string]any{"tags": []string{"work"}}, nil)
+	ts.Do(t, http.MethodPatch, "/tags/work", alice, map[string]string{"name": "job"}, nil)
+	got = models.Task{}
+	ts.Do(t, http.MethodGet, "/tasks/"+task.ID, alice, nil, &got)
+	if got.Version != 4 {
+		t.Errorf("version after tag rename = %d, want 4", got.Version)
+	}
+
+	if status, _ := conditional(t, ts, http.MethodDelete, "/tasks/"+task.ID, alice, "If-Match", got.ETag(), nil); status != http.StatusOK {
+		t.Errorf("delete with current tag: got %d, want 200", status)
+	}
+}
+
+type batchResponse struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Results   []struct {
+		Status int             `json:"status"`
+		Task   *models.Task    `json:"task"`
+		Error  *apperr.Problem `json:"error"`
+	} `json:"results"`
+}
+
+func TestBatch(t *testing.T) {
+	ts := servertest.New(t)
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+
+	report := createTask(t, ts, alice, "report")
+	draft := createTask(t, ts, alice, "draft")
+	bobs := createTask(t, ts, bob, "bob's")
+	ts.Clock.BlockUntil(1)
+
+	statuses := func(out batchResponse) []int {
+		var got []int
+		for _, r := range out.Results {
+			got = append(got, r.Status)
+		}
+		return got
+	}
+	count := func() int {
+		var list taskList
+		ts.Do(t, http.MethodGet, "/tasks", alice, nil, &list)
+		return list.Count
+	}
+
+	// One failing operation keeps an atomic batch from changing anything.
+	var out batchResponse
+	resp := ts.Do(t, http.MethodPost, "/tasks/batch", alice, map[string]any{
+		"mode": "atomic",
+		"operations": []map[string]any{
+			{"op": "create", "title": "new"},
+			{"op": "update", "id": report.ID, "title": "final report"},
+			{"op": "status", "id": bobs.ID, "status": models.StatusCompleted},
+		},
+	}, &out)
+	if resp.StatusCode != http.StatusMultiStatus || !slices.Equal(statuses(out), []int{424, 424, 403}) || out.Failed != 3 {
+		t.Fatalf("atomic batch with a forbidden operation: %d %v", resp.StatusCode, statuses(out))
+	}
+	if out.Results[2].Error.Code != "forbidden" || out.Results[0].Error.Code != "failed_dependency" {
+		t.Errorf("errors = %+v, %+v", out.Results[2].Error, out.Results[0].Error)
+	}
+	var got models.Task
+	ts.Do(t, http.MethodGet, "/tasks/"+report.ID, alice, nil, &got)
+	if n := count(); n != 2 || got.Title != "report" || got.Version != 1 {
+		t.Errorf("after rolled back batch: %d tasks, report %q v%d", n, got.Title, got.Version)
+	}
+
+	// A task may only be changed once in an atomic batch.
+	out = batchResponse{}
+	ts.Do(t, http.MethodPost, "/tasks/batch", alice, map[string]any{
+		"mode": "atomic",
+		"operations": []map[string]any{
+			{"op": "update", "id": report.ID, "title": "final report"},
+			{"op": "delete", "id": report.ID},
+		},
+	}, &out)
+	if !slices.Equal(statuses(out), []int{424, 409}) {
+		t.Errorf("atomic batch changing a task twice: %v", statuses(out))
+	}
+
+	// Nor may it complete a task and put open subtasks under it.
+	out = batchResponse{}
+	ts.Do(t, http.MethodPost, "/tasks/batch", alice, map[string]any{
+		"mode": "atomic",
+		"operations": []map[string]any{
+			{"op": "status", "id": report.ID, "status": models.StatusCompleted},
+			{"op": "create", "title": "chapter", "parent_id": report.ID},
+			{"op": "update", "id": draft.ID, "parent_id": report.ID},
+		},
+	}, &out)
+	if !slices.Equal(statuses(out), []int{424, 409, 409}) {
+		t.Errorf("atomic batch completing a parent it adds an open subtask to: %v", statuses(out))
+	}
+
+	out = batchResponse{}
+	resp = ts.Do(t, http.MethodPost, "/tasks/batch", alice, map[string]any{
+		"mode": "atomic",
+		"operations": []map[string]any{
+			{"op": "create", "title": "chapter", "parent_id": report.ID, "tags": []string{"work"}},
+			{"op": "update", "id": report.ID, "title": "final report", "if_match": `"1"`},
+			{"op": "delete", "id": draft.ID},
+		},
+	}, &out)
+	if resp.StatusCode != http.StatusOK || !slices.Equal(statuses(out), []int{201, 200, 200}) || out.Succeeded != 3 {
+		t.Fatalf("atomic batch: %d %v", resp.StatusCode, statuses(out))
+	}
+	chapter := out.Results[0].Task
+	if chapter == nil || chapter.ParentID == nil || *chapter.ParentID != report.ID || out.Results[1].Task.Version != 2 {
+		t.Errorf("results = %+v", out.Results)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+draft.ID, alice, nil, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted task: got %d, want 404", resp.StatusCode)
+	}
+	var h struct {
+		Events []models.TaskEvent `json:"events"`
+	}
+	ts.Do(t, http.MethodGet, "/tasks/"+report.ID+"/history", alice, nil, &h)
+	if len(h.Events) != 2 || h.Events[1].Type != models.EventUpdated {
+		t.Errorf("report history = %+v", h.Events)
+	}
+
+	// Best effort applies what it can, in order.
+	out = batchResponse{}
+	resp = ts.Do(t, http.MethodPost, "/tasks/batch", alice, map[string]any{
+		"mode": "best_effort",
+		"operations": []map[string]any{
+			{"op": "create", "title": "appendix"},
+			{"op": "create"},
+			{"op": "status", "id": chapter.ID, "status": models.StatusInProgress},
+			{"op": "delete", "id": bobs.ID},
+			{"op": "update", "id": report.ID, "title": "lost", "if_match": `"1"`},
+		},
+	}, &out)
+	if resp.StatusCode != http.StatusMultiStatus || !slices.Equal(statuses(out), []int{201, 400, 200, 403, 412}) {
+		t.Fatalf("best-effort batch: %d %v", resp.StatusCode, statuses(out))
+	}
+	if out.Succeeded != 2 || out.Failed != 3 || out.Results[1].Error.Errors[0].Field != "title" {
+		t.Errorf("best-effort results = %+v", out)
+	}
+	if n := count(); n != 3 {
+		t.Errorf("alice has %d tasks, want 3", n)
+	}
+
+	for _, body := range []map[string]any{
+		{"mode": "atomic", "operations": []map[string]any{}},
+		{"mode": "sometimes", "operations": []map[string]any{{"op": "create", "title": "x"}}},
+		{"mode": "atomic", "operations": []map[string]any{{"op": "archive", "id": report.ID}}},
+		{"mode": "atomic", "operations": slices.Repeat([]map[string]any{{"op": "create", "title": "x"}}, 101)},
+	} {
+		if resp := ts.Do(t, http.MethodPost, "/tasks/batch", alice, body, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("batch %v: got %d, want 400", body["mode"], resp.StatusCode)
+		}
+	}
+
+	// Tasks created in a batch are auto-completed like any other.
+	ts.Clock.Advance(servertest.AutoCompleteDelay)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got = models.Task{}
+		ts.Do(t, http.MethodGet, "/tasks/"+out.Results[0].Task.ID, alice, nil, &got)
+		if got.Status == models.StatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch-created task not auto-completed, status %q", got.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		ts.Clock.Advance(servertest.AutoCompleteDelay)
+	}
+}
+
+// attachmentResult is the body of an upload: the attachment, or a problem.
+type attachmentResult struct {
+	models.Attachment
+	Code string `json:"code"`
+}
+
+// upload sends content as the file field of a multipart form.
+func upload(t *testing.T, ts *servertest.TestServer, token, taskID, name string, content []byte) (int, attachmentResult) {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", name)
+	fw.Write(content)
+	mw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/tasks/"+taskID+"/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out attachmentResult
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode upload response: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestAttachments(t *testing.T) {
+	ts := servertest.New(t, func(cfg *config.Config) {
+		cfg.Attachments.MaxSize = 1000
+		cfg.Attachments.UserQuota = 1520
+		cfg.Attachments.AllowedTypes = []string{"image/*", "text/plain"}
+	})
+	ts.CreateAdmin(t, "admin@test.com", "adminpass123")
+	admin := ts.Login(t, "admin@test.com", "adminpass123")
+	alice := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	bob := ts.RegisterAndLogin(t, "bob@test.com", "password123")
+	report := createTask(t, ts, alice, "report")
+	other := createTask(t, ts, alice, "other")
+
+	notes := []byte("the numbers are final\n")
+	status, receipt := upload(t, ts, alice, report.ID, `C:\scans\receipt.txt`, notes)
+	if status != http.StatusCreated {
+		t.Fatalf("upload: got %d (%s)", status, receipt.Code)
+	}
+	sum := sha256.Sum256(notes)
+	if receipt.Name != "receipt.txt" || receipt.Size != int64(len(notes)) || receipt.ContentType != "text/plain; charset=utf-8" ||
+		receipt.Checksum != hex.EncodeToString(sum[:]) || receipt.UserID != receipt.UploaderID {
+		t.Errorf("attachment = %+v", receipt.Attachment)
+	}
+
+	// An admin's upload counts against the task owner.
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 600)...)
+	status, shot := upload(t, ts, admin, report.ID, "screenshot.png", png)
+	if status != http.StatusCreated || shot.ContentType != "image/png" || shot.UserID != receipt.UserID || shot.UploaderID == receipt.UserID {
+		t.Fatalf("admin upload: got %d, %+v", status, shot.Attachment)
+	}
+
+	for _, tc := range []struct {
+		name, token, task, file string
+		content                 []byte
+		want                    int
+		code                    string
+	}{
+		{"to another user's task", bob, report.ID, "a.txt", notes, http.StatusForbidden, "forbidden"},
+		{"to a missing task", alice, "missing", "a.txt", notes, http.StatusNotFound, "not_found"},
+		{"an empty file", alice, report.ID, "a.txt", nil, http.StatusBadRequest, "validation_failed"},
+		{"an HTML page named .txt", alice, report.ID, "a.txt", []byte("<html><script>alert(1)</script>"), http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"a file over the size limit", alice, report.ID, "a.txt", bytes.Repeat([]byte("a"), 1001), http.StatusRequestEntityTooLarge, "too_large"},
+		{"a file over the quota", alice, other.ID, "a.txt", bytes.Repeat([]byte("a"), 900), http.StatusInsufficientStorage, "quota_exceeded"},
+	} {
+		if status, out := upload(t, ts, tc.token, tc.task, tc.file, tc.content); status != tc.want || out.Code != tc.code {
+			t.Errorf("upload %s: got %d %q, want %d %q", tc.name, status, out.Code, tc.want, tc.code)
+		}
+	}
+	if resp := ts.Do(t, http.MethodPost, "/tasks/"+report.ID+"/attachments", alice, map[string]string{"file": "x"}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("upload as JSON: got %d, want 400", resp.StatusCode)
+	}
+
+	var list struct {
+		Count       int                 `json:"count"`
+		Attachments []models.Attachment `json:"attachments"`
+		NextCursor  string              `json:"next_cursor"`
+	}
+	ts.Do(t, http.MethodGet, "/tasks/"+report.ID+"/attachments?limit=1", alice, nil, &list)
+	if list.Count != 1 || list.Attachments[0].ID != receipt.ID || list.NextCursor == "" {
+		t.Errorf("first page = %+v", list)
+	}
+	if resp := ts.Do(t, http.MethodGet, "/tasks/"+report.ID+"/attachments", bob, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob lists alice's attachments: got %d, want 403", resp.StatusCode)
+	}
+
+	download := func(token, path, ifNoneMatch string) (*http.Response, []byte) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, b
+	}
+	path := "/tasks/" + report.ID + "/attachments/" + receipt.ID
+	resp, content := download(alice, path, "")
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(content, notes) {
+		t.Fatalf("download: got %d %q", resp.StatusCode, content)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename=receipt.txt` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if resp.Header.Get("Content-Type") != receipt.ContentType || resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("download headers = %v", resp.Header)
+	}
+	if resp, _ := download(alice, path, resp.Header.Get("ETag")); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("download with a current If-None-Match: got %d, want 304", resp.StatusCode)
+	}
+	for _, tc := range []struct {
+		name, token, path string
+		want              int
+	}{
+		{"as another user", bob, path, http.StatusForbidden},
+		{"through another task", alice, "/tasks/" + other.ID + "/attachments/" + receipt.ID, http.StatusNotFound},
+		{"a missing attachment", alice, "/tasks/" + report.ID + "/attachments/missing", http.StatusNotFound},
+	} {
+		if resp, _ := download(tc.token, tc.path, ""); resp.StatusCode != tc.want {
+			t.Errorf("download %s: got %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+
+	// Attachments are out of reach while their task is in the trash, and
+	// come back with it.
+	ts.Do(t, http.MethodDelete, "/tasks/"+report.ID, alice, nil, nil)
+	if resp, _ := download(alice, path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("download from a trashed task: got %d, want 404", resp.StatusCode)
+	}
+	ts.Do(t, http.MethodPost, "/tasks/"+report.ID+"/restore", alice, nil, nil)
+	if resp, _ := download(alice, path, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("download after restore: got %d, want 200", resp.StatusCode)
+	}
+
+	// Deleting frees the quota and the stored content.
+	if resp := ts.Do(t, http.MethodDelete, path, bob, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bob deletes alice's attachment: got %d, want 403", resp.StatusCode)
+	}
+	if resp := ts.Do(t, http.MethodDelete, path, alice, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete: got %d", resp.StatusCode)
+	}
+	if resp, _ := download(alice, path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("download a deleted attachment: got %d, want 404", resp.StatusCode)
+	}
+	if _, err := ts.Blobs.Get(context.Background(), report.ID+"/"+receipt.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("content of a deleted attachment: err = %v, want ErrNotFound", err)
+	}
+	if status, out := upload(t, ts, alice, other.ID, "a.txt", bytes.Repeat([]byte("a"), 900)); status != http.StatusCreated {
+		t.Errorf("upload after freeing quota: got %d %q", status, out.Code)
+	}
+}
@@ -0,0 +1,191 @@
+// Package servertest starts a fully wired server on top of in-memory
+// repositories and a fake clock, for end-to-end HTTP tests.
+package servertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/blob"
+	"github.com/CashInvoice-Golang-Assignment/internal/clock"
+	"github.com/CashInvoice-Golang-Assignment/internal/config"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+	"github.com/CashInvoice-Golang-Assignment/internal/server"
+	"github.com/CashInvoice-Golang-Assignment/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const JWTSecret = "test-secret-at-least-32-characters"
+
+// AutoCompleteDelay is the delay the test server's worker waits before
+// auto-completing a task.
+const AutoCompleteDelay = time.Minute
+
+type TestServer struct {
+	*httptest.Server
+
+	App      *server.Server
+	Clock    *clock.Fake
+	Tasks    *repository.MemoryTaskRepository
+	Users    *repository.MemoryUserRepository
+	Comments *repository.MemoryCommentRepository
+	Events   *repository.MemoryTaskEventRepository
+	Tags     *repository.MemoryTagRepository
+	Deps     *repository.MemoryDependencyRepository
+	Series   *repository.MemorySeriesRepository
+
+	Attachments *repository.MemoryAttachmentRepository
+	// Blobs keeps attachment content under a temporary directory.
+	Blobs *blob.FileStore
+}
+
+// New starts a test server with one auto-complete worker. Options may adjust
+// the config before the server is built. It is shut down when the test
+// finishes.
+func New(t testing.TB, opts ...func(*config.Config)) *TestServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	// The memory repositories ignore DB settings; sqlite just needs none
+	// of the MySQL connection fields to pass validation.
+	cfg.DB.Driver = "sqlite"
+	cfg.Auth.JWTSecret = JWTSecret
+	cfg.Worker.AutoCompleteMinutes = int(AutoCompleteDelay / time.Minute)
+	cfg.Health.DBMaxLatency = time.Second
+	// Tests log in repeatedly; TestRateLimiting opts back in.
+	cfg.RateLimit.Enabled = false
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	ts := &TestServer{
+		Clock:    clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Tasks:    repository.NewMemoryTaskRepository(),
+		Users:    repository.NewMemoryUserRepository(),
+		Comments: repository.NewMemoryCommentRepository(),
+		Events:   repository.NewMemoryTaskEventRepository(),
+		Series:   repository.NewMemorySeriesRepository(),
+
+		Attachments: repository.NewMemoryAttachmentRepository(),
+	}
+	blobs, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Blobs = blobs
+
+	ts.Tags = repository.NewMemoryTagRepository(ts.Tasks, ts.Series)
+	ts.Deps = repository.NewMemoryDependencyRepository(ts.Tasks)
+
+	app := server.New(cfg, server.Deps{
+		TaskRepo:    ts.Tasks,
+		UserRepo:    ts.Users,
+		CommentRepo: ts.Comments,
+		EventRepo:   ts.Events,
+		TagRepo:     ts.Tags,
+		DepRepo:     ts.Deps,
+		SeriesRepo:  ts.Series,
+		Clock:       ts.Clock,
+
+		AttachmentRepo: ts.Attachments,
+		BlobStore:      ts.Blobs,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	app.StartWorkers(ctx, 1)
+	ts.App = app
+	ts.Server = httptest.NewServer(app.Router)
+
+	t.Cleanup(func() {
+		ts.Server.Close()
+		cancel()
+		app.Close()
+	})
+	return ts
+}
+
+// CreateAdmin inserts an admin user directly, the same way the README
+// bootstraps the first admin.
+func (ts *TestServer) CreateAdmin(t testing.TB, email, password string) {
+	t.Helper()
+	if err := service.NewAuthService(ts.Users, service.Timeouts{}, nil).
+		RegisterAdmin(context.Background(), email, password); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+}
+
+// Do sends a JSON request, optionally authenticated with token, and decodes
+// the JSON response into out when out is non-nil.
+func (ts *TestServer) Do(t testing.TB, method, path, token string, body, out any) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp
+}
+
+// Login logs in an existing user and returns its token.
+func (ts *TestServer) Login(t testing.TB, email, password string) string {
+	t.Helper()
+
+	var out struct {
+		Token string `json:"token"`
+	}
+	resp := ts.Do(t, http.MethodPost, "/auth/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	}, &out)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login %s: status %d", email, resp.StatusCode)
+	}
+	return out.Token
+}
+
+// RegisterAndLogin creates a regular user and returns its token.
+func (ts *TestServer) RegisterAndLogin(t testing.TB, email, password string) string {
+	t.Helper()
+
+	resp := ts.Do(t, http.MethodPost, "/auth/register", "", map[string]string{
+		"email":    email,
+		"password": password,
+	}, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("register %s: status %d", email, resp.StatusCode)
+	}
+	return ts.Login(t, email, password)
+}
@@ -0,0 +1,74 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/server/servertest"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ts := servertest.New(t)
+	token := ts.RegisterAndLogin(t, "alice@test.com", "password123")
+	createTask(t, ts, token, "traced")
+
+	ts.Clock.BlockUntil(1)
+	ts.Clock.Advance(servertest.AutoCompleteDelay)
+
+	var job sdktrace.ReadOnlySpan
+	deadline := time.Now().Add(2 * time.Second)
+	for job == nil && time.Now().Before(deadline) {
+		for _, s := range recorder.Ended() {
+			if s.Name() == "AutoCompleteWorker.Job" {
+				job = s
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job == nil {
+		t.Fatal("no AutoCompleteWorker.Job span recorded")
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	request, ok := spans["POST /tasks"]
+	if !ok {
+		t.Fatalf("no server span for POST /tasks, got %v", keys(spans))
+	}
+	create, ok := spans["TaskService.CreateTask"]
+	if !ok {
+		t.Fatal("no TaskService.CreateTask span")
+	}
+	if create.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("service span is not a child of the request span")
+	}
+	if _, ok := spans["bcrypt.CompareHashAndPassword"]; !ok {
+		t.Error("no bcrypt span recorded for login")
+	}
+
+	if job.Parent().IsValid() {
+		t.Error("worker span should start a new trace")
+	}
+	links := job.Links()
+	if len(links) != 1 || links[0].SpanContext.TraceID() != request.SpanContext().TraceID() {
+		t.Errorf("worker span not linked to the creating request: %+v", links)
+	}
+}
+
+func keys(m map[string]sdktrace.ReadOnlySpan) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
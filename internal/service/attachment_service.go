@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/blob"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// AttachmentLimits bound the files that may be attached to tasks.
+type AttachmentLimits struct {
+	// MaxSize is the largest file accepted, in bytes.
+	MaxSize int64
+	// AllowedTypes lists the media types accepted; "image/*" accepts
+	// every image type.
+	AllowedTypes []string
+	// UserQuota caps the total size of the files on one user's tasks. 0
+	// means no quota.
+	UserQuota int64
+}
+
+// allows reports whether files of mediaType are accepted.
+func (l AttachmentLimits) allows(mediaType string) bool {
+	for _, t := range l.AllowedTypes {
+		if t == mediaType {
+			return true
+		}
+		if family, ok := strings.CutSuffix(t, "/*"); ok && strings.HasPrefix(mediaType, family+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachmentService manages the files attached to tasks. The metadata is
+// kept in the repository and the content in a blob.Store. Attachments are
+// visible to whoever can see the task, and count against the quota of its
+// owner.
+type AttachmentService struct {
+	tasks       repository.TaskRepository
+	attachments repository.AttachmentRepository
+	store       blob.Store
+	limits      AttachmentLimits
+	timeouts    Timeouts
+}
+
+func NewAttachmentService(t repository.TaskRepository, a repository.AttachmentRepository, store blob.Store, limits AttachmentLimits, timeouts Timeouts) *AttachmentService {
+	return &AttachmentService{tasks: t, attachments: a, store: store, limits: limits, timeouts: timeouts}
+}
+
+// maxAttachmentNameLen matches the task_attachments.name column
+// (VARCHAR(255) on MySQL).
+const maxAttachmentNameLen = 255
+
+// cleanName reduces a client's file name to its last path element, without
+// control characters, so it is safe to hand back in a Content-Disposition
+// header. It returns "" when nothing usable is left.
+func cleanName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	if runes := []rune(name); len(runes) > maxAttachmentNameLen {
+		name = string(runes[:maxAttachmentNameLen])
+	}
+	return name
+}
+
+// blobKey is where the content of attachment is stored.
+func blobKey(attachment *models.Attachment) string {
+	return attachment.TaskID + "/" + attachment.ID
+}
+
+// attachment loads an attachment and checks it belongs to taskID, so an
+// attachment cannot be reached through another task's URL.
+func (s *AttachmentService) attachment(ctx context.Context, taskID, attachmentID string) (*models.Attachment, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	attachment, err := s.attachments.GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.TaskID != taskID {
+		return nil, fmt.Errorf("attachment %w", apperr.ErrNotFound)
+	}
+	return attachment, nil
+}
+
+// ListAttachments returns one page of a task's attachments, oldest first,
+// and whether more follow it.
+func (s *AttachmentService) ListAttachments(ctx context.Context, taskID, userID, role string, page Page) (attachments []models.Attachment, more bool, err error) {
+	ctx, span := startSpan(ctx, "AttachmentService.ListAttachments")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, taskID, userID, role); err != nil {
+		return nil, false, err
+	}
+
+	q := repository.AttachmentQuery{TaskID: taskID, Offset: page.Offset}
+	// Fetch one extra row to learn whether another page exists.
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
+	}
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	attachments, err = s.attachments.List(readCtx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	if page.Limit > 0 && len(attachments) > page.Limit {
+		return attachments[:page.Limit], true, nil
+	}
+	return attachments, false, nil
+}
+
+// readTracker remembers whether reading failed, to tell a broken upload
+// from a failure to spool it.
+type readTracker struct {
+	r      io.Reader
+	failed bool
+}
+
+func (t *readTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF {
+		t.failed = true
+	}
+	return n, err
+}
+
+// UploadAttachment stores the content read from r as attachment, a new
+// attachment of attachment.TaskID by attachment.UploaderID, and fills in
+// its owner, size, type and checksum. The type is detected from the
+// content; the client's word for it is not trusted.
+//
+// The content is spooled to a temporary file first, so nothing is stored
+// unless it passes the size, type and quota checks. Uploads running at the
+// same time are checked against the same usage, so together they may
+// overshoot the quota by one file each.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, attachment *models.Attachment, role string, r io.Reader) (err error) {
+	ctx, span := startSpan(ctx, "AttachmentService.UploadAttachment")
+	defer func() { endSpan(span, err) }()
+
+	if attachment.Name = cleanName(attachment.Name); attachment.Name == "" {
+		return apperr.Validation(apperr.FieldError{Field: "file", Code: "required", Message: "the file must have a name"})
+	}
+	task, err := visibleTask(ctx, s.tasks, s.timeouts, attachment.TaskID, attachment.UploaderID, role)
+	if err != nil {
+		return err
+	}
+
+	spool, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	hash := sha256.New()
+	src := &readTracker{r: io.LimitReader(r, s.limits.MaxSize+1)}
+	size, err := io.Copy(io.MultiWriter(spool, hash), src)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return ctx.Err()
+	case err != nil && src.failed:
+		return apperr.Validation(apperr.FieldError{Field: "file", Code: "incomplete", Message: "the upload was cut short or malformed"})
+	case err != nil:
+		return err
+	case size > s.limits.MaxSize:
+		return fmt.Errorf("files may be at most %d bytes: %w", s.limits.MaxSize, apperr.ErrTooLarge)
+	case size == 0:
+		return apperr.Validation(apperr.FieldError{Field: "file", Code: "required", Message: "the file is empty"})
+	}
+
+	head := make([]byte, 512)
+	n, err := spool.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	contentType := http.DetectContentType(head[:n])
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	if !s.limits.allows(mediaType) {
+		return fmt.Errorf("files of type %s are not accepted: %w", mediaType, apperr.ErrUnsupportedMediaType)
+	}
+
+	if s.limits.UserQuota > 0 {
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		usage, err := s.attachments.Usage(readCtx, task.UserID)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if usage+size > s.limits.UserQuota {
+			return fmt.Errorf("the task owner's attachments use %d of their %d bytes, and this file needs %d: %w",
+				usage, s.limits.UserQuota, size, apperr.ErrQuotaExceeded)
+		}
+	}
+
+	attachment.UserID = task.UserID
+	attachment.Size = size
+	attachment.ContentType = contentType
+	attachment.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// The blob is bounded by the request rather than the database
+	// timeouts; large files take a while.
+	key := blobKey(attachment)
+	if err = s.store.Put(ctx, key, spool, size, contentType); err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err = s.attachments.Create(writeCtx, attachment); err != nil {
+		// Without its row the blob can never be reached.
+		s.deleteBlob(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// OpenAttachment returns an attachment of a task the caller can see,
+// together with its content, which the caller closes.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, taskID, attachmentID, userID, role string) (attachment *models.Attachment, content io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "AttachmentService.OpenAttachment")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, taskID, userID, role); err != nil {
+		return nil, nil, err
+	}
+	if attachment, err = s.attachment(ctx, taskID, attachmentID); err != nil {
+		return nil, nil, err
+	}
+	if content, err = s.store.Get(ctx, blobKey(attachment)); err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+// DeleteAttachment removes an attachment and its content. Anyone who can
+// see the task may, as with comments.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, taskID, attachmentID, userID, role string) (err error) {
+	ctx, span := startSpan(ctx, "AttachmentService.DeleteAttachment")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, taskID, userID, role); err != nil {
+		return err
+	}
+	attachment, err := s.attachment(ctx, taskID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	// The row goes first: it is what the quota counts and downloads look
+	// up, so a blob left behind is only wasted space.
+	if err = s.attachments.Delete(writeCtx, attachmentID); err != nil {
+		return err
+	}
+	s.deleteBlob(ctx, blobKey(attachment))
+	return nil
+}
+
+// TaskPurged deletes the attachments of a task that is about to be purged
+// for good. Register it with TaskService.OnPurge. Attachments stay while
+// their task is in the trash, so it can be restored with them.
+func (s *AttachmentService) TaskPurged(ctx context.Context, taskID string) error {
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	attachments, err := s.attachments.List(readCtx, repository.AttachmentQuery{TaskID: taskID})
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.attachments.DeleteByTask(writeCtx, taskID); err != nil {
+		return err
+	}
+	for i := range attachments {
+		s.deleteBlob(ctx, blobKey(&attachments[i]))
+	}
+	return nil
+}
+
+// deleteBlob removes content whose row is already gone. Nothing can reach
+// it any more, so a failure only leaves wasted space and is logged.
+func (s *AttachmentService) deleteBlob(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		slog.WarnContext(ctx, "deleting attachment content failed", "key", key, "error", err)
+	}
+}
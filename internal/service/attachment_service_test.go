@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/blob"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+func TestCleanName(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"receipt.pdf", "receipt.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\alice\scan.png`, "scan.png"},
+		{"  notes\r\n.txt ", "notes.txt"},
+		{"dir/", "dir"},
+		{"..", ""},
+		{"/", ""},
+		{"", ""},
+		{strings.Repeat("é", 300), strings.Repeat("é", maxAttachmentNameLen)},
+	} {
+		if got := cleanName(tc.in); got != tc.want {
+			t.Errorf("cleanName(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestAttachmentsPurgedWithTask(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	attachments := repository.NewMemoryAttachmentRepository()
+	store, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewAttachmentService(tasks, attachments, store, AttachmentLimits{MaxSize: 1 << 10, AllowedTypes: []string{"text/plain"}}, Timeouts{})
+	taskService := NewTaskService(tasks, repository.NewMemoryCommentRepository(), repository.NewMemoryTaskEventRepository(),
+		repository.NewMemoryDependencyRepository(tasks), nil, nil, Timeouts{}, SubtaskPolicy{})
+	taskService.OnPurge(s.TaskPurged)
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var uploaded []*models.Attachment
+	for _, id := range []string{"old", "live"} {
+		if err := tasks.Create(ctx, &models.Task{ID: id, Title: id, Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+		a := &models.Attachment{ID: id + "-file", TaskID: id, UploaderID: "alice", Name: "notes.txt", CreatedAt: now}
+		if err := s.UploadAttachment(ctx, a, "user", strings.NewReader("some notes")); err != nil {
+			t.Fatalf("upload to %s: %v", id, err)
+		}
+		uploaded = append(uploaded, a)
+	}
+	tasks.Delete(ctx, &models.Task{ID: "old", Version: 1}, now.AddDate(0, 0, -31))
+
+	if purged, err := taskService.PurgeTrash(ctx, now.AddDate(0, 0, -30)); err != nil || purged != 1 {
+		t.Fatalf("PurgeTrash = %d, %v, want 1", purged, err)
+	}
+	if _, err := attachments.GetByID(ctx, "old-file"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("attachment of a purged task: err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Get(ctx, blobKey(uploaded[0])); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("content of a purged task's attachment: err = %v, want ErrNotFound", err)
+	}
+	if usage, _ := attachments.Usage(ctx, "alice"); usage != int64(len("some notes")) {
+		t.Errorf("usage after purge = %d, want only the live task's file", usage)
+	}
+	content, err := store.Get(ctx, blobKey(uploaded[1]))
+	if err != nil {
+		t.Fatalf("content of a live task's attachment: %v", err)
+	}
+	content.Close()
+}
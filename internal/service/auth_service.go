@@ -1,42 +1,86 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/metrics"
 	"github.com/CashInvoice-Golang-Assignment/internal/models"
 	"github.com/CashInvoice-Golang-Assignment/internal/repository"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// errInvalidCredentials is returned for an unknown email and a wrong
+// password alike, so callers cannot probe which accounts exist.
+var errInvalidCredentials = fmt.Errorf("invalid credentials: %w", apperr.ErrUnauthorized)
+
 type AuthService struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	timeouts Timeouts
+	metrics  *metrics.Metrics
 }
 
-func NewAuthService(r repository.UserRepository) *AuthService {
-	return &AuthService{repo: r}
+func NewAuthService(r repository.UserRepository, t Timeouts, m *metrics.Metrics) *AuthService {
+	return &AuthService{repo: r, timeouts: t, metrics: m}
 }
 
-func (s *AuthService) Login(email, password string) (*models.User, error) {
-	user, err := s.repo.GetByEmail(email)
+func (s *AuthService) Login(ctx context.Context, email, password string) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "AuthService.Login")
+	defer func() { endSpan(span, err) }()
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	user, err = s.repo.GetByEmail(readCtx, email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		// Cancellation and storage failures are not a credentials problem;
+		// let the caller see them.
+		if ctxErr := readCtx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if !errors.Is(err, apperr.ErrNotFound) {
+			return nil, err
+		}
+		s.metrics.LoginAttempt(false)
+		return nil, errInvalidCredentials
 	}
 
-	err = bcrypt.CompareHashAndPassword(
-		[]byte(user.Password),
-		[]byte(password),
-	)
+	err = comparePassword(ctx, user.Password, password)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		s.metrics.LoginAttempt(false)
+		return nil, errInvalidCredentials
 	}
 
+	s.metrics.LoginAttempt(true)
 	return user, nil
 }
 
-func (s *AuthService) Register(email, password string) error {
+// Refresh returns the user a refresh token was issued to, as stored now,
+// so the new tokens carry their current role. A user that no longer
+// exists is ErrUnauthorized.
+func (s *AuthService) Refresh(ctx context.Context, userID string) (user *models.User, err error) {
+	ctx, span := startSpan(ctx, "AuthService.Refresh")
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	user, err = s.repo.GetByID(ctx, userID)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, fmt.Errorf("user no longer exists: %w", apperr.ErrUnauthorized)
+	}
+	return user, err
+}
+
+func (s *AuthService) Register(ctx context.Context, email, password string) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.Register")
+	defer func() { endSpan(span, err) }()
+
 	// Hash password
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashed, err := hashPassword(ctx, password)
 	if err != nil {
 		return err
 	}
@@ -44,14 +88,20 @@ func (s *AuthService) Register(email, password string) error {
 	user := &models.User{
 		ID:       uuid.NewString(),
 		Email:    email,
-		Password: string(hashed),
+		Password: hashed,
 		Role:     "user", // default role
 	}
 
-	return s.repo.Create(user)
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return s.repo.Create(ctx, user)
 }
-func (s *AuthService) RegisterAdmin(email, password string) error {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (s *AuthService) RegisterAdmin(ctx context.Context, email, password string) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.RegisterAdmin")
+	defer func() { endSpan(span, err) }()
+
+	hashed, err := hashPassword(ctx, password)
 	if err != nil {
 		return err
 	}
@@ -59,9 +109,55 @@ func (s *AuthService) RegisterAdmin(email, password string) error {
 	user := &models.User{
 		ID:       uuid.NewString(),
 		Email:    email,
-		Password: string(hashed),
+		Password: hashed,
 		Role:     "admin",
 	}
 
-	return s.repo.Create(user)
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return s.repo.Create(ctx, user)
+}
+
+func (s *AuthService) ListUsers(ctx context.Context) (users []models.User, err error) {
+	ctx, span := startSpan(ctx, "AuthService.ListUsers")
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	return s.repo.List(ctx)
+}
+
+// DeleteUser removes a user account. Admins cannot delete themselves, so
+// there is always someone left to manage the rest.
+func (s *AuthService) DeleteUser(ctx context.Context, id, callerID string) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.DeleteUser")
+	defer func() { endSpan(span, err) }()
+
+	if id == callerID {
+		return fmt.Errorf("cannot delete your own account: %w", apperr.ErrConflict)
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return s.repo.Delete(ctx, id)
+}
+
+// bcrypt is deliberately slow, so it gets its own spans to tell it apart
+// from database time.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := startSpan(ctx, "bcrypt.GenerateFromPassword")
+	defer span.End()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
+}
+
+func comparePassword(ctx context.Context, hash, password string) error {
+	_, span := startSpan(ctx, "bcrypt.CompareHashAndPassword")
+	defer span.End()
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
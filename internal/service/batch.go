@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// MaxBatchSize caps the operations in one Batch call.
+const MaxBatchSize = 100
+
+// The kinds of BatchOp.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	// OpStatus is an update of the status alone.
+	OpStatus = "status"
+)
+
+// BatchOp is one operation of a batch.
+type BatchOp struct {
+	// Op is one of the Op constants.
+	Op string
+	// Task is the task a create operation creates.
+	Task *models.Task
+	// TaskID is the task an update, delete or status operation applies
+	// to.
+	TaskID string
+	// Update is the change an update operation makes. A status operation
+	// uses only its Status. Scope and Recurrence cannot be used in a
+	// batch.
+	Update TaskUpdate
+	// IfMatch is checked as for TaskUpdate.IfMatch.
+	IfMatch []string
+}
+
+// BatchResult is the outcome of one operation of a batch: the created or
+// updated task, nothing for a delete, or the error it failed with.
+type BatchResult struct {
+	Task *models.Task
+	Err  error
+}
+
+// errBatchConflict is an operation of an atomic batch on a task another
+// operation of it already writes.
+var errBatchConflict = fmt.Errorf("task is already changed by another operation of the batch: %w", apperr.ErrConflict)
+
+// Batch runs up to MaxBatchSize operations for the caller and returns
+// their results in order. Each is checked as the single call it stands
+// for would be, ownership included.
+//
+// In atomic mode every operation is checked first, against the tasks as
+// they were before the batch, and all are then saved in one transaction.
+// If one fails nothing is saved, and the others fail with
+// apperr.ErrFailedDependency; a failing transaction fails the whole call.
+// That includes a task changed or deleted by another request since it was
+// checked, which fails it with apperr.ErrConflict. A task may only be
+// written by one operation of an atomic batch, and a task above one the
+// batch creates or moves, before or after it, may not be moved by another.
+//
+// Otherwise the operations run one after another, and each failure only
+// affects its own result.
+func (s *TaskService) Batch(ctx context.Context, userID, role string, ops []BatchOp, atomic bool) (results []BatchResult, err error) {
+	ctx, span := startSpan(ctx, "TaskService.Batch")
+	defer func() { endSpan(span, err) }()
+
+	if userID == "" {
+		return nil, apperr.ErrUnauthorized
+	}
+	if err = checkBatch(ops); err != nil {
+		return nil, err
+	}
+
+	if atomic {
+		return s.batchAtomic(asUser(ctx, userID, role), userID, role, ops)
+	}
+	results = make([]BatchResult, len(ops))
+	for i, op := range ops {
+		results[i].Task, results[i].Err = s.batchOne(ctx, userID, role, op)
+	}
+	return results, nil
+}
+
+// checkBatch checks the shape of a batch before any of it runs.
+func checkBatch(ops []BatchOp) error {
+	if len(ops) == 0 {
+		return apperr.Validation(apperr.FieldError{Field: "operations", Code: "required", Message: "at least one operation is required"})
+	}
+	if len(ops) > MaxBatchSize {
+		return apperr.Validation(apperr.FieldError{
+			Field:   "operations",
+			Code:    "max",
+			Message: fmt.Sprintf("a batch may have at most %d operations", MaxBatchSize),
+		})
+	}
+
+	var fields []apperr.FieldError
+	for i, op := range ops {
+		field := fmt.Sprintf("operations[%d]", i)
+		switch {
+		case !slices.Contains([]string{OpCreate, OpUpdate, OpDelete, OpStatus}, op.Op):
+			fields = append(fields, apperr.FieldError{Field: field + ".op", Code: "oneof", Message: "must be one of: create update delete status"})
+		case op.Op == OpCreate && op.Task == nil:
+			fields = append(fields, apperr.FieldError{Field: field, Code: "required", Message: "a create operation needs a task"})
+		case op.Op != OpCreate && op.TaskID == "":
+			fields = append(fields, apperr.FieldError{Field: field + ".id", Code: "required", Message: "id is required"})
+		case op.Op == OpStatus && op.Update.Status == nil:
+			fields = append(fields, apperr.FieldError{Field: field + ".status", Code: "required", Message: "status is required"})
+		case op.Update.Scope != "" && op.Update.Scope != ScopeThis || op.Update.Recurrence != nil:
+			fields = append(fields, apperr.FieldError{Field: field, Code: "unsupported", Message: "recurring series cannot be changed in a batch"})
+		}
+	}
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+// update returns the TaskUpdate an update or status operation makes.
+func (op BatchOp) update() TaskUpdate {
+	upd := op.Update
+	if op.Op == OpStatus {
+		upd = TaskUpdate{Status: op.Update.Status}
+	}
+	upd.IfMatch = op.IfMatch
+	return upd
+}
+
+// batchOne runs one operation of a best-effort batch through the single
+// call it stands for.
+func (s *TaskService) batchOne(ctx context.Context, userID, role string, op BatchOp) (*models.Task, error) {
+	switch op.Op {
+	case OpCreate:
+		task := *op.Task
+		task.UserID = userID
+		if err := s.CreateTask(ctx, &task, role); err != nil {
+			return nil, err
+		}
+		return &task, nil
+	case OpDelete:
+		return nil, s.DeleteTask(ctx, op.TaskID, userID, role, op.IfMatch)
+	default:
+		return s.UpdateTask(ctx, op.TaskID, userID, role, op.update())
+	}
+}
+
+// plannedOp is an operation of an atomic batch that was checked and added
+// to the batch's writes, but not saved yet.
+type plannedOp struct {
+	task *models.Task
+	// writes are the IDs of the existing tasks it changes.
+	writes []string
+	// parentID is the task it places a task under, if any.
+	parentID string
+	// moves is the existing task it moves to another parent, if any.
+	moves string
+	// completes is the existing task it completes, if any.
+	completes string
+	// openUnder is the parent it places an open task under, or reopens a
+	// task under, if any.
+	openUnder string
+	// above are the tasks above the task it places, before and after the
+	// batch. Its cycle and depth checks only hold while they stay put.
+	above []string
+	// saved follows up on the operation once its writes are saved.
+	saved func(ctx context.Context)
+}
+
+// batchAtomic checks every operation, then saves them all in one
+// transaction.
+func (s *TaskService) batchAtomic(ctx context.Context, userID, role string, ops []BatchOp) ([]BatchResult, error) {
+	// Truncated to what the repositories store, as in DeleteTask.
+	b := repository.TaskBatch{DeletedAt: time.Now().UTC().Truncate(time.Second)}
+	results := make([]BatchResult, len(ops))
+	planned := make([]plannedOp, len(ops))
+	written := map[string]bool{}
+	trashed := map[string]bool{}
+	failed := false
+	for i, op := range ops {
+		p, err := s.planOp(ctx, userID, role, op, &b)
+		if err == nil {
+			for _, id := range p.writes {
+				if written[id] {
+					err = errBatchConflict
+				}
+				written[id] = true
+			}
+		}
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+		planned[i] = p
+		results[i].Task = p.task
+	}
+	for _, task := range b.Delete {
+		trashed[task.ID] = true
+	}
+	moved, completed := map[string]bool{}, map[string]bool{}
+	for _, p := range planned {
+		if p.moves != "" {
+			moved[p.moves] = true
+		}
+		if p.completes != "" {
+			completed[p.completes] = true
+		}
+	}
+	for i, p := range planned {
+		if results[i].Err != nil {
+			continue
+		}
+		switch {
+		case trashed[p.parentID]:
+			results[i].Err = fmt.Errorf("parent task is deleted by another operation of the batch: %w", apperr.ErrConflict)
+		case slices.ContainsFunc(p.above, func(id string) bool { return moved[id] }):
+			results[i].Err = fmt.Errorf("a task above it is moved by another operation of the batch: %w", apperr.ErrConflict)
+		case completed[p.openUnder] && !s.policy.CompleteWithOpenSubtasks:
+			results[i].Err = fmt.Errorf("parent task is completed by another operation of the batch: %w", apperr.ErrConflict)
+		default:
+			continue
+		}
+		failed = true
+	}
+	if failed {
+		for i := range results {
+			results[i].Task = nil
+			if results[i].Err == nil {
+				results[i].Err = fmt.Errorf("not applied, another operation failed: %w", apperr.ErrFailedDependency)
+			}
+		}
+		return results, nil
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	err := s.repo.Apply(writeCtx, b)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range planned {
+		p.saved(ctx)
+	}
+	return results, nil
+}
+
+// planOp checks op and adds its writes to b.
+func (s *TaskService) planOp(ctx context.Context, userID, role string, op BatchOp, b *repository.TaskBatch) (plannedOp, error) {
+	switch op.Op {
+	case OpCreate:
+		task := *op.Task
+		task.UserID = userID
+		if err := s.checkCreate(ctx, &task, role); err != nil {
+			return plannedOp{}, err
+		}
+		b.Create = append(b.Create, &task)
+		p := plannedOp{task: &task, saved: func(ctx context.Context) { s.created(ctx, &task) }}
+		if task.ParentID != nil {
+			p.parentID = *task.ParentID
+			above, err := s.ancestors(ctx, &task)
+			if err != nil {
+				return plannedOp{}, err
+			}
+			p.above = above
+			if task.Status != models.StatusCompleted {
+				p.openUnder = p.parentID
+			}
+		}
+		return p, nil
+
+	case OpDelete:
+		task, err := s.checkDelete(ctx, op.TaskID, userID, role, op.IfMatch)
+		if err != nil {
+			return plannedOp{}, err
+		}
+		return s.planDelete(ctx, task, b)
+
+	default:
+		upd := op.update()
+		task, before, err := s.checkUpdate(ctx, op.TaskID, userID, role, upd)
+		if err != nil {
+			return plannedOp{}, err
+		}
+		b.Update = append(b.Update, task)
+		p := plannedOp{task: task, writes: []string{task.ID}}
+		if upd.ParentID != nil {
+			p.parentID = *upd.ParentID
+		}
+		if optional(before.ParentID) != optional(task.ParentID) {
+			p.moves = task.ID
+			if p.above, err = s.ancestors(ctx, &before); err != nil {
+				return plannedOp{}, err
+			}
+			above, err := s.ancestors(ctx, task)
+			if err != nil {
+				return plannedOp{}, err
+			}
+			p.above = append(p.above, above...)
+		}
+		switch {
+		case task.Status == models.StatusCompleted && before.Status != models.StatusCompleted:
+			p.completes = task.ID
+		case task.Status != models.StatusCompleted && task.ParentID != nil && (p.moves != "" || before.Status == models.StatusCompleted):
+			p.openUnder = *task.ParentID
+		}
+		p.saved = func(ctx context.Context) {
+			s.updated(ctx, &before, task)
+			if task.Status == models.StatusCompleted && before.Status != models.StatusCompleted {
+				s.occurrenceCompleted(ctx, task)
+			}
+		}
+		return p, nil
+	}
+}
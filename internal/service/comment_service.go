@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// CommentService manages the comments on tasks. Comments are visible to
+// whoever can see the task: its owner and admins.
+type CommentService struct {
+	tasks    repository.TaskRepository
+	comments repository.CommentRepository
+	timeouts Timeouts
+}
+
+func NewCommentService(t repository.TaskRepository, c repository.CommentRepository, timeouts Timeouts) *CommentService {
+	return &CommentService{tasks: t, comments: c, timeouts: timeouts}
+}
+
+// maxCommentLen keeps a single comment to a sensible size.
+const maxCommentLen = 10000
+
+func validateComment(body string) error {
+	switch body = strings.TrimSpace(body); {
+	case body == "":
+		return apperr.Validation(apperr.FieldError{Field: "body", Code: "required", Message: "body is required"})
+	case utf8.RuneCountInString(body) > maxCommentLen:
+		return apperr.Validation(apperr.FieldError{Field: "body", Code: "max", Message: "body must be at most 10000 characters"})
+	}
+	return nil
+}
+
+// visibleTask loads the task and checks the caller may see it, with the
+// same rule as TaskService.GetTaskByID. The services that keep data on
+// tasks share it.
+func visibleTask(ctx context.Context, tasks repository.TaskRepository, timeouts Timeouts, taskID, userID, role string) (*models.Task, error) {
+	ctx, cancel := withTimeout(ctx, timeouts.Read)
+	defer cancel()
+
+	task, err := tasks.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && task.UserID != userID {
+		return nil, apperr.ErrForbidden
+	}
+	return task, nil
+}
+
+// comment loads a comment and checks it belongs to taskID, so a comment
+// cannot be reached through another task's URL.
+func (s *CommentService) comment(ctx context.Context, taskID, commentID string) (*models.Comment, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	comment, err := s.comments.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.TaskID != taskID {
+		return nil, fmt.Errorf("comment %w", apperr.ErrNotFound)
+	}
+	return comment, nil
+}
+
+// ListComments returns one page of a task's comments, oldest first, and
+// whether more follow it.
+func (s *CommentService) ListComments(ctx context.Context, taskID, userID, role string, page Page) (comments []models.Comment, more bool, err error) {
+	ctx, span := startSpan(ctx, "CommentService.ListComments")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, taskID, userID, role); err != nil {
+		return nil, false, err
+	}
+
+	q := repository.CommentQuery{TaskID: taskID, Offset: page.Offset}
+	// Fetch one extra row to learn whether another page exists.
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
+	}
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	comments, err = s.comments.List(readCtx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	if page.Limit > 0 && len(comments) > page.Limit {
+		return comments[:page.Limit], true, nil
+	}
+	return comments, false, nil
+}
+
+// AddComment posts comment on its task as comment.AuthorID.
+func (s *CommentService) AddComment(ctx context.Context, comment *models.Comment, role string) (err error) {
+	ctx, span := startSpan(ctx, "CommentService.AddComment")
+	defer func() { endSpan(span, err) }()
+
+	if err = validateComment(comment.Body); err != nil {
+		return err
+	}
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, comment.TaskID, comment.AuthorID, role); err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return s.comments.Create(writeCtx, comment)
+}
+
+// EditComment replaces a comment's body and marks it edited. Only the
+// author may edit a comment.
+func (s *CommentService) EditComment(ctx context.Context, taskID, commentID, userID, role, body string) (comment *models.Comment, err error) {
+	ctx, span := startSpan(ctx, "CommentService.EditComment")
+	defer func() { endSpan(span, err) }()
+
+	if err = validateComment(body); err != nil {
+		return nil, err
+	}
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, taskID, userID, role); err != nil {
+		return nil, err
+	}
+	comment, err = s.comment(ctx, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != userID {
+		return nil, apperr.ErrForbidden
+	}
+
+	comment.Body = body
+	comment.Edited = true
+	comment.UpdatedAt = time.Now()
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err = s.comments.Update(writeCtx, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment removes a comment. Anyone who can see the task may delete
+// its comments, so owners can moderate their own tasks.
+func (s *CommentService) DeleteComment(ctx context.Context, taskID, commentID, userID, role string) (err error) {
+	ctx, span := startSpan(ctx, "CommentService.DeleteComment")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = visibleTask(ctx, s.tasks, s.timeouts, taskID, userID, role); err != nil {
+		return err
+	}
+	if _, err = s.comment(ctx, taskID, commentID); err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return s.comments.Delete(writeCtx, commentID)
+}
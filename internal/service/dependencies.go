@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+var (
+	errBlockerNotFound = apperr.FieldError{Field: "blocker_id", Code: "exists", Message: "blocking task does not exist"}
+	errBlockerCycle    = apperr.FieldError{Field: "blocker_id", Code: "cycle", Message: "a task cannot be blocked by itself or by a task it blocks"}
+)
+
+// TaskDependencies lists the tasks that block a task and the tasks it
+// blocks.
+type TaskDependencies struct {
+	BlockedBy []models.Task
+	Blocks    []models.Task
+}
+
+// tasksByID loads the given tasks, newest first. Missing IDs are skipped.
+func (s *TaskService) tasksByID(ctx context.Context, ids []string) ([]models.Task, error) {
+	if len(ids) == 0 {
+		return []models.Task{}, nil
+	}
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.List(ctx, repository.TaskQuery{IDs: ids})
+}
+
+// openBlockers returns the IDs of the tasks blocking taskID that are not
+// completed.
+func (s *TaskService) openBlockers(ctx context.Context, taskID string) ([]string, error) {
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	deps, err := s.deps.BlockersOf(readCtx, taskID)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(deps))
+	for i, dep := range deps {
+		ids[i] = dep.BlockerID
+	}
+
+	blockers, err := s.tasksByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var open []string
+	for _, b := range blockers {
+		if b.Status != models.StatusCompleted {
+			open = append(open, b.ID)
+		}
+	}
+	return open, nil
+}
+
+// checkUnblocked refuses to start or complete a task while any of its
+// blockers is open.
+func (s *TaskService) checkUnblocked(ctx context.Context, taskID string) error {
+	open, err := s.openBlockers(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("task is blocked by %d open task(s): %w", len(open), apperr.ErrConflict)
+	}
+	return nil
+}
+
+// blocks reports whether taskID is blocked, directly or through other
+// tasks, by targetID.
+func (s *TaskService) blocks(ctx context.Context, targetID, taskID string) (bool, error) {
+	seen := map[string]bool{taskID: true}
+	frontier := []string{taskID}
+	for len(frontier) > 0 {
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		deps, err := s.deps.BlockersOf(readCtx, frontier...)
+		cancel()
+		if err != nil {
+			return false, err
+		}
+
+		frontier = frontier[:0]
+		for _, dep := range deps {
+			if dep.BlockerID == targetID {
+				return true, nil
+			}
+			if !seen[dep.BlockerID] {
+				seen[dep.BlockerID] = true
+				frontier = append(frontier, dep.BlockerID)
+			}
+		}
+	}
+	return false, nil
+}
+
+// GetDependencies returns the tasks blocking a task the caller may see
+// and the tasks it blocks.
+func (s *TaskService) GetDependencies(ctx context.Context, taskID, userID, role string) (deps *TaskDependencies, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetDependencies")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = s.GetTaskByID(ctx, taskID, userID, role); err != nil {
+		return nil, err
+	}
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	blockers, err := s.deps.BlockersOf(readCtx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	dependents, err := s.deps.DependentsOf(readCtx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	deps = &TaskDependencies{}
+	ids := make([]string, len(blockers))
+	for i, dep := range blockers {
+		ids[i] = dep.BlockerID
+	}
+	if deps.BlockedBy, err = s.tasksByID(ctx, ids); err != nil {
+		return nil, err
+	}
+	ids = make([]string, len(dependents))
+	for i, dep := range dependents {
+		ids[i] = dep.TaskID
+	}
+	if deps.Blocks, err = s.tasksByID(ctx, ids); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// AddDependency marks a task the caller may modify as blocked by
+// blockerID. The blocker must have the same owner, and the new edge must
+// not close a loop of tasks blocking each other.
+func (s *TaskService) AddDependency(ctx context.Context, taskID, blockerID, userID, role string) (dep *models.Dependency, err error) {
+	ctx, span := startSpan(ctx, "TaskService.AddDependency")
+	defer func() { endSpan(span, err) }()
+
+	task, err := s.GetTaskByID(ctx, taskID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if blockerID == task.ID {
+		return nil, apperr.Validation(errBlockerCycle)
+	}
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	blocker, err := s.repo.GetByID(readCtx, blockerID)
+	cancel()
+	if errors.Is(err, apperr.ErrNotFound) || err == nil && blocker.UserID != task.UserID {
+		return nil, apperr.Validation(errBlockerNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cycle, err := s.blocks(ctx, task.ID, blocker.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, apperr.Validation(errBlockerCycle)
+	}
+
+	dep = &models.Dependency{TaskID: task.ID, BlockerID: blocker.ID, CreatedAt: time.Now()}
+
+	writeCtx, cancelWrite := withTimeout(ctx, s.timeouts.Write)
+	defer cancelWrite()
+
+	if err = s.deps.Add(writeCtx, dep); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+// RemoveDependency removes blockerID from the blockers of a task the
+// caller may modify, and schedules the task for auto-complete again as
+// unblocked does.
+func (s *TaskService) RemoveDependency(ctx context.Context, taskID, blockerID, userID, role string) (err error) {
+	ctx, span := startSpan(ctx, "TaskService.RemoveDependency")
+	defer func() { endSpan(span, err) }()
+
+	task, err := s.GetTaskByID(ctx, taskID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err = s.deps.Remove(writeCtx, taskID, blockerID); err != nil {
+		return err
+	}
+	if task.Status != models.StatusCompleted && task.SeriesID == nil {
+		s.schedule(ctx, task)
+	}
+	return nil
+}
+
+// unblocked schedules the tasks blockerID blocks for auto-complete again,
+// now that it is completed or in the trash. The worker drops the job of a
+// task that is still blocked when it comes due, so without this such a
+// task would never be auto-completed. One that is still blocked by
+// another task is dropped again, and rescheduled when that one is done.
+// Like rollUp, it only logs a failure.
+func (s *TaskService) unblocked(ctx context.Context, blockerID string) {
+	err := func() error {
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		deps, err := s.deps.DependentsOf(readCtx, blockerID)
+		cancel()
+		if err != nil || len(deps) == 0 {
+			return err
+		}
+		ids := make([]string, len(deps))
+		for i, dep := range deps {
+			ids[i] = dep.TaskID
+		}
+		dependents, err := s.tasksByID(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for i := range dependents {
+			// Occurrences of a recurring task are never auto-completed.
+			if t := &dependents[i]; t.Status != models.StatusCompleted && t.SeriesID == nil {
+				s.schedule(ctx, t)
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		slog.WarnContext(ctx, "rescheduling blocked tasks failed", "task_id", blockerID, "error", err)
+	}
+}
+
+// deleteDependencies removes the edges to and from a purged task. Like
+// the task's comments, leftover edges are harmless, so a failure is only
+// logged.
+func (s *TaskService) deleteDependencies(ctx context.Context, taskID string) {
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.deps.DeleteByTask(writeCtx, taskID); err != nil {
+		slog.WarnContext(ctx, "deleting task dependencies failed", "task_id", taskID, "error", err)
+	}
+}
+
+// startsWork reports whether moving from one status to another needs the
+// task to be unblocked.
+func startsWork(from, to models.TaskStatus) bool {
+	return from != to && (to == models.StatusInProgress || to == models.StatusCompleted)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// actor is who the changes made under a context are recorded against.
+type actor struct {
+	// name is one of the models.Actor constants.
+	name   string
+	userID string
+}
+
+type actorKey struct{}
+
+// asUser returns ctx with the caller as the actor of the changes made
+// under it.
+func asUser(ctx context.Context, userID, role string) context.Context {
+	name := models.ActorUser
+	if role == "admin" {
+		name = models.ActorAdmin
+	}
+	return context.WithValue(ctx, actorKey{}, actor{name: name, userID: userID})
+}
+
+// asSystem returns ctx with a system actor, such as
+// models.ActorAutoComplete, as the actor of the changes made under it.
+func asSystem(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor{name: name})
+}
+
+// diffTasks lists the fields that differ between before and after, the
+// same task either side of a change. A nil before stands for a task that
+// did not exist yet: every field set on after is listed, with a null old
+// value.
+func diffTasks(before, after *models.Task) []models.FieldChange {
+	created := before == nil
+	if created {
+		before = &models.Task{}
+	}
+
+	var changes []models.FieldChange
+	add := func(field string, old, new any) {
+		if created {
+			old = nil
+		}
+		changes = append(changes, models.FieldChange{Field: field, Old: old, New: new})
+	}
+	if before.Title != after.Title {
+		add("title", before.Title, after.Title)
+	}
+	if before.Description != after.Description {
+		add("description", before.Description, after.Description)
+	}
+	if before.Status != after.Status {
+		add("status", before.Status, after.Status)
+	}
+	if oldParent, newParent := optional(before.ParentID), optional(after.ParentID); oldParent != newParent {
+		add("parent_id", oldParent, newParent)
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		add("tags", nonNil(before.Tags), nonNil(after.Tags))
+	}
+	return changes
+}
+
+// optional returns *p, or nil when p is nil.
+func optional(p *string) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// nonNil returns tags, or an empty slice when it is nil, so it encodes
+// as [] rather than null.
+func nonNil(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// recordChange records the change from before to after, which is already
+// saved. A nil before records after as created. A change that left every
+// field as it was is not recorded.
+func (s *TaskService) recordChange(ctx context.Context, before, after *models.Task) {
+	changes := diffTasks(before, after)
+	typ := models.EventCreated
+	if before != nil {
+		if len(changes) == 0 {
+			return
+		}
+		typ = models.EventUpdated
+		if len(changes) == 1 && changes[0].Field == "status" {
+			typ = models.EventStatusChanged
+		}
+	}
+	s.record(ctx, after.ID, typ, changes)
+}
+
+// record adds an event to the history of taskID, made by the actor in
+// ctx. The change it describes is already saved, so a failure is only
+// logged.
+func (s *TaskService) record(ctx context.Context, taskID string, typ models.TaskEventType, changes []models.FieldChange) {
+	a, _ := ctx.Value(actorKey{}).(actor)
+	event := &models.TaskEvent{
+		TaskID:    taskID,
+		Type:      typ,
+		Actor:     a.name,
+		ActorID:   a.userID,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.events.Create(writeCtx, event); err != nil {
+		slog.WarnContext(ctx, "recording task event failed", "task_id", taskID, "type", typ, "error", err)
+	}
+}
+
+// GetHistory returns one page of the history of a task the caller may
+// see, oldest first, and whether more follow it.
+func (s *TaskService) GetHistory(ctx context.Context, taskID, userID, role string, page Page) (events []models.TaskEvent, more bool, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetHistory")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = s.GetTaskByID(ctx, taskID, userID, role); err != nil {
+		return nil, false, err
+	}
+
+	q := repository.TaskEventQuery{TaskID: taskID, Offset: page.Offset}
+	// Fetch one extra row to learn whether another page exists.
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	events, err = s.events.List(ctx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	if page.Limit > 0 && len(events) > page.Limit {
+		return events[:page.Limit], true, nil
+	}
+	return events, false, nil
+}
@@ -1,20 +1,88 @@
 package service
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
 	"github.com/CashInvoice-Golang-Assignment/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(user *models.User, secret string, expiryMinutes int) (string, error) {
-	claims := jwt.MapClaims{
+// Token types, carried in the "typ" claim. Only access tokens authenticate
+// API calls; refresh tokens are only accepted by POST /auth/refresh.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// RefreshClaims is what POST /auth/refresh takes from a refresh token.
+// The user's role and email are not trusted from it: they are loaded
+// again, so a deleted or demoted user cannot keep their old ones.
+type RefreshClaims struct {
+	UserID string
+	// SessionEnd is when the login the token continues ends. No refresh
+	// token issued for it expires later.
+	SessionEnd time.Time
+}
+
+func GenerateToken(user *models.User, secret string, expiry time.Duration) (string, error) {
+	return signToken(tokenClaims(user, TokenTypeAccess, time.Now().Add(expiry)), secret)
+}
+
+// GenerateRefreshToken issues a long-lived token that can only be
+// exchanged for a new token pair. It expires after expiry, or at
+// sessionEnd if that comes first.
+func GenerateRefreshToken(user *models.User, secret string, expiry time.Duration, sessionEnd time.Time) (string, error) {
+	expiresAt := time.Now().Add(expiry)
+	if sessionEnd.Before(expiresAt) {
+		expiresAt = sessionEnd
+	}
+	claims := tokenClaims(user, TokenTypeRefresh, expiresAt)
+	claims["sess_exp"] = sessionEnd.Unix()
+	return signToken(claims, secret)
+}
+
+// ParseRefreshToken verifies a refresh token and returns its claims.
+// Invalid, expired and access tokens are all ErrUnauthorized.
+func ParseRefreshToken(tokenStr, secret string) (*RefreshClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token: %w", apperr.ErrUnauthorized)
+	}
+	if claims["typ"] != TokenTypeRefresh {
+		return nil, fmt.Errorf("not a refresh token: %w", apperr.ErrUnauthorized)
+	}
+
+	rc := &RefreshClaims{}
+	rc.UserID, _ = claims["user_id"].(string)
+	if rc.UserID == "" {
+		return nil, fmt.Errorf("invalid refresh token: %w", apperr.ErrUnauthorized)
+	}
+	// Tokens issued before sessions were capped have no session end and
+	// must not be refreshed forever, so their users log in again.
+	sessionEnd, ok := claims["sess_exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("refresh token has no session end: %w", apperr.ErrUnauthorized)
+	}
+	rc.SessionEnd = time.Unix(int64(sessionEnd), 0)
+	return rc, nil
+}
+
+func tokenClaims(user *models.User, typ string, expiresAt time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
 		"user_id": user.ID,
 		"role":    user.Role,
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Duration(expiryMinutes) * time.Minute).Unix(),
+		"typ":     typ,
+		"exp":     expiresAt.Unix(),
 	}
+}
 
+func signToken(claims jwt.MapClaims, secret string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+)
+
+func TestRefreshTokenSession(t *testing.T) {
+	const secret = "test-secret-at-least-32-characters"
+	user := &models.User{ID: "u1", Role: "user"}
+
+	sessionEnd := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := GenerateRefreshToken(user, secret, 7*24*time.Hour, sessionEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseRefreshToken(token, secret)
+	if err != nil || claims.UserID != "u1" || !claims.SessionEnd.Equal(sessionEnd) {
+		t.Fatalf("ParseRefreshToken = %+v, %v", claims, err)
+	}
+
+	// A token of a session that has ended is expired, however long the
+	// refresh expiry.
+	token, err = GenerateRefreshToken(user, secret, 7*24*time.Hour, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRefreshToken(token, secret); !errors.Is(err, apperr.ErrUnauthorized) {
+		t.Errorf("token past its session end: %v", err)
+	}
+
+	// Tokens from before sessions were capped carry no session end.
+	token, err = signToken(tokenClaims(user, TokenTypeRefresh, time.Now().Add(time.Hour)), secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRefreshToken(token, secret); !errors.Is(err, apperr.ErrUnauthorized) {
+		t.Errorf("token without a session end: %v", err)
+	}
+}
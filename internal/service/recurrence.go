@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/recurrence"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Which occurrences of a recurring task an update applies to.
+const (
+	// ScopeThis changes only the occurrence being updated.
+	ScopeThis = "this"
+	// ScopeFuture also changes the series, so occurrences created from now
+	// on follow the change, and the later occurrences already created.
+	ScopeFuture = "future"
+)
+
+// Recurrence says how a task repeats.
+type Recurrence struct {
+	// RRule is an RFC 5545 rule in the subset package recurrence supports.
+	RRule string
+	// Timezone is an IANA zone name. Empty means UTC for a new series and
+	// the series' own zone when changing one.
+	Timezone string
+	// StartsAt is the earliest an occurrence may fall on, and sets their
+	// time of day. Zero means now when creating a series, and the updated
+	// occurrence's time when changing one.
+	StartsAt time.Time
+}
+
+// maxDueSeries caps the series one GenerateDue call handles; the rest
+// wait for the next call.
+const maxDueSeries = 100
+
+var errNotOccurrence = apperr.FieldError{Field: "scope", Code: "recurring", Message: "scope=future only applies to an occurrence of a recurring task"}
+
+// parseRecurrence checks rec and returns its rule, its start in the
+// series' timezone, and the timezone's name.
+func parseRecurrence(rec Recurrence, defaultStart time.Time) (*recurrence.Rule, time.Time, string, error) {
+	var fields []apperr.FieldError
+	rule, err := recurrence.Parse(rec.RRule)
+	if err != nil {
+		fields = append(fields, apperr.FieldError{Field: "recurrence.rrule", Code: "format", Message: err.Error()})
+	}
+	tz := rec.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	// "Local" would tie the series to the server's own zone.
+	if err != nil || tz == "Local" {
+		fields = append(fields, apperr.FieldError{Field: "recurrence.timezone", Code: "timezone", Message: "must be an IANA time zone such as Europe/Berlin"})
+	}
+	if len(fields) > 0 {
+		return nil, time.Time{}, "", apperr.Validation(fields...)
+	}
+
+	start := rec.StartsAt
+	if start.IsZero() {
+		start = defaultStart
+	}
+	return rule, start.In(loc).Truncate(time.Second), tz, nil
+}
+
+// seriesRule parses the rule of a stored series and returns it with the
+// series' start in its timezone.
+func seriesRule(series *models.Series) (*recurrence.Rule, time.Time, error) {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("series %s: %w", series.ID, err)
+	}
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("series %s: %w", series.ID, err)
+	}
+	return rule, series.StartsAt.In(loc), nil
+}
+
+// CreateRecurringTask creates a series from task and rec, and task as its
+// first occurrence. Recurring tasks are not auto-completed.
+func (s *TaskService) CreateRecurringTask(ctx context.Context, task *models.Task, rec Recurrence, role string) (err error) {
+	ctx, span := startSpan(ctx, "TaskService.CreateRecurringTask")
+	defer func() { endSpan(span, err) }()
+	ctx = asUser(ctx, task.UserID, role)
+
+	if err = validateTask(task); err != nil {
+		return err
+	}
+	if task.ParentID != nil && *task.ParentID != "" {
+		return apperr.Validation(apperr.FieldError{Field: "parent_id", Code: "recurring", Message: "a recurring task cannot be a subtask"})
+	}
+	task.ParentID = nil
+
+	rule, start, tz, err := parseRecurrence(rec, task.CreatedAt)
+	if err != nil {
+		return err
+	}
+	first, ok := rule.After(start, start.Add(-time.Second))
+	if !ok {
+		return apperr.Validation(apperr.FieldError{Field: "recurrence.rrule", Code: "empty", Message: "the rule has no occurrences after starts_at"})
+	}
+
+	series := &models.Series{
+		ID:          uuid.NewString(),
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		Tags:        slices.Clone(task.Tags),
+		RRule:       rule.String(),
+		Timezone:    tz,
+		StartsAt:    start,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.CreatedAt,
+	}
+	if next, ok := rule.After(start, first); ok {
+		series.NextAt = &next
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err = s.series.Create(writeCtx, series); err != nil {
+		return err
+	}
+	first = first.UTC()
+	task.SeriesID = &series.ID
+	task.OccursAt = &first
+	if err = s.repo.Create(writeCtx, task); err != nil {
+		s.discardSeries(ctx, series.ID)
+		return err
+	}
+	s.recordChange(ctx, nil, task)
+	return nil
+}
+
+// discardSeries deletes a series whose first occurrence could not be
+// created, so the generator does not pick up a task nobody was told about.
+// A failure is only logged; the caller already has an error to return.
+func (s *TaskService) discardSeries(ctx context.Context, id string) {
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.series.Delete(writeCtx, id); err != nil {
+		slog.ErrorContext(ctx, "deleting orphaned series failed", "series_id", id, "error", err)
+	}
+}
+
+// createOccurrence creates the task for the occurrence of series at at.
+func (s *TaskService) createOccurrence(ctx context.Context, series *models.Series, at time.Time) error {
+	now := time.Now()
+	at = at.UTC()
+	task := &models.Task{
+		ID:          uuid.NewString(),
+		Title:       series.Title,
+		Description: series.Description,
+		Status:      models.StatusPending,
+		UserID:      series.UserID,
+		SeriesID:    &series.ID,
+		OccursAt:    &at,
+		Tags:        slices.Clone(series.Tags),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.repo.Create(writeCtx, task); err != nil {
+		return err
+	}
+	s.recordChange(asSystem(ctx, models.ActorRecurrence), nil, task)
+	slog.InfoContext(ctx, "occurrence created", "series_id", series.ID, "task_id", task.ID, "occurs_at", at)
+	return nil
+}
+
+// advance creates the task for the series' next occurrence and moves
+// NextAt past it. Occurrences up to catchUp that were missed, for example
+// while the server was down, are skipped so only the latest of them is
+// created. The task is created before NextAt moves, and a series has one
+// task per occurrence, so a call that fails in between is retried without
+// losing or duplicating the occurrence. It reports whether it created the
+// task; false means there was nothing to do or another caller got there
+// first.
+func (s *TaskService) advance(ctx context.Context, series *models.Series, catchUp time.Time) (bool, error) {
+	if series.NextAt == nil {
+		return false, nil
+	}
+	rule, start, err := seriesRule(series)
+	if err != nil {
+		return false, err
+	}
+
+	at, skipped := *series.NextAt, 0
+	var next *time.Time
+	for occ := range rule.Occurrences(start) {
+		if !occ.After(at) {
+			continue
+		}
+		if !occ.After(catchUp) {
+			at, skipped = occ, skipped+1
+			continue
+		}
+		next = &occ
+		break
+	}
+
+	err = s.createOccurrence(ctx, series, at)
+	if err != nil && !errors.Is(err, apperr.ErrConflict) {
+		return false, err
+	}
+	created := err == nil
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	claimed, err := s.series.Advance(writeCtx, series.ID, *series.NextAt, next)
+	cancel()
+	if err != nil {
+		return created, err
+	}
+	if claimed && skipped > 0 {
+		slog.WarnContext(ctx, "skipped missed occurrences", "series_id", series.ID, "skipped", skipped)
+	}
+	return created, nil
+}
+
+// GenerateDue creates the occurrences of recurring tasks that are due at
+// now and returns how many it created. A series that fails is logged and
+// retried on the next call.
+func (s *TaskService) GenerateDue(ctx context.Context, now time.Time) (created int, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GenerateDue")
+	defer func() { endSpan(span, err) }()
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	due, err := s.series.Due(readCtx, now, maxDueSeries)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+		ok, err := s.advance(ctx, &due[i], now)
+		if ok {
+			created++
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "creating occurrence failed", "series_id", due[i].ID, "error", err)
+		}
+	}
+	return created, nil
+}
+
+// occurrenceCompleted creates the next occurrence of task's series early,
+// unless another occurrence is still open. The completion is already
+// saved, so a failure here is only logged.
+func (s *TaskService) occurrenceCompleted(ctx context.Context, task *models.Task) {
+	if task.SeriesID == nil {
+		return
+	}
+	err := func() error {
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		defer cancel()
+
+		series, err := s.series.GetByID(readCtx, *task.SeriesID)
+		if err != nil || series.NextAt == nil {
+			return err
+		}
+		occurrences, err := s.repo.List(readCtx, repository.TaskQuery{SeriesID: series.ID})
+		if err != nil {
+			return err
+		}
+		for _, occ := range occurrences {
+			if occ.Status != models.StatusCompleted {
+				return nil
+			}
+		}
+		_, err = s.advance(ctx, series, time.Time{})
+		return err
+	}()
+	if err != nil {
+		slog.WarnContext(ctx, "creating next occurrence failed", "task_id", task.ID, "error", err)
+	}
+}
+
+// checkScope validates the recurrence-related parts of an update to task.
+func checkScope(task *models.Task, upd TaskUpdate) error {
+	switch upd.Scope {
+	case "", ScopeThis:
+		if upd.Recurrence != nil {
+			return apperr.Validation(apperr.FieldError{Field: "recurrence", Code: "scope", Message: "recurrence can only be changed with scope=future"})
+		}
+	case ScopeFuture:
+		if task.SeriesID == nil {
+			return apperr.Validation(errNotOccurrence)
+		}
+	default:
+		return apperr.Validation(apperr.FieldError{Field: "scope", Code: "oneof", Message: "must be one of: this future"})
+	}
+	return nil
+}
+
+// futureUpdate is what an update with scope=future changes besides the
+// occurrence itself.
+type futureUpdate struct {
+	// series holds the updated series, not saved yet.
+	series *models.Series
+	// updated are the later open occurrences brought in line with the
+	// update, and before holds them as they were.
+	updated []*models.Task
+	before  []models.Task
+	// trashed are the later open occurrences a new rule drops.
+	trashed []*models.Task
+}
+
+// planFuture works out an update with scope=future to task, which already
+// holds the updated fields, and adds the writes to the later open
+// occurrences to b. When the rule changes they go to the trash instead,
+// and are created again from the new rule as they come due. The series is
+// left for saveFuture, once the occurrences are saved.
+func (s *TaskService) planFuture(ctx context.Context, task *models.Task, upd TaskUpdate, b *repository.TaskBatch) (*futureUpdate, error) {
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	series, err := s.series.GetByID(readCtx, *task.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+	if upd.Title != nil {
+		series.Title = task.Title
+	}
+	if upd.Description != nil {
+		series.Description = task.Description
+	}
+	if upd.Tags != nil {
+		series.Tags = slices.Clone(task.Tags)
+	}
+	if upd.Recurrence != nil {
+		rec := *upd.Recurrence
+		if rec.Timezone == "" {
+			rec.Timezone = series.Timezone
+		}
+		rule, start, tz, err := parseRecurrence(rec, *task.OccursAt)
+		if err != nil {
+			return nil, err
+		}
+		series.RRule, series.Timezone, series.StartsAt = rule.String(), tz, start
+		series.NextAt = nil
+		if next, ok := rule.After(start, *task.OccursAt); ok {
+			series.NextAt = &next
+		}
+	}
+	series.UpdatedAt = time.Now()
+
+	occurrences, err := s.repo.List(readCtx, repository.TaskQuery{SeriesID: *task.SeriesID})
+	if err != nil {
+		return nil, err
+	}
+	f := &futureUpdate{series: series}
+	for i := range occurrences {
+		occ := &occurrences[i]
+		if occ.ID == task.ID || occ.Status == models.StatusCompleted || !occ.OccursAt.After(*task.OccursAt) {
+			continue
+		}
+		if upd.Recurrence != nil {
+			f.trashed = append(f.trashed, occ)
+			continue
+		}
+		f.before = append(f.before, *occ)
+		if upd.Title != nil {
+			occ.Title = task.Title
+		}
+		if upd.Description != nil {
+			occ.Description = task.Description
+		}
+		if upd.Tags != nil {
+			occ.Tags = slices.Clone(task.Tags)
+		}
+		occ.UpdatedAt = time.Now()
+		f.updated = append(f.updated, occ)
+	}
+	b.Update = append(b.Update, f.updated...)
+	b.Delete = append(b.Delete, f.trashed...)
+	return f, nil
+}
+
+// saveFuture records the changes to the later occurrences, which are
+// saved by now, then saves the series.
+func (s *TaskService) saveFuture(ctx context.Context, f *futureUpdate) error {
+	for i, occ := range f.updated {
+		s.recordChange(ctx, &f.before[i], occ)
+	}
+	for _, occ := range f.trashed {
+		s.record(ctx, occ.ID, models.EventDeleted, nil)
+		s.unblocked(ctx, occ.ID)
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+	return s.series.Update(writeCtx, f.series)
+}
+
+// visibleSeries loads a series and checks the caller may see it, with
+// the same rule as for tasks.
+func (s *TaskService) visibleSeries(ctx context.Context, seriesID, userID, role string) (*models.Series, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	series, err := s.series.GetByID(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && series.UserID != userID {
+		return nil, apperr.ErrForbidden
+	}
+	return series, nil
+}
+
+func (s *TaskService) GetSeries(ctx context.Context, seriesID, userID, role string) (series *models.Series, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetSeries")
+	defer func() { endSpan(span, err) }()
+
+	return s.visibleSeries(ctx, seriesID, userID, role)
+}
+
+// EndSeries stops a series from creating further occurrences. The
+// occurrences already created are kept.
+func (s *TaskService) EndSeries(ctx context.Context, seriesID, userID, role string) (series *models.Series, err error) {
+	ctx, span := startSpan(ctx, "TaskService.EndSeries")
+	defer func() { endSpan(span, err) }()
+
+	series, err = s.visibleSeries(ctx, seriesID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	series.NextAt = nil
+	series.UpdatedAt = time.Now()
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err = s.series.Update(writeCtx, series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// What DeleteTask does with the subtasks of the task being deleted.
+const (
+	// ParentDeleteCascade deletes every task below it.
+	ParentDeleteCascade = "cascade"
+	// ParentDeletePromote moves its direct subtasks up to its own parent.
+	ParentDeletePromote = "promote"
+	// ParentDeleteRestrict refuses to delete a task that has subtasks.
+	ParentDeleteRestrict = "restrict"
+)
+
+// SubtaskPolicy sets how subtasks and their parents affect each other.
+type SubtaskPolicy struct {
+	// MaxDepth is the number of levels a task tree may have, counting the
+	// top-level task.
+	MaxDepth int
+	// AutoCompleteParent completes a task once all its direct subtasks are
+	// completed, and so on up the tree.
+	AutoCompleteParent bool
+	// CompleteWithOpenSubtasks lets a task be completed, or stay completed,
+	// while some of its direct subtasks are not.
+	CompleteWithOpenSubtasks bool
+	// OnParentDelete is one of the ParentDelete constants.
+	OnParentDelete string
+}
+
+var (
+	errParentNotFound = apperr.FieldError{Field: "parent_id", Code: "exists", Message: "parent task does not exist"}
+	errParentCycle    = apperr.FieldError{Field: "parent_id", Code: "cycle", Message: "a task cannot be moved under itself or one of its subtasks"}
+)
+
+func (s *TaskService) errTooDeep() error {
+	return apperr.Validation(apperr.FieldError{
+		Field:   "parent_id",
+		Code:    "max_depth",
+		Message: fmt.Sprintf("subtasks may only be nested %d levels deep", s.policy.MaxDepth),
+	})
+}
+
+// setParent makes task a subtask of parentID. The parent must belong to
+// ownerID, or to anyone when ownerID is empty; task takes the parent's
+// owner. A parent that does not qualify is reported as missing, so its
+// existence does not leak. height is the number of levels in task's own
+// subtree, counting task.
+func (s *TaskService) setParent(ctx context.Context, task *models.Task, parentID, ownerID string, height int) error {
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	parent, err := s.repo.GetByID(readCtx, parentID)
+	cancel()
+	if errors.Is(err, apperr.ErrNotFound) || err == nil && ownerID != "" && parent.UserID != ownerID {
+		return apperr.Validation(errParentNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	above, err := s.ancestors(ctx, parent)
+	if err != nil {
+		return err
+	}
+	if len(above)+1+height > s.policy.MaxDepth {
+		return s.errTooDeep()
+	}
+
+	task.UserID = parent.UserID
+	task.ParentID = &parent.ID
+	return nil
+}
+
+// moveTask moves task under parentID, or to the top level when parentID
+// is empty. The task keeps its owner, so the new parent must have it too.
+func (s *TaskService) moveTask(ctx context.Context, task *models.Task, parentID string) error {
+	switch {
+	case parentID == "":
+		task.ParentID = nil
+		return nil
+	case task.ParentID != nil && *task.ParentID == parentID:
+		return nil
+	case parentID == task.ID:
+		return apperr.Validation(errParentCycle)
+	}
+
+	levels, err := s.descendants(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	for _, level := range levels {
+		for _, t := range level {
+			if t.ID == parentID {
+				return apperr.Validation(errParentCycle)
+			}
+		}
+	}
+	return s.setParent(ctx, task, parentID, task.UserID, len(levels)+1)
+}
+
+// ancestors returns the IDs of the tasks above task, nearest first. The
+// chain can be longer than MaxDepth, which may have been lowered since the
+// tree was built; callers that limit depth check the length. Each task is
+// returned once, so parent rows that loop back cannot keep it going.
+func (s *TaskService) ancestors(ctx context.Context, task *models.Task) ([]string, error) {
+	var ids []string
+	seen := map[string]bool{task.ID: true}
+	for task.ParentID != nil && !seen[*task.ParentID] {
+		seen[*task.ParentID] = true
+		ids = append(ids, *task.ParentID)
+
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		parent, err := s.repo.GetByID(readCtx, *task.ParentID)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		task = parent
+	}
+	return ids, nil
+}
+
+// subtasks returns the direct subtasks of a task.
+func (s *TaskService) subtasks(ctx context.Context, taskID string) ([]models.Task, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+	return s.repo.List(ctx, repository.TaskQuery{ParentID: taskID})
+}
+
+// descendants returns the tasks below taskID one level per element,
+// nearest level first. Each task is returned once, so parent rows that
+// loop back cannot keep it going.
+func (s *TaskService) descendants(ctx context.Context, taskID string) ([][]models.Task, error) {
+	var levels [][]models.Task
+	seen := map[string]bool{taskID: true}
+	parents := []string{taskID}
+	for len(parents) > 0 {
+		var level []models.Task
+		for _, id := range parents {
+			children, err := s.subtasks(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				if !seen[child.ID] {
+					seen[child.ID] = true
+					level = append(level, child)
+				}
+			}
+		}
+		if len(level) == 0 {
+			break
+		}
+		levels = append(levels, level)
+
+		parents = parents[:0]
+		for _, t := range level {
+			parents = append(parents, t.ID)
+		}
+	}
+	return levels, nil
+}
+
+// checkCanComplete refuses to complete a task with open subtasks unless
+// the policy allows it.
+func (s *TaskService) checkCanComplete(ctx context.Context, taskID string) error {
+	if s.policy.CompleteWithOpenSubtasks {
+		return nil
+	}
+	children, err := s.subtasks(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if child.Status != models.StatusCompleted {
+			return fmt.Errorf("task has open subtasks: %w", apperr.ErrConflict)
+		}
+	}
+	return nil
+}
+
+// completeParents completes parentID if all its subtasks are completed,
+// then does the same for its parent, and so on up the tree, saving them
+// all in one transaction. It does nothing unless the policy asks for it.
+func (s *TaskService) completeParents(ctx context.Context, parentID string) error {
+	if !s.policy.AutoCompleteParent {
+		return nil
+	}
+	var before []models.Task
+	var parents []*models.Task
+	for range s.policy.MaxDepth {
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		parent, err := s.repo.GetByID(readCtx, parentID)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if parent.Status == models.StatusCompleted {
+			break
+		}
+
+		// A task whose last subtask was deleted has nothing to roll up.
+		children, err := s.subtasks(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		if len(children) == 0 || !allCompleted(children, parents) {
+			break
+		}
+		// A blocked parent waits for its blockers like any other task.
+		open, err := s.openBlockers(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		if len(open) > 0 {
+			break
+		}
+
+		before = append(before, *parent)
+		parent.Status = models.StatusCompleted
+		parent.UpdatedAt = time.Now()
+		parents = append(parents, parent)
+
+		if parent.ParentID == nil {
+			break
+		}
+		parentID = *parent.ParentID
+	}
+	return s.saveParents(ctx, before, parents, "parent task auto-completed")
+}
+
+// allCompleted reports whether every task in children is completed, or
+// about to be as one of completing.
+func allCompleted(children []models.Task, completing []*models.Task) bool {
+	for _, child := range children {
+		if child.Status == models.StatusCompleted {
+			continue
+		}
+		if !slices.ContainsFunc(completing, func(t *models.Task) bool { return t.ID == child.ID }) {
+			return false
+		}
+	}
+	return true
+}
+
+// reopenParents moves parentID and the completed tasks above it back to
+// in_progress in one transaction, because an open task was just placed
+// below them. It does nothing when the policy lets completed tasks have
+// open subtasks.
+func (s *TaskService) reopenParents(ctx context.Context, parentID string) error {
+	if s.policy.CompleteWithOpenSubtasks {
+		return nil
+	}
+	var before []models.Task
+	var parents []*models.Task
+	for range s.policy.MaxDepth {
+		readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+		parent, err := s.repo.GetByID(readCtx, parentID)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if parent.Status != models.StatusCompleted {
+			break
+		}
+
+		before = append(before, *parent)
+		parent.Status = models.StatusInProgress
+		parent.UpdatedAt = time.Now()
+		parents = append(parents, parent)
+
+		if parent.ParentID == nil {
+			break
+		}
+		parentID = *parent.ParentID
+	}
+	return s.saveParents(ctx, before, parents, "parent task reopened")
+}
+
+// saveParents saves the parents the subtask policy changed in one
+// transaction, then records the changes. before holds them as they were.
+func (s *TaskService) saveParents(ctx context.Context, before []models.Task, parents []*models.Task, msg string) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	err := s.repo.Apply(writeCtx, repository.TaskBatch{Update: parents})
+	cancel()
+	if err != nil {
+		return err
+	}
+	for i, parent := range parents {
+		s.recordChange(asSystem(ctx, models.ActorSubtasks), &before[i], parent)
+		slog.InfoContext(ctx, msg, "task_id", parent.ID)
+		if parent.Status == models.StatusCompleted {
+			s.unblocked(ctx, parent.ID)
+		}
+	}
+	return nil
+}
+
+// rollUp brings the parents of task in line with the policy after task
+// was saved: completed parents of an open task are reopened, and parents
+// whose subtasks are all completed are completed. The change to task is
+// already saved, so a failure here is only logged.
+func (s *TaskService) rollUp(ctx context.Context, task *models.Task) {
+	if task.ParentID == nil {
+		return
+	}
+	parentID := *task.ParentID
+	var err error
+	if task.Status == models.StatusCompleted {
+		err = s.completeParents(ctx, parentID)
+	} else {
+		err = s.reopenParents(ctx, parentID)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "updating parent task failed", "task_id", parentID, "error", err)
+	}
+}
+
+// subtaskRemoved completes parentID if the subtask that was just moved
+// away or deleted was the last open one. Like rollUp, it only logs a
+// failure.
+func (s *TaskService) subtaskRemoved(ctx context.Context, parentID string) {
+	if err := s.completeParents(ctx, parentID); err != nil {
+		slog.WarnContext(ctx, "updating parent task failed", "task_id", parentID, "error", err)
+	}
+}
+
+// TaskAutoCompleted is called by the auto-complete worker after it has
+// completed a task whose status was from. It records the change,
+// reschedules the tasks it no longer blocks, and makes the task's parents
+// follow the subtask policy.
+func (s *TaskService) TaskAutoCompleted(ctx context.Context, taskID string, from models.TaskStatus) error {
+	s.record(asSystem(ctx, models.ActorAutoComplete), taskID, models.EventStatusChanged, []models.FieldChange{
+		{Field: "status", Old: from, New: models.StatusCompleted},
+	})
+	s.unblocked(ctx, taskID)
+
+	if !s.policy.AutoCompleteParent {
+		return nil
+	}
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	task, err := s.repo.GetByID(readCtx, taskID)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if task.Status != models.StatusCompleted || task.ParentID == nil {
+		return nil
+	}
+	return s.completeParents(ctx, *task.ParentID)
+}
+
+// GetSubtasks returns one page of the direct subtasks of a task the caller
+// may see, newest first, and whether more follow it.
+func (s *TaskService) GetSubtasks(ctx context.Context, taskID, userID, role string, page Page) (tasks []models.Task, more bool, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetSubtasks")
+	defer func() { endSpan(span, err) }()
+
+	if _, err = s.GetTaskByID(ctx, taskID, userID, role); err != nil {
+		return nil, false, err
+	}
+
+	q := repository.TaskQuery{ParentID: taskID, Offset: page.Offset}
+	// Fetch one extra row to learn whether another page exists.
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	tasks, err = s.repo.List(ctx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	if page.Limit > 0 && len(tasks) > page.Limit {
+		return tasks[:page.Limit], true, nil
+	}
+	return tasks, false, nil
+}
+
+// subtaskDeletion is what deleting a task does to its subtasks under the
+// OnParentDelete policy.
+type subtaskDeletion struct {
+	// promoted are the direct subtasks moved up to the task's parent, as
+	// they were before the move.
+	promoted []models.Task
+	// trashed are the tasks below it, deepest first and as they were
+	// read, which go to the trash with it.
+	trashed []*models.Task
+}
+
+// planSubtaskDeletion works out what deleting task does to its subtasks,
+// or fails if the policy forbids deleting it. Nothing is saved.
+func (s *TaskService) planSubtaskDeletion(ctx context.Context, task *models.Task) (plan subtaskDeletion, err error) {
+	children, err := s.subtasks(ctx, task.ID)
+	if err != nil || len(children) == 0 {
+		return plan, err
+	}
+
+	switch s.policy.OnParentDelete {
+	case ParentDeleteRestrict:
+		return plan, fmt.Errorf("task has subtasks: %w", apperr.ErrConflict)
+
+	case ParentDeletePromote:
+		plan.promoted = children
+
+	default:
+		levels, err := s.descendants(ctx, task.ID)
+		if err != nil {
+			return plan, err
+		}
+		// Deepest first, so a failure part way never leaves a subtask
+		// whose parent is gone.
+		for i := len(levels) - 1; i >= 0; i-- {
+			for j := range levels[i] {
+				plan.trashed = append(plan.trashed, &levels[i][j])
+			}
+		}
+	}
+	return plan, nil
+}
+
+// promote returns child moved up to the parent of the task being deleted.
+func promote(child models.Task, task *models.Task) *models.Task {
+	child.ParentID = task.ParentID
+	child.UpdatedAt = time.Now()
+	return &child
+}
+
+// planDelete adds the writes of deleting task to b: task and, under the
+// OnParentDelete policy, its subtasks. Trashed subtasks share the
+// parent's deletion time, b.DeletedAt, which is how RestoreTask finds
+// them again. The trashed tasks keep their comments and dependencies
+// until they are purged, so a restore brings them back.
+func (s *TaskService) planDelete(ctx context.Context, task *models.Task, b *repository.TaskBatch) (plannedOp, error) {
+	sub, err := s.planSubtaskDeletion(ctx, task)
+	if err != nil {
+		return plannedOp{}, err
+	}
+	deleted := append(sub.trashed, task)
+	promoted := make([]*models.Task, len(sub.promoted))
+	for i := range sub.promoted {
+		promoted[i] = promote(sub.promoted[i], task)
+	}
+	b.Delete = append(b.Delete, deleted...)
+	b.Update = append(b.Update, promoted...)
+
+	var p plannedOp
+	for _, t := range slices.Concat(deleted, promoted) {
+		p.writes = append(p.writes, t.ID)
+	}
+	p.saved = func(ctx context.Context) {
+		for i, child := range promoted {
+			s.recordChange(ctx, &sub.promoted[i], child)
+		}
+		for _, t := range deleted {
+			s.record(ctx, t.ID, models.EventDeleted, nil)
+			s.unblocked(ctx, t.ID)
+		}
+		if task.ParentID != nil {
+			s.subtaskRemoved(ctx, *task.ParentID)
+		}
+	}
+	return p, nil
+}
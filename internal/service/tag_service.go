@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// TagService manages the caller's own tags. Tags are put on tasks through
+// TaskService.
+type TagService struct {
+	repo     repository.TagRepository
+	timeouts Timeouts
+}
+
+func NewTagService(r repository.TagRepository, t Timeouts) *TagService {
+	return &TagService{repo: r, timeouts: t}
+}
+
+// fieldErrors turns the non-nil errors into a validation error, or nil
+// when there are none.
+func fieldErrors(errs ...*apperr.FieldError) error {
+	var fields []apperr.FieldError
+	for _, fe := range errs {
+		if fe != nil {
+			fields = append(fields, *fe)
+		}
+	}
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+func (s *TagService) ListTags(ctx context.Context, userID string) (tags []models.Tag, err error) {
+	ctx, span := startSpan(ctx, "TagService.ListTags")
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	return s.repo.List(ctx, userID)
+}
+
+// RenameTag renames one of the caller's tags on every task that has it and
+// returns the renamed tag.
+func (s *TagService) RenameTag(ctx context.Context, userID, name, newName string) (tag *models.Tag, err error) {
+	ctx, span := startSpan(ctx, "TagService.RenameTag")
+	defer func() { endSpan(span, err) }()
+
+	name, nameErr := normalizeTagName("name", name)
+	newName, newNameErr := normalizeTagName("new_name", newName)
+	if err = fieldErrors(nameErr, newNameErr); err != nil {
+		return nil, err
+	}
+
+	writeCtx, cancelWrite := withTimeout(ctx, s.timeouts.Write)
+	defer cancelWrite()
+
+	if err = s.repo.Rename(writeCtx, userID, name, newName); err != nil {
+		return nil, err
+	}
+
+	readCtx, cancelRead := withTimeout(ctx, s.timeouts.Read)
+	defer cancelRead()
+
+	return s.repo.Get(readCtx, userID, newName)
+}
+
+// MergeTags retags every task tagged from with into, deletes from, and
+// returns into.
+func (s *TagService) MergeTags(ctx context.Context, userID, from, into string) (tag *models.Tag, err error) {
+	ctx, span := startSpan(ctx, "TagService.MergeTags")
+	defer func() { endSpan(span, err) }()
+
+	from, fromErr := normalizeTagName("name", from)
+	into, intoErr := normalizeTagName("into", into)
+	if err = fieldErrors(fromErr, intoErr); err != nil {
+		return nil, err
+	}
+	if from == into {
+		return nil, apperr.Validation(apperr.FieldError{Field: "into", Code: "invalid", Message: "cannot merge a tag into itself"})
+	}
+
+	writeCtx, cancelWrite := withTimeout(ctx, s.timeouts.Write)
+	defer cancelWrite()
+
+	if err = s.repo.Merge(writeCtx, userID, from, into); err != nil {
+		return nil, err
+	}
+
+	readCtx, cancelRead := withTimeout(ctx, s.timeouts.Read)
+	defer cancelRead()
+
+	return s.repo.Get(readCtx, userID, into)
+}
+
+// DeleteTag deletes one of the caller's tags and removes it from their
+// tasks.
+func (s *TagService) DeleteTag(ctx context.Context, userID, name string) (err error) {
+	ctx, span := startSpan(ctx, "TagService.DeleteTag")
+	defer func() { endSpan(span, err) }()
+
+	name, nameErr := normalizeTagName("name", name)
+	if err = fieldErrors(nameErr); err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	return s.repo.Delete(ctx, userID, name)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+)
+
+const (
+	// maxTagLen matches the tags.name column (VARCHAR(50) on MySQL).
+	maxTagLen = 50
+	// maxTags caps the tags on a task and in a filter.
+	maxTags = 20
+)
+
+// Tag names are lower case, start with a letter or digit and may contain
+// . _ : and -, so they are safe in URL paths and query strings.
+var tagPattern = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}][\p{Ll}\p{Lo}\p{N}._:-]*$`)
+
+// normalizeTagName lower-cases and trims name and checks it is a valid tag
+// name. The returned FieldError is reported under field.
+func normalizeTagName(field, name string) (string, *apperr.FieldError) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == "":
+		return "", &apperr.FieldError{Field: field, Code: "required", Message: "tag name is required"}
+	case utf8.RuneCountInString(name) > maxTagLen:
+		return "", &apperr.FieldError{Field: field, Code: "max", Message: "tag names must be at most 50 characters"}
+	case !tagPattern.MatchString(name):
+		return "", &apperr.FieldError{Field: field, Code: "format", Message: "tag names may only contain letters, digits, '.', '_', ':' and '-'"}
+	}
+	return name, nil
+}
+
+// normalizeTags normalizes each name and returns them sorted without
+// duplicates, up to maxTags of them. It never returns nil on success, so an
+// untagged task encodes its tags as [].
+func normalizeTags(field string, names []string) ([]string, *apperr.FieldError) {
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, fe := normalizeTagName(field, name)
+		if fe != nil {
+			return nil, fe
+		}
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+	if len(tags) > maxTags {
+		return nil, &apperr.FieldError{Field: field, Code: "max", Message: "at most 20 tags are allowed"}
+	}
+	return tags, nil
+}
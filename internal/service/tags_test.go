@@ -0,0 +1,31 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got, fe := normalizeTags("tags", []string{" Urgent", "billing", "urgent", "q3:eu"})
+	if fe != nil || !slices.Equal(got, []string{"billing", "q3:eu", "urgent"}) {
+		t.Errorf("normalizeTags = %v, %+v", got, fe)
+	}
+	if got, _ := normalizeTags("tags", nil); got == nil {
+		t.Error("normalizeTags(nil) = nil, want an empty slice")
+	}
+
+	for name, code := range map[string]string{"": "required", "has space": "format", "-leading": "format"} {
+		if _, fe := normalizeTags("tags", []string{"ok", name}); fe == nil || fe.Field != "tags" || fe.Code != code {
+			t.Errorf("tag %q: %+v, want code %s", name, fe, code)
+		}
+	}
+
+	many := make([]string, maxTags+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("t%d", i)
+	}
+	if _, fe := normalizeTags("tags", many); fe == nil || fe.Code != "max" {
+		t.Errorf("%d tags: %+v", len(many), fe)
+	}
+}
@@ -1,72 +1,411 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/logging"
 	"github.com/CashInvoice-Golang-Assignment/internal/models"
 	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+	"github.com/CashInvoice-Golang-Assignment/internal/worker"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TaskService struct {
-	repo  repository.TaskRepository
-	queue chan string
+	repo     repository.TaskRepository
+	comments repository.CommentRepository
+	events   repository.TaskEventRepository
+	deps     repository.DependencyRepository
+	series   repository.SeriesRepository
+	queue    chan worker.Job
+	timeouts Timeouts
+	policy   SubtaskPolicy
+	// onPurge, if set, runs before each task is purged for good.
+	onPurge func(ctx context.Context, taskID string) error
 }
 
-func NewTaskService(r repository.TaskRepository, q chan string) *TaskService {
-	return &TaskService{repo: r, queue: q}
+func NewTaskService(r repository.TaskRepository, c repository.CommentRepository, e repository.TaskEventRepository, d repository.DependencyRepository, sr repository.SeriesRepository, q chan worker.Job, t Timeouts, p SubtaskPolicy) *TaskService {
+	return &TaskService{repo: r, comments: c, events: e, deps: d, series: sr, queue: q, timeouts: t, policy: p}
 }
 
-func (s *TaskService) CreateTask(task *models.Task) error {
-	if err := s.repo.Create(task); err != nil {
+// maxTitleLen matches the tasks.title column (VARCHAR(255) on MySQL).
+const maxTitleLen = 255
+
+// validateTask checks the fields the storage layer relies on and
+// normalizes the task's tags.
+func validateTask(task *models.Task) error {
+	var fields []apperr.FieldError
+	switch title := strings.TrimSpace(task.Title); {
+	case title == "":
+		fields = append(fields, apperr.FieldError{Field: "title", Code: "required", Message: "title is required"})
+	case utf8.RuneCountInString(title) > maxTitleLen:
+		fields = append(fields, apperr.FieldError{Field: "title", Code: "max", Message: "title must be at most 255 characters"})
+	}
+	if !task.Status.Valid() {
+		fields = append(fields, apperr.FieldError{Field: "status", Code: "oneof", Message: "must be one of: pending in_progress completed"})
+	}
+	if tags, fe := normalizeTags("tags", task.Tags); fe != nil {
+		fields = append(fields, *fe)
+	} else {
+		task.Tags = tags
+	}
+	if len(fields) > 0 {
+		return apperr.Validation(fields...)
+	}
+	return nil
+}
+
+// Page selects a window of a listing.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// TaskFilter narrows a task listing.
+type TaskFilter struct {
+	// Tags keeps tasks with any of these tags, or all of them when
+	// MatchAll is set. Empty keeps every task.
+	Tags     []string
+	MatchAll bool
+	// SeriesID keeps only the occurrences of one recurring task.
+	SeriesID string
+}
+
+// TaskUpdate is a partial update; nil fields are left unchanged.
+type TaskUpdate struct {
+	Title       *string
+	Description *string
+	Status      *models.TaskStatus
+	// Tags replaces the task's tags; an empty slice removes them all.
+	Tags *[]string
+	// ParentID moves the task under another task; an empty string makes
+	// it a top-level task.
+	ParentID *string
+	// Scope is ScopeThis or ScopeFuture; empty means ScopeThis. Status
+	// and ParentID only ever apply to the task itself.
+	Scope string
+	// Recurrence replaces the rule of the task's series from this
+	// occurrence on. It needs ScopeFuture.
+	Recurrence *Recurrence
+	// IfMatch, when not nil, lists the entity tags the task must still
+	// have; see checkIfMatch.
+	IfMatch []string
+}
+
+// checkIfMatch returns ErrPreconditionFailed unless task's entity tag is
+// one of ifMatch, or ifMatch holds "*". A nil ifMatch always passes.
+func checkIfMatch(task *models.Task, ifMatch []string) error {
+	if ifMatch == nil || slices.Contains(ifMatch, "*") || slices.Contains(ifMatch, task.ETag()) {
+		return nil
+	}
+	return fmt.Errorf("task is at %s: %w", task.ETag(), apperr.ErrPreconditionFailed)
+}
+
+// CreateTask creates task. A subtask takes its parent's owner, so an admin
+// can add subtasks to anyone's task.
+func (s *TaskService) CreateTask(ctx context.Context, task *models.Task, role string) (err error) {
+	ctx, span := startSpan(ctx, "TaskService.CreateTask")
+	defer func() { endSpan(span, err) }()
+	ctx = asUser(ctx, task.UserID, role)
+
+	if err = s.checkCreate(ctx, task, role); err != nil {
+		return err
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err = s.repo.Create(writeCtx, task); err != nil {
+		return err
+	}
+	s.created(ctx, task)
+	return nil
+}
+
+// checkCreate validates a task about to be created and places it under
+// its parent, if it has one.
+func (s *TaskService) checkCreate(ctx context.Context, task *models.Task, role string) error {
+	if err := validateTask(task); err != nil {
 		return err
 	}
+	if task.ParentID != nil && *task.ParentID == "" {
+		task.ParentID = nil
+	}
+	if task.ParentID == nil {
+		return nil
+	}
+	owner := task.UserID
+	if role == "admin" {
+		owner = ""
+	}
+	return s.setParent(ctx, task, *task.ParentID, owner, 1)
+}
+
+// created follows up on a task that was just saved: it records it, lets
+// its parents follow the subtask policy and schedules it for
+// auto-complete.
+func (s *TaskService) created(ctx context.Context, task *models.Task) {
+	s.recordChange(ctx, nil, task)
+	s.rollUp(ctx, task)
+	s.schedule(ctx, task)
+}
 
+// schedule queues task for the auto-complete worker.
+func (s *TaskService) schedule(ctx context.Context, task *models.Task) {
 	// Non-blocking send
 	select {
-	case s.queue <- task.ID:
+	case s.queue <- worker.Job{
+		TaskID:     task.ID,
+		RequestID:  logging.RequestID(ctx),
+		EnqueuedAt: time.Now(),
+		Link:       trace.SpanContextFromContext(ctx),
+	}:
 		// enqueued
 	case <-time.After(500 * time.Millisecond):
-		log.Println("Auto-complete queue timeout, skipping:", task.ID)
+		slog.WarnContext(ctx, "auto-complete queue timeout, skipping", "task_id", task.ID)
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "request cancelled before enqueue, skipping auto-complete", "task_id", task.ID)
 	}
-	return nil
 }
 
-func (s *TaskService) GetAllTasks(userID string, role string) ([]models.Task, error) {
+// GetAllTasks returns one page of the tasks the caller may see, and
+// whether more follow it.
+func (s *TaskService) GetAllTasks(ctx context.Context, userID string, role string, filter TaskFilter, page Page) (tasks []models.Task, more bool, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetAllTasks")
+	defer func() { endSpan(span, err) }()
+
 	if userID == "" {
-		return nil, errors.New("unauthorized")
+		return nil, false, apperr.ErrUnauthorized
+	}
+
+	tags, fe := normalizeTags("tag", filter.Tags)
+	if fe != nil {
+		return nil, false, apperr.Validation(*fe)
+	}
+
+	q := repository.TaskQuery{Tags: tags, MatchAll: filter.MatchAll, SeriesID: filter.SeriesID, Offset: page.Offset}
+	if role != "admin" {
+		q.UserID = userID
+	}
+	// Fetch one extra row to learn whether another page exists.
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
 	}
 
-	isAdmin := role == "admin"
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
 
-	return s.repo.GetAll(userID, isAdmin)
+	tasks, err = s.repo.List(ctx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	if page.Limit > 0 && len(tasks) > page.Limit {
+		return tasks[:page.Limit], true, nil
+	}
+	return tasks, false, nil
 }
 
-func (s *TaskService) GetTaskByID(taskID, userID, role string) (*models.Task, error) {
-	task, err := s.repo.GetByID(taskID)
+func (s *TaskService) GetTaskByID(ctx context.Context, taskID, userID, role string) (task *models.Task, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetTaskByID")
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	task, err = s.repo.GetByID(ctx, taskID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Authorization: user can only access own task
 	if role != "admin" && task.UserID != userID {
-		return nil, errors.New("forbidden")
+		return nil, apperr.ErrForbidden
 	}
 
 	return task, nil
 }
 
-func (s *TaskService) DeleteTask(taskID, userID, role string) error {
-	task, err := s.repo.GetByID(taskID)
+// UpdateTask applies a partial update to a task the caller may modify and
+// returns the result.
+func (s *TaskService) UpdateTask(ctx context.Context, taskID, userID, role string, upd TaskUpdate) (task *models.Task, err error) {
+	ctx, span := startSpan(ctx, "TaskService.UpdateTask")
+	defer func() { endSpan(span, err) }()
+	ctx = asUser(ctx, userID, role)
+
+	task, before, err := s.checkUpdate(ctx, taskID, userID, role, upd)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// With scope=future the later occurrences are saved along with task,
+	// and the series only once they are.
+	b := repository.TaskBatch{Update: []*models.Task{task}}
+	var future *futureUpdate
+	if upd.Scope == ScopeFuture {
+		// Truncated to what the repositories store, as in DeleteTask.
+		b.DeletedAt = time.Now().UTC().Truncate(time.Second)
+		if future, err = s.planFuture(ctx, task, upd, &b); err != nil {
+			return nil, err
+		}
 	}
 
-	// Authorization: user can only delete own task
+	writeCtx, cancelWrite := withTimeout(ctx, s.timeouts.Write)
+	defer cancelWrite()
+
+	if err = s.repo.Apply(writeCtx, b); err != nil {
+		// The task changed since it was read above, so the tag the
+		// caller matched is stale too.
+		if upd.IfMatch != nil && errors.Is(err, apperr.ErrConflict) {
+			return nil, fmt.Errorf("task was changed by another request: %w", apperr.ErrPreconditionFailed)
+		}
+		return nil, err
+	}
+	s.updated(ctx, &before, task)
+
+	if future != nil {
+		if err = s.saveFuture(ctx, future); err != nil {
+			return nil, err
+		}
+	}
+	if task.Status == models.StatusCompleted && before.Status != models.StatusCompleted {
+		s.occurrenceCompleted(ctx, task)
+	}
+	return task, nil
+}
+
+// checkUpdate reads a task the caller may modify and applies upd to it,
+// checking the result against the subtask, dependency and recurrence
+// rules. It returns the task as it was too. Nothing is saved.
+func (s *TaskService) checkUpdate(ctx context.Context, taskID, userID, role string, upd TaskUpdate) (task *models.Task, before models.Task, err error) {
+	readCtx, cancelRead := withTimeout(ctx, s.timeouts.Read)
+	defer cancelRead()
+
+	task, err = s.repo.GetByID(readCtx, taskID)
+	if err != nil {
+		return nil, before, err
+	}
+
+	// Authorization: user can only update own task
 	if role != "admin" && task.UserID != userID {
-		return errors.New("forbidden")
+		return nil, before, apperr.ErrForbidden
+	}
+	if err = checkIfMatch(task, upd.IfMatch); err != nil {
+		return nil, before, err
+	}
+	before = *task
+	wasStatus := task.Status
+
+	if upd.Title != nil {
+		task.Title = *upd.Title
+	}
+	if upd.Description != nil {
+		task.Description = *upd.Description
+	}
+	if upd.Status != nil {
+		task.Status = *upd.Status
+	}
+	if upd.Tags != nil {
+		task.Tags = *upd.Tags
+	}
+	if err = validateTask(task); err != nil {
+		return nil, before, err
+	}
+	if err = checkScope(task, upd); err != nil {
+		return nil, before, err
+	}
+	if startsWork(wasStatus, task.Status) {
+		if err = s.checkUnblocked(ctx, task.ID); err != nil {
+			return nil, before, err
+		}
 	}
+	if upd.Status != nil && *upd.Status == models.StatusCompleted && wasStatus != models.StatusCompleted {
+		if err = s.checkCanComplete(ctx, task.ID); err != nil {
+			return nil, before, err
+		}
+	}
+	if upd.ParentID != nil {
+		if err = s.moveTask(ctx, task, *upd.ParentID); err != nil {
+			return nil, before, err
+		}
+	}
+	task.UpdatedAt = time.Now()
+	return task, before, nil
+}
 
-	return s.repo.Delete(taskID)
+// updated follows up on a change to a task that was just saved: it
+// records it, lets the parents the task is under, or was moved away from,
+// follow the subtask policy, and reschedules the tasks a completed task
+// no longer blocks.
+func (s *TaskService) updated(ctx context.Context, before, task *models.Task) {
+	s.recordChange(ctx, before, task)
+	if task.Status == models.StatusCompleted && before.Status != models.StatusCompleted {
+		s.unblocked(ctx, task.ID)
+	}
+
+	s.rollUp(ctx, task)
+	if oldParent := before.ParentID; oldParent != nil && (task.ParentID == nil || *task.ParentID != *oldParent) {
+		s.subtaskRemoved(ctx, *oldParent)
+	}
+}
+
+// DeleteTask moves a task to the trash, which unblocks the tasks it
+// blocked. Its subtasks are handled as the SubtaskPolicy says; with
+// cascade they go to the trash along with it. A non-nil ifMatch is
+// checked as for TaskUpdate.IfMatch.
+func (s *TaskService) DeleteTask(ctx context.Context, taskID, userID, role string, ifMatch []string) (err error) {
+	ctx, span := startSpan(ctx, "TaskService.DeleteTask")
+	defer func() { endSpan(span, err) }()
+	ctx = asUser(ctx, userID, role)
+
+	existing, err := s.checkDelete(ctx, taskID, userID, role, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	// Truncated to what the repositories store, so the tasks trashed
+	// together can be matched up again.
+	b := repository.TaskBatch{DeletedAt: time.Now().UTC().Truncate(time.Second)}
+	p, err := s.planDelete(ctx, existing, &b)
+	if err != nil {
+		return err
+	}
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	err = s.repo.Apply(writeCtx, b)
+	cancel()
+	if err != nil {
+		// As in UpdateTask, a task changed since it was read no longer
+		// has the tag the caller matched.
+		if ifMatch != nil && errors.Is(err, apperr.ErrConflict) {
+			return fmt.Errorf("task was changed by another request: %w", apperr.ErrPreconditionFailed)
+		}
+		return err
+	}
+	p.saved(ctx)
+	return nil
+}
+
+// checkDelete reads a task the caller may delete.
+func (s *TaskService) checkDelete(ctx context.Context, taskID, userID, role string, ifMatch []string) (*models.Task, error) {
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	existing, err := s.repo.GetByID(readCtx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorization: user can only delete own task
+	if role != "admin" && existing.UserID != userID {
+		return nil, apperr.ErrForbidden
+	}
+	if err = checkIfMatch(existing, ifMatch); err != nil {
+		return nil, err
+	}
+	return existing, nil
 }
@@ -0,0 +1,585 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+	"github.com/CashInvoice-Golang-Assignment/internal/worker"
+)
+
+// slowTaskRepository blocks every lookup until the context is done.
+type slowTaskRepository struct {
+	repository.TaskRepository
+}
+
+func (slowTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGetTaskByIDReadTimeout(t *testing.T) {
+	s := NewTaskService(slowTaskRepository{}, nil, nil, nil, nil, nil, Timeouts{Read: 10 * time.Millisecond}, SubtaskPolicy{})
+
+	_, err := s.GetTaskByID(context.Background(), "id", "user", "user")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestGetTaskByIDCallerCancel(t *testing.T) {
+	s := NewTaskService(slowTaskRepository{}, nil, nil, nil, nil, nil, Timeouts{Read: time.Minute}, SubtaskPolicy{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.GetTaskByID(ctx, "id", "user", "user")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+// stubTaskRepository returns a fixed task or error from GetByID.
+type stubTaskRepository struct {
+	repository.TaskRepository
+	task *models.Task
+	err  error
+}
+
+func (r stubTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	return r.task, r.err
+}
+
+func TestGetTaskByIDErrors(t *testing.T) {
+	outage := errors.New("dial tcp: connection refused")
+	s := NewTaskService(stubTaskRepository{err: outage}, nil, nil, nil, nil, nil, Timeouts{}, SubtaskPolicy{})
+	_, err := s.GetTaskByID(context.Background(), "id", "user", "user")
+	if !errors.Is(err, outage) || errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("storage failure: err = %v, want the outage, not ErrNotFound", err)
+	}
+
+	s = NewTaskService(stubTaskRepository{task: &models.Task{UserID: "alice"}}, nil, nil, nil, nil, nil, Timeouts{}, SubtaskPolicy{})
+	if _, err := s.GetTaskByID(context.Background(), "id", "bob", "user"); !errors.Is(err, apperr.ErrForbidden) {
+		t.Errorf("other user's task: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestCreateTaskValidation(t *testing.T) {
+	s := NewTaskService(nil, nil, nil, nil, nil, nil, Timeouts{}, SubtaskPolicy{})
+
+	err := s.CreateTask(context.Background(), &models.Task{Title: "   ", Status: models.StatusPending}, "user")
+	var ve *apperr.ValidationError
+	if !errors.As(err, &ve) || len(ve.Fields) != 1 || ve.Fields[0].Field != "title" {
+		t.Fatalf("blank title: err = %v, want a validation error on title", err)
+	}
+	if !errors.Is(err, apperr.ErrValidation) {
+		t.Error("ValidationError should match ErrValidation")
+	}
+}
+
+// racingTaskRepository changes a task right after every lookup of it, as
+// a concurrent request would.
+type racingTaskRepository struct {
+	*repository.MemoryTaskRepository
+}
+
+func (r racingTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
+	task, err := r.MemoryTaskRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changed := *task
+	changed.Title += "!"
+	if err := r.Update(ctx, &changed); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func TestDeleteTaskChangedSinceRead(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	s := NewTaskService(racingTaskRepository{tasks}, nil, repository.NewMemoryTaskEventRepository(), nil, nil, nil, Timeouts{}, SubtaskPolicy{})
+
+	now := time.Now()
+	parentID := "t"
+	for _, task := range []*models.Task{
+		{ID: "t", Title: "t", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now},
+		{ID: "sub", Title: "sub", Status: models.StatusPending, UserID: "alice", ParentID: &parentID, CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.DeleteTask(ctx, "t", "alice", "user", []string{`"1"`}); !errors.Is(err, apperr.ErrPreconditionFailed) {
+		t.Errorf("DeleteTask with If-Match = %v, want ErrPreconditionFailed", err)
+	}
+	if err := s.DeleteTask(ctx, "t", "alice", "user", nil); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("DeleteTask = %v, want ErrConflict", err)
+	}
+	// The subtasks are trashed in the same transaction, so they stay too.
+	for _, id := range []string{"t", "sub"} {
+		if _, err := tasks.GetByID(ctx, id); err != nil {
+			t.Errorf("task %s was trashed: %v", id, err)
+		}
+	}
+}
+
+func TestCreateRecurringTaskInsertFails(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	series := repository.NewMemorySeriesRepository()
+	s := NewTaskService(tasks, nil, nil, nil, series, nil, Timeouts{}, SubtaskPolicy{})
+
+	now := time.Now()
+	if err := tasks.Create(ctx, &models.Task{ID: "daily", Title: "taken", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	task := &models.Task{ID: "daily", Title: "standup", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}
+	if err := s.CreateRecurringTask(ctx, task, Recurrence{RRule: "FREQ=DAILY"}, "user"); !errors.Is(err, apperr.ErrConflict) {
+		t.Fatalf("CreateRecurringTask = %v, want ErrConflict", err)
+	}
+	// The series of the task that was never created must not go on to
+	// create occurrences.
+	if due, _ := series.Due(ctx, now.AddDate(1, 0, 0), 10); len(due) != 0 {
+		t.Errorf("series left behind: %+v", due)
+	}
+}
+
+// failingCreates fails the next *n calls to Create.
+type failingCreates struct {
+	repository.TaskRepository
+	n *int
+}
+
+func (r failingCreates) Create(ctx context.Context, task *models.Task) error {
+	if *r.n > 0 {
+		*r.n--
+		return errors.New("insert failed")
+	}
+	return r.TaskRepository.Create(ctx, task)
+}
+
+// failingAdvances fails the next *n calls to Advance.
+type failingAdvances struct {
+	repository.SeriesRepository
+	n *int
+}
+
+func (r failingAdvances) Advance(ctx context.Context, id string, from time.Time, to *time.Time) (bool, error) {
+	if *r.n > 0 {
+		*r.n--
+		return false, errors.New("update failed")
+	}
+	return r.SeriesRepository.Advance(ctx, id, from, to)
+}
+
+func TestGenerateDueRetriesFailedOccurrence(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	series := repository.NewMemorySeriesRepository()
+	var failCreate, failAdvance int
+	s := NewTaskService(failingCreates{tasks, &failCreate}, nil, repository.NewMemoryTaskEventRepository(), nil, failingAdvances{series, &failAdvance}, nil, Timeouts{}, SubtaskPolicy{})
+
+	now := time.Now()
+	task := &models.Task{ID: "daily", Title: "standup", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}
+	if err := s.CreateRecurringTask(ctx, task, Recurrence{RRule: "FREQ=DAILY"}, "user"); err != nil {
+		t.Fatal(err)
+	}
+	occurrences := func() int {
+		t.Helper()
+		list, err := tasks.List(ctx, repository.TaskQuery{SeriesID: *task.SeriesID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(list)
+	}
+	due := now.Add(36 * time.Hour)
+
+	// A failed insert leaves the occurrence due.
+	failCreate = 1
+	if created, _ := s.GenerateDue(ctx, due); created != 0 || occurrences() != 1 {
+		t.Fatalf("after a failed insert: created %d, %d occurrences", created, occurrences())
+	}
+	// A failed claim leaves it due too, but the retry does not create it
+	// a second time.
+	failAdvance = 1
+	if created, _ := s.GenerateDue(ctx, due); created != 1 || occurrences() != 2 {
+		t.Fatalf("after a failed claim: created %d, %d occurrences", created, occurrences())
+	}
+	if created, _ := s.GenerateDue(ctx, due); created != 0 || occurrences() != 2 {
+		t.Fatalf("retrying the claim: created %d, %d occurrences", created, occurrences())
+	}
+	if left, _ := series.Due(ctx, due, 10); len(left) != 0 {
+		t.Errorf("series still due: %+v", left)
+	}
+}
+
+func TestUpdateFutureChangedSinceRead(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	events := repository.NewMemoryTaskEventRepository()
+	series := repository.NewMemorySeriesRepository()
+	plain := NewTaskService(tasks, nil, events, nil, series, nil, Timeouts{}, SubtaskPolicy{})
+	racing := NewTaskService(racingTaskRepository{tasks}, nil, events, nil, series, nil, Timeouts{}, SubtaskPolicy{})
+
+	now := time.Now()
+	task := &models.Task{ID: "daily", Title: "standup", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}
+	if err := plain.CreateRecurringTask(ctx, task, Recurrence{RRule: "FREQ=DAILY"}, "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "sync"
+	upd := TaskUpdate{Title: &title, Scope: ScopeFuture, IfMatch: []string{task.ETag()}}
+	if _, err := racing.UpdateTask(ctx, "daily", "alice", "user", upd); !errors.Is(err, apperr.ErrPreconditionFailed) {
+		t.Fatalf("UpdateTask = %v, want ErrPreconditionFailed", err)
+	}
+	// The series is only saved once the occurrence is.
+	if got, _ := series.GetByID(ctx, *task.SeriesID); got.Title != "standup" {
+		t.Errorf("series title = %q, want it unchanged", got.Title)
+	}
+}
+
+func TestBatchDeleteChangedSinceRead(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	s := NewTaskService(racingTaskRepository{tasks}, nil, repository.NewMemoryTaskEventRepository(), nil, nil, nil, Timeouts{}, SubtaskPolicy{})
+
+	now := time.Now()
+	for _, id := range []string{"a", "b"} {
+		if err := tasks.Create(ctx, &models.Task{ID: id, Title: id, Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ops := []BatchOp{{Op: OpDelete, TaskID: "a"}, {Op: OpDelete, TaskID: "b"}}
+	if _, err := s.Batch(ctx, "alice", "user", ops, true); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Batch = %v, want ErrConflict", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if _, err := tasks.GetByID(ctx, id); err != nil {
+			t.Errorf("task %s was trashed: %v", id, err)
+		}
+	}
+}
+
+func TestBatchParentCycle(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	s := NewTaskService(tasks, nil, repository.NewMemoryTaskEventRepository(), nil, nil, nil, Timeouts{}, SubtaskPolicy{MaxDepth: 3})
+
+	now := time.Now()
+	for _, id := range []string{"a", "b"} {
+		if err := tasks.Create(ctx, &models.Task{ID: id, Title: id, Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each move is fine on its own, but together they make a loop.
+	a, b := "a", "b"
+	ops := []BatchOp{
+		{Op: OpUpdate, TaskID: "a", Update: TaskUpdate{ParentID: &b}},
+		{Op: OpUpdate, TaskID: "b", Update: TaskUpdate{ParentID: &a}},
+	}
+	results, err := s.Batch(ctx, "alice", "user", ops, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if !errors.Is(r.Err, apperr.ErrConflict) {
+			t.Errorf("result %d = %v, want ErrConflict", i, r.Err)
+		}
+	}
+	for _, id := range []string{"a", "b"} {
+		if task, _ := tasks.GetByID(ctx, id); task.ParentID != nil {
+			t.Errorf("task %s was moved under %s", id, *task.ParentID)
+		}
+	}
+
+	// A loop that got into the table anyway must not hang the reads.
+	for _, move := range [][2]string{{"a", "b"}, {"b", "a"}} {
+		task, _ := tasks.GetByID(ctx, move[0])
+		task.ParentID = &move[1]
+		if err := tasks.Update(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if task, err := tasks.GetByID(ctx, "a"); err != nil || task.Progress == nil || *task.Progress != 0 {
+		t.Errorf("GetByID(a) = %+v, %v, want progress 0", task, err)
+	}
+	if levels, err := s.descendants(ctx, "a"); err != nil || len(levels) != 1 {
+		t.Errorf("descendants(a) = %v, %v, want one level", levels, err)
+	}
+	task, _ := tasks.GetByID(ctx, "a")
+	if above, err := s.ancestors(ctx, task); err != nil || !slices.Equal(above, []string{"b"}) {
+		t.Errorf("ancestors(a) = %v, %v, want [b]", above, err)
+	}
+}
+
+func TestMaxDepthLowered(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	s := NewTaskService(tasks, nil, repository.NewMemoryTaskEventRepository(), repository.NewMemoryDependencyRepository(tasks), nil, nil, Timeouts{}, SubtaskPolicy{MaxDepth: 2})
+
+	// a > b > c > d was built while the limit was higher.
+	now := time.Now()
+	var parent *string
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if err := tasks.Create(ctx, &models.Task{ID: id, Title: id, Status: models.StatusPending, UserID: "alice", ParentID: parent, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+		parent = &id
+	}
+
+	err := s.CreateTask(ctx, &models.Task{Title: "e", Status: models.StatusPending, UserID: "alice", ParentID: parent}, "user")
+	var ve *apperr.ValidationError
+	if !errors.As(err, &ve) || len(ve.Fields) != 1 || ve.Fields[0].Code != "max_depth" {
+		t.Fatalf("subtask of d: err = %v, want a max_depth validation error", err)
+	}
+	// The existing tasks can still be changed.
+	done := models.StatusCompleted
+	if _, err := s.UpdateTask(ctx, "d", "alice", "user", TaskUpdate{Status: &done}); err != nil {
+		t.Errorf("complete d: %v", err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	comments := repository.NewMemoryCommentRepository()
+	events := repository.NewMemoryTaskEventRepository()
+	deps := repository.NewMemoryDependencyRepository(tasks)
+	s := NewTaskService(tasks, comments, events, deps, nil, nil, Timeouts{}, SubtaskPolicy{})
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"old", "recent", "live"} {
+		if err := tasks.Create(ctx, &models.Task{ID: id, Title: id, Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	comments.Create(ctx, &models.Comment{ID: "c1", TaskID: "old", AuthorID: "alice", Body: "hi", CreatedAt: now, UpdatedAt: now})
+	events.Create(ctx, &models.TaskEvent{TaskID: "old", Type: models.EventDeleted, Actor: models.ActorUser, ActorID: "alice", CreatedAt: now})
+	deps.Add(ctx, &models.Dependency{TaskID: "live", BlockerID: "old", CreatedAt: now})
+	tasks.Delete(ctx, &models.Task{ID: "old", Version: 1}, now.AddDate(0, 0, -31))
+	tasks.Delete(ctx, &models.Task{ID: "recent", Version: 1}, now.AddDate(0, 0, -1))
+
+	purged, err := s.PurgeTrash(ctx, now.AddDate(0, 0, -30))
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeTrash = %d, %v, want 1", purged, err)
+	}
+	if trash, _ := tasks.List(ctx, repository.TaskQuery{Trashed: true}); len(trash) != 1 || trash[0].ID != "recent" {
+		t.Errorf("trash after purge = %+v, want only recent", trash)
+	}
+	if _, err := tasks.GetByID(ctx, "live"); err != nil {
+		t.Errorf("live task: %v", err)
+	}
+	if _, err := comments.GetByID(ctx, "c1"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("comment of a purged task: err = %v, want ErrNotFound", err)
+	}
+	if history, _ := events.List(ctx, repository.TaskEventQuery{TaskID: "old"}); len(history) != 0 {
+		t.Errorf("history of a purged task survived: %+v", history)
+	}
+	if blockers, _ := deps.BlockersOf(ctx, "live"); len(blockers) != 0 {
+		t.Errorf("dependency on a purged task survived: %+v", blockers)
+	}
+}
+
+func TestPurgeTrashHookFails(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	s := NewTaskService(tasks, repository.NewMemoryCommentRepository(), repository.NewMemoryTaskEventRepository(),
+		repository.NewMemoryDependencyRepository(tasks), nil, nil, Timeouts{}, SubtaskPolicy{})
+	hookErr := errors.New("store unavailable")
+	s.OnPurge(func(ctx context.Context, taskID string) error { return hookErr })
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	task := &models.Task{ID: "old", Title: "old", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}
+	if err := tasks.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	tasks.Delete(ctx, task, now.AddDate(0, 0, -31))
+
+	// The task stays in the trash, so the next purge runs the hook again.
+	if purged, err := s.PurgeTrash(ctx, now.AddDate(0, 0, -30)); err != nil || purged != 0 {
+		t.Fatalf("PurgeTrash with a failing hook = %d, %v, want 0", purged, err)
+	}
+	if trash, _ := tasks.List(ctx, repository.TaskQuery{Trashed: true}); len(trash) != 1 {
+		t.Fatalf("trash after a failed purge = %+v, want the task", trash)
+	}
+	hookErr = nil
+	if purged, err := s.PurgeTrash(ctx, now.AddDate(0, 0, -30)); err != nil || purged != 1 {
+		t.Fatalf("PurgeTrash = %d, %v, want 1", purged, err)
+	}
+}
+
+func TestBlockedTaskRescheduled(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	queue := make(chan worker.Job, 10)
+	s := NewTaskService(tasks, repository.NewMemoryCommentRepository(), repository.NewMemoryTaskEventRepository(),
+		repository.NewMemoryDependencyRepository(tasks), nil, queue, Timeouts{}, SubtaskPolicy{MaxDepth: 3})
+
+	now := time.Now()
+	for _, id := range []string{"release", "build", "test", "done"} {
+		if err := s.CreateTask(ctx, &models.Task{ID: id, Title: id, Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}, "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, blocker := range []string{"build", "test"} {
+		if _, err := s.AddDependency(ctx, "release", blocker, "alice", "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.AddDependency(ctx, "done", "release", "alice", "user"); err != nil {
+		t.Fatal(err)
+	}
+	queued := func() []string {
+		var ids []string
+		for len(queue) > 0 {
+			ids = append(ids, (<-queue).TaskID)
+		}
+		return ids
+	}
+	// The worker dropped these jobs while the tasks were blocked.
+	queued()
+
+	// Completing a blocker or trashing one schedules the tasks it
+	// blocked again.
+	completed := models.StatusCompleted
+	if _, err := s.UpdateTask(ctx, "release", "alice", "user", TaskUpdate{Status: &completed}); err == nil {
+		t.Fatal("completed a blocked task")
+	}
+	if _, err := s.UpdateTask(ctx, "build", "alice", "user", TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	if got := queued(); !slices.Equal(got, []string{"release"}) {
+		t.Errorf("queued after completing a blocker = %v, want [release]", got)
+	}
+	if err := s.DeleteTask(ctx, "test", "alice", "user", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := queued(); !slices.Equal(got, []string{"release"}) {
+		t.Errorf("queued after trashing a blocker = %v, want [release]", got)
+	}
+
+	// The same goes for a blocker the worker completes.
+	from, err := tasks.AutoCompleteIfPending(ctx, "release")
+	if err != nil || from != models.StatusPending {
+		t.Fatalf("AutoCompleteIfPending = %q, %v", from, err)
+	}
+	if err := s.TaskAutoCompleted(ctx, "release", from); err != nil {
+		t.Fatal(err)
+	}
+	if got := queued(); !slices.Equal(got, []string{"done"}) {
+		t.Errorf("queued after auto-completing a blocker = %v, want [done]", got)
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	ctx := context.Background()
+	tasks := repository.NewMemoryTaskRepository()
+	events := repository.NewMemoryTaskEventRepository()
+	deps := repository.NewMemoryDependencyRepository(tasks)
+	queue := make(chan worker.Job, 10)
+	s := NewTaskService(tasks, repository.NewMemoryCommentRepository(), events, deps, nil, queue, Timeouts{}, SubtaskPolicy{
+		MaxDepth:           3,
+		AutoCompleteParent: true,
+		OnParentDelete:     ParentDeleteCascade,
+	})
+
+	now := time.Now()
+	parent := &models.Task{ID: "parent", Title: "Release", Status: models.StatusPending, UserID: "alice", CreatedAt: now, UpdatedAt: now}
+	if err := s.CreateTask(ctx, parent, "user"); err != nil {
+		t.Fatal(err)
+	}
+	child := &models.Task{ID: "child", Title: "Tag", Status: models.StatusPending, UserID: "alice", ParentID: &parent.ID, CreatedAt: now, UpdatedAt: now}
+	if err := s.CreateTask(ctx, child, "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	title, tags := "Release 1.0", []string{"ops"}
+	if _, err := s.UpdateTask(ctx, "parent", "alice", "user", TaskUpdate{Title: &title, Tags: &tags}); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the task unchanged records nothing.
+	if _, err := s.UpdateTask(ctx, "parent", "alice", "user", TaskUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	// An admin starts the subtask; the worker then completes it, and the
+	// parent follows.
+	started := models.StatusInProgress
+	if _, err := s.UpdateTask(ctx, "child", "root", "admin", TaskUpdate{Status: &started}); err != nil {
+		t.Fatal(err)
+	}
+	from, err := tasks.AutoCompleteIfPending(ctx, "child")
+	if err != nil || from != models.StatusInProgress {
+		t.Fatalf("AutoCompleteIfPending = %q, %v", from, err)
+	}
+	if err := s.TaskAutoCompleted(ctx, "child", from); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTask(ctx, "parent", "alice", "user", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreTask(ctx, "parent", "alice", "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		typ       models.TaskEventType
+		actor, by string
+		fields    string
+		firstOld  any
+		firstNew  any
+	}
+	check := func(taskID string, want []entry) {
+		t.Helper()
+		history, more, err := s.GetHistory(ctx, taskID, "alice", "user", Page{Limit: 100})
+		if err != nil || more {
+			t.Fatalf("GetHistory(%s) = more %v, %v", taskID, more, err)
+		}
+		if len(history) != len(want) {
+			t.Fatalf("history of %s has %d events, want %d: %+v", taskID, len(history), len(want), history)
+		}
+		for i, e := range history {
+			var fields []string
+			for _, c := range e.Changes {
+				fields = append(fields, c.Field)
+			}
+			got := entry{typ: e.Type, actor: e.Actor, by: e.ActorID, fields: strings.Join(fields, ",")}
+			w := want[i]
+			if len(e.Changes) > 0 {
+				got.firstOld, got.firstNew = e.Changes[0].Old, e.Changes[0].New
+			}
+			if got.typ != w.typ || got.actor != w.actor || got.by != w.by || got.fields != w.fields ||
+				fmt.Sprint(got.firstOld) != fmt.Sprint(w.firstOld) || fmt.Sprint(got.firstNew) != fmt.Sprint(w.firstNew) {
+				t.Errorf("%s event %d = %+v, want %+v", taskID, i, got, w)
+			}
+		}
+	}
+	check("parent", []entry{
+		{models.EventCreated, models.ActorUser, "alice", "title,status", nil, "Release"},
+		{models.EventUpdated, models.ActorUser, "alice", "title,tags", "Release", "Release 1.0"},
+		{models.EventStatusChanged, models.ActorSubtasks, "", "status", models.StatusPending, models.StatusCompleted},
+		{models.EventDeleted, models.ActorUser, "alice", "", nil, nil},
+		{models.EventRestored, models.ActorUser, "alice", "", nil, nil},
+	})
+	check("child", []entry{
+		{models.EventCreated, models.ActorUser, "alice", "title,status,parent_id", nil, "Tag"},
+		{models.EventStatusChanged, models.ActorAdmin, "root", "status", models.StatusPending, models.StatusInProgress},
+		{models.EventStatusChanged, models.ActorAutoComplete, "", "status", models.StatusInProgress, models.StatusCompleted},
+		{models.EventDeleted, models.ActorUser, "alice", "", nil, nil},
+		{models.EventRestored, models.ActorUser, "alice", "", nil, nil},
+	})
+
+	if _, _, err := s.GetHistory(ctx, "parent", "bob", "user", Page{}); !errors.Is(err, apperr.ErrForbidden) {
+		t.Errorf("another user's history: err = %v, want ErrForbidden", err)
+	}
+}
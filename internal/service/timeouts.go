@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bound the repository calls a service makes. A zero value means
+// the call is only limited by the caller's context.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/apperr"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
+	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+)
+
+// maxPurgedTasks caps the tasks one PurgeTrash call deletes; the rest
+// wait for the next call.
+const maxPurgedTasks = 1000
+
+// GetTrash returns one page of the trashed tasks the caller may see, most
+// recently deleted first, and whether more follow it.
+func (s *TaskService) GetTrash(ctx context.Context, userID, role string, page Page) (tasks []models.Task, more bool, err error) {
+	ctx, span := startSpan(ctx, "TaskService.GetTrash")
+	defer func() { endSpan(span, err) }()
+
+	if userID == "" {
+		return nil, false, apperr.ErrUnauthorized
+	}
+
+	q := repository.TaskQuery{Trashed: true, Offset: page.Offset}
+	if role != "admin" {
+		q.UserID = userID
+	}
+	// Fetch one extra row to learn whether another page exists.
+	if page.Limit > 0 {
+		q.Limit = page.Limit + 1
+	}
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	tasks, err = s.repo.List(ctx, q)
+	if err != nil {
+		return nil, false, err
+	}
+	if page.Limit > 0 && len(tasks) > page.Limit {
+		return tasks[:page.Limit], true, nil
+	}
+	return tasks, false, nil
+}
+
+// trashedSubtasks returns the direct subtasks of taskID that were trashed
+// at at, that is, together with it.
+func (s *TaskService) trashedSubtasks(ctx context.Context, taskID string, at time.Time) ([]models.Task, error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	trashed, err := s.repo.List(ctx, repository.TaskQuery{ParentID: taskID, Trashed: true})
+	if err != nil {
+		return nil, err
+	}
+	var together []models.Task
+	for _, t := range trashed {
+		if t.DeletedAt.Equal(at) {
+			together = append(together, t)
+		}
+	}
+	return together, nil
+}
+
+// RestoreTask takes a task the caller may modify out of the trash, along
+// with the subtasks that were deleted with it. A subtask cannot come back
+// while its parent is still in the trash.
+func (s *TaskService) RestoreTask(ctx context.Context, taskID, userID, role string) (task *models.Task, err error) {
+	ctx, span := startSpan(ctx, "TaskService.RestoreTask")
+	defer func() { endSpan(span, err) }()
+	ctx = asUser(ctx, userID, role)
+
+	readCtx, cancelRead := withTimeout(ctx, s.timeouts.Read)
+	defer cancelRead()
+
+	trashed, err := s.repo.List(readCtx, repository.TaskQuery{IDs: []string{taskID}, Trashed: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(trashed) == 0 {
+		return nil, fmt.Errorf("task %w in the trash", apperr.ErrNotFound)
+	}
+	task = &trashed[0]
+
+	// Authorization: user can only restore own task
+	if role != "admin" && task.UserID != userID {
+		return nil, apperr.ErrForbidden
+	}
+	if task.ParentID != nil {
+		_, err = s.repo.GetByID(readCtx, *task.ParentID)
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, fmt.Errorf("restore the parent task first: %w", apperr.ErrConflict)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Top down, so a failure part way never leaves a live subtask whose
+	// parent is still in the trash.
+	at := *task.DeletedAt
+	level := []models.Task{*task}
+	for len(level) > 0 {
+		var next []models.Task
+		for _, t := range level {
+			writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+			err = s.repo.Restore(writeCtx, t.ID)
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+			s.record(ctx, t.ID, models.EventRestored, nil)
+			children, err := s.trashedSubtasks(ctx, t.ID, at)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, children...)
+		}
+		level = next
+	}
+
+	task.DeletedAt = nil
+	s.rollUp(ctx, task)
+
+	readCtx, cancelRead = withTimeout(ctx, s.timeouts.Read)
+	defer cancelRead()
+	return s.repo.GetByID(readCtx, task.ID)
+}
+
+// PurgeTrash deletes the tasks trashed before before for good, with their
+// comments, history, dependencies and, through OnPurge, attachments, and
+// returns how many it deleted. A task that fails is logged and retried on
+// the next call.
+func (s *TaskService) PurgeTrash(ctx context.Context, before time.Time) (purged int, err error) {
+	ctx, span := startSpan(ctx, "TaskService.PurgeTrash")
+	defer func() { endSpan(span, err) }()
+
+	readCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	expired, err := s.repo.List(readCtx, repository.TaskQuery{Trashed: true, TrashedBefore: before, Limit: maxPurgedTasks})
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range expired {
+		if err := s.purgeOne(ctx, task.ID); err != nil {
+			slog.ErrorContext(ctx, "purging task failed", "task_id", task.ID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// OnPurge registers fn to run before a task is purged for good, for the
+// data other services keep on it, such as its attachments. If it fails the
+// task stays in the trash, so fn runs again on the next purge and must
+// cope with finding its own work partly done. Call it before the server
+// starts.
+func (s *TaskService) OnPurge(fn func(ctx context.Context, taskID string) error) {
+	s.onPurge = fn
+}
+
+// purgeOne runs the OnPurge hook, then deletes a task, its comments,
+// history and dependencies for good.
+func (s *TaskService) purgeOne(ctx context.Context, taskID string) error {
+	if s.onPurge != nil {
+		if err := s.onPurge(ctx, taskID); err != nil {
+			return fmt.Errorf("purge hook: %w", err)
+		}
+	}
+
+	writeCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.repo.Purge(writeCtx, taskID); err != nil {
+		return err
+	}
+	// Comments on a missing task can no longer be reached, so a failure
+	// here leaves only dead rows behind; the task is still gone.
+	if err := s.comments.DeleteByTask(writeCtx, taskID); err != nil {
+		slog.WarnContext(ctx, "deleting task comments failed", "task_id", taskID, "error", err)
+	}
+	if err := s.events.DeleteByTask(writeCtx, taskID); err != nil {
+		slog.WarnContext(ctx, "deleting task history failed", "task_id", taskID, "error", err)
+	}
+	s.deleteDependencies(ctx, taskID)
+	return nil
+}
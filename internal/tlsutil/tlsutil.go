@@ -0,0 +1,126 @@
+// Package tlsutil builds the server's TLS configuration: certificates that
+// are reloaded when their files change, the minimum protocol version and
+// client-certificate verification for mTLS.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/config"
+)
+
+// reloadInterval is how often, at most, the certificate files are checked
+// for changes. Checks happen during handshakes, so an idle server never
+// touches the filesystem.
+const reloadInterval = 10 * time.Second
+
+// ServerConfig returns the TLS config for cfg, or nil when TLS is off.
+func ServerConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	certs, err := NewCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tc := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+	if cfg.MinVersion == "1.3" {
+		tc.MinVersion = tls.VersionTLS13
+	}
+
+	switch cfg.ClientAuth {
+	case "optional":
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if tc.ClientAuth != tls.NoClientCert {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA file contains no PEM certificates")
+		}
+		tc.ClientCAs = pool
+	}
+	return tc, nil
+}
+
+// CertReloader serves a certificate/key pair and picks up new versions of
+// the files without a restart. A pair that fails to load is logged and the
+// previous one stays in use.
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate is the tls.Config.GetCertificate hook.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= reloadInterval {
+		r.checked = time.Now()
+		if mod, err := r.latestModTime(); err == nil && !mod.Equal(r.modTime) {
+			if err := r.load(); err != nil {
+				slog.Warn("TLS certificate reload failed, keeping current", "error", err)
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// load reads the pair. Callers other than the constructor hold r.mu.
+func (r *CertReloader) load() error {
+	mod, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	if r.cert != nil {
+		slog.Info("TLS certificate reloaded", "cert_file", r.certFile)
+	}
+	r.cert, r.modTime = &cert, mod
+	return nil
+}
+
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,214 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/config"
+	"github.com/CashInvoice-Golang-Assignment/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type keyPair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// issue creates a certificate for cn signed by parent, or self-signed
+// when parent is nil.
+func issue(t *testing.T, cn string, serial int64, parent *keyPair, isCA bool) *keyPair {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &keyPair{cert: cert, key: key, der: der}
+}
+
+// write stores kp as PEM files in dir and returns their paths.
+func (kp *keyPair) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(kp.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: kp.der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (kp *keyPair) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{kp.der}, PrivateKey: kp.key}
+}
+
+func TestMutualTLSIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	ca := issue(t, "test-ca", 1, nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := issue(t, "server", 2, ca, false).write(t, dir, "server")
+
+	tc, err := ServerConfig(config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.3",
+		ClientAuth:   "optional",
+		ClientCAFile: caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(middleware.Problems())
+	r.Use(middleware.ClientCert(map[string]middleware.ClientIdentity{
+		"billing-svc": {UserID: "svc-billing", Role: "admin"},
+	}))
+	r.GET("/whoami", middleware.JWTMiddleware("unused-secret"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id"), "role": c.GetString("role")})
+	})
+	// httptest's StartTLS would install its own certificate, which wins
+	// over GetCertificate, so serve on a TLS listener directly.
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: r}
+	go srv.Serve(ln)
+	defer srv.Close()
+	url := "https://" + ln.Addr().String() + "/whoami"
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      pool,
+			Certificates: certs,
+		}}}
+	}
+
+	resp, err := client(issue(t, "billing-svc", 3, ca, false).tlsCert()).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var who map[string]string
+	json.NewDecoder(resp.Body).Decode(&who)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || who["user_id"] != "svc-billing" || who["role"] != "admin" {
+		t.Errorf("mapped client cert: %d %v", resp.StatusCode, who)
+	}
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("TLS version = %x, want 1.3", resp.TLS.Version)
+	}
+
+	// Unmapped certificates and no certificate fall back to JWT auth.
+	for name, c := range map[string]*http.Client{
+		"unmapped": client(issue(t, "stranger", 4, ca, false).tlsCert()),
+		"none":     client(),
+	} {
+		resp, err := c.Get(url)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: got %d, want %d", name, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	// A certificate from another CA is rejected during the handshake. The
+	// client would not offer it unprompted, so force it.
+	forged := issue(t, "billing-svc", 6, issue(t, "other-ca", 5, nil, true), false).tlsCert()
+	c := client()
+	c.Transport.(*http.Transport).TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &forged, nil
+	}
+	if resp, err := c.Get(url); err == nil {
+		resp.Body.Close()
+		t.Errorf("certificate from an untrusted CA was accepted: %d", resp.StatusCode)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := issue(t, "first", 1, nil, false).write(t, dir, "server")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial := func() int64 {
+		t.Helper()
+		c, _ := r.GetCertificate(nil)
+		leaf, _ := x509.ParseCertificate(c.Certificate[0])
+		return leaf.SerialNumber.Int64()
+	}
+	if got := serial(); got != 1 {
+		t.Fatalf("serial = %d, want 1", got)
+	}
+
+	issue(t, "second", 2, nil, false).write(t, dir, "server")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+
+	// Within the check interval the old certificate is still served.
+	if got := serial(); got != 1 {
+		t.Errorf("serial before interval = %d, want 1", got)
+	}
+	r.checked = time.Time{}
+	if got := serial(); got != 2 {
+		t.Errorf("serial after rotation = %d, want 2", got)
+	}
+
+	// A broken pair is ignored and the current certificate kept.
+	os.WriteFile(keyFile, []byte("garbage"), 0o600)
+	evenLater := later.Add(time.Minute)
+	os.Chtimes(keyFile, evenLater, evenLater)
+	r.checked = time.Time{}
+	if got := serial(); got != 2 {
+		t.Errorf("serial after bad rotation = %d, want 2", got)
+	}
+}
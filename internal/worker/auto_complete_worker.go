@@ -2,69 +2,228 @@ package worker
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/CashInvoice-Golang-Assignment/internal/clock"
+	"github.com/CashInvoice-Golang-Assignment/internal/logging"
+	"github.com/CashInvoice-Golang-Assignment/internal/metrics"
+	"github.com/CashInvoice-Golang-Assignment/internal/models"
 	"github.com/CashInvoice-Golang-Assignment/internal/repository"
+	"github.com/CashInvoice-Golang-Assignment/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Job asks the worker to auto-complete a task. RequestID and Link tie the
+// worker's log lines and span back to the request that created the task.
+type Job struct {
+	TaskID     string
+	RequestID  string
+	EnqueuedAt time.Time
+	Link       trace.SpanContext
+}
+
 type AutoCompleteWorker struct {
 	repo  repository.TaskRepository
-	queue chan string
-	delay time.Duration
+	queue chan Job
 	wg    *sync.WaitGroup
+	clock clock.Clock
+
+	// delay is read per job so SetDelay applies to jobs picked up after it.
+	delay atomic.Int64
+
+	// timeout bounds each auto-complete update.
+	timeout time.Duration
+	metrics *metrics.Metrics
+
+	// onComplete, if set, runs after each task the worker completes.
+	onComplete func(ctx context.Context, taskID string, from models.TaskStatus) error
+
+	// started counts the goroutines in the pool, including those SetCount
+	// removed that are still finishing a job; alive counts those running.
+	started atomic.Int32
+	alive   atomic.Int32
+
+	// mu guards the running goroutines' stop channels, so the pool can be
+	// resized after Start.
+	mu     sync.Mutex
+	ctx    context.Context
+	stops  []chan struct{}
+	nextID int
 }
 
 // Constructor
 func NewAutoCompleteWorker(
 	repo repository.TaskRepository,
-	queue chan string,
+	queue chan Job,
 	delay time.Duration,
 	wg *sync.WaitGroup,
+	clk clock.Clock,
+	timeout time.Duration,
+	m *metrics.Metrics,
 ) *AutoCompleteWorker {
-	return &AutoCompleteWorker{
+	w := &AutoCompleteWorker{
 		repo:  repo,
 		queue: queue,
-		delay: delay,
 		wg:    wg,
+		clock: clk,
+
+		timeout: timeout,
+		metrics: m,
 	}
+	w.delay.Store(int64(delay))
+	return w
 }
 
 func (w *AutoCompleteWorker) Start(ctx context.Context, numWorkers int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ctx = ctx
 	for i := 0; i < numWorkers; i++ {
-		w.wg.Add(1)
-		go w.workerLoop(ctx, i)
+		w.spawnLocked()
 	}
 }
 
+// OnComplete registers fn to run after the worker completes a task, with
+// the status the task had before, for work that follows from it such as
+// recording the change or completing the task's parent. A failure is
+// logged; the task stays completed. Call it before Start.
+func (w *AutoCompleteWorker) OnComplete(fn func(ctx context.Context, taskID string, from models.TaskStatus) error) {
+	w.onComplete = fn
+}
+
+// SetDelay changes the auto-complete delay. Jobs already waiting keep the
+// delay they started with.
+func (w *AutoCompleteWorker) SetDelay(d time.Duration) {
+	w.delay.Store(int64(d))
+}
+
+// SetCount grows or shrinks the pool to n goroutines. Goroutines being
+// removed finish the job they hold first, so no queued job is dropped, and
+// count as started until they exit. It does nothing before Start.
+func (w *AutoCompleteWorker) SetCount(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx == nil || w.ctx.Err() != nil {
+		return
+	}
+	for len(w.stops) < n {
+		w.spawnLocked()
+	}
+	for len(w.stops) > n {
+		last := len(w.stops) - 1
+		close(w.stops[last])
+		w.stops = w.stops[:last]
+	}
+}
+
+// Count returns the number of goroutines the pool is sized to.
+func (w *AutoCompleteWorker) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.stops)
+}
+
+func (w *AutoCompleteWorker) spawnLocked() {
+	stop := make(chan struct{})
+	w.stops = append(w.stops, stop)
+	w.wg.Add(1)
+	w.started.Add(1)
+	go w.workerLoop(w.ctx, w.nextID, stop)
+	w.nextID++
+}
+
+// Health reports how many worker goroutines are running out of those
+// started.
+func (w *AutoCompleteWorker) Health() (alive, started int) {
+	return int(w.alive.Load()), int(w.started.Load())
+}
+
 // Each worker runs forever
-func (w *AutoCompleteWorker) workerLoop(ctx context.Context, id int) {
+func (w *AutoCompleteWorker) workerLoop(ctx context.Context, id int, stop <-chan struct{}) {
 	defer w.wg.Done()
-	log.Printf("Auto-complete worker %d started\n", id)
+	w.alive.Add(1)
+	resized := false
+	defer func() {
+		// A goroutine SetCount removed leaves the pool; one that exits for
+		// any other reason is missing from it.
+		if resized {
+			w.started.Add(-1)
+		}
+		w.alive.Add(-1)
+	}()
+	logger := slog.With("worker", id)
+	logger.Info("auto-complete worker started")
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Worker %d shutting down (context cancelled)\n", id)
+			logger.Info("worker shutting down", "reason", "context cancelled")
 			return
 
-		case taskID, _ := <-w.queue:
+		case <-stop:
+			logger.Info("worker shutting down", "reason", "pool resized")
+			resized = true
+			return
 
-			log.Printf("Worker %d received task %s\n", id, taskID)
+		case job, ok := <-w.queue:
+			if !ok {
+				logger.Info("worker shutting down", "reason", "queue closed")
+				return
+			}
+
+			jobCtx := logging.WithRequestID(ctx, job.RequestID)
+			if !job.EnqueuedAt.IsZero() {
+				w.metrics.ObserveSchedulingLag(time.Since(job.EnqueuedAt))
+			}
+			logger.InfoContext(jobCtx, "worker received task", "task_id", job.TaskID)
 
 			// Wait for delay or shutdown signal
 			select {
-			case <-time.After(w.delay):
-				if err := w.repo.AutoCompleteIfPending(taskID); err != nil {
-					log.Printf("Worker %d failed task %s: %v\n", id, taskID, err)
-				} else {
-					log.Printf("Worker %d completed task %s\n", id, taskID)
-				}
+			case <-w.clock.After(time.Duration(w.delay.Load())):
+				w.complete(jobCtx, logger, job)
 
 			case <-ctx.Done():
-				log.Printf("Worker %d cancelled while waiting\n", id)
+				logger.InfoContext(jobCtx, "worker cancelled while waiting", "task_id", job.TaskID)
 				return
 			}
 		}
 	}
 }
+
+func (w *AutoCompleteWorker) complete(ctx context.Context, logger *slog.Logger, job Job) {
+	// Each job is its own trace, linked to the request that created the
+	// task rather than parented to it: the request finished long ago.
+	ctx, span := tracing.Tracer().Start(ctx, "AutoCompleteWorker.Job",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.Link{SpanContext: job.Link}),
+		trace.WithAttributes(attribute.String("task.id", job.TaskID)),
+	)
+	defer span.End()
+
+	if w.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+
+	from, err := w.repo.AutoCompleteIfPending(ctx, job.TaskID)
+	if err != nil {
+		w.metrics.JobFailed()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.ErrorContext(ctx, "auto-complete failed", "task_id", job.TaskID, "error", err)
+		return
+	}
+	w.metrics.JobProcessed()
+	logger.InfoContext(ctx, "auto-complete processed", "task_id", job.TaskID)
+
+	if from != "" && w.onComplete != nil {
+		if err := w.onComplete(ctx, job.TaskID, from); err != nil {
+			logger.WarnContext(ctx, "auto-complete follow-up failed", "task_id", job.TaskID, "error", err)
+		}
+	}
+}
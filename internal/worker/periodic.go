@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/clock"
+)
+
+// Periodic runs a maintenance job, such as creating the occurrences of
+// recurring tasks that have come due or purging the trash, at a fixed
+// interval.
+type Periodic struct {
+	name     string
+	run      func(ctx context.Context, now time.Time) (int, error)
+	interval time.Duration
+	clock    clock.Clock
+	wg       *sync.WaitGroup
+}
+
+// NewPeriodic returns a job that calls run every interval. run reports how
+// many items it handled. The job wakes on a real ticker but takes the
+// current time from clk, so tests can move time on without waiting for it.
+func NewPeriodic(
+	name string,
+	run func(ctx context.Context, now time.Time) (int, error),
+	interval time.Duration,
+	clk clock.Clock,
+	wg *sync.WaitGroup,
+) *Periodic {
+	return &Periodic{name: name, run: run, interval: interval, clock: clk, wg: wg}
+}
+
+// Start runs the job until ctx is cancelled. The first run is immediate,
+// so work that came due while the server was down is done at startup.
+func (p *Periodic) Start(ctx context.Context) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		slog.Info("periodic job started", "job", p.name, "interval", p.interval)
+		for {
+			p.runOnce(ctx)
+			select {
+			case <-ctx.Done():
+				slog.Info("periodic job shutting down", "job", p.name, "reason", "context cancelled")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (p *Periodic) runOnce(ctx context.Context) {
+	n, err := p.run(ctx, p.clock.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "periodic job failed", "job", p.name, "error", err)
+		}
+		return
+	}
+	if n > 0 {
+		slog.InfoContext(ctx, "periodic job done", "job", p.name, "count", n)
+	}
+}
@@ -0,0 +1,185 @@
+// Package api defines the JSON request and response bodies of the HTTP
+// API, the resources they carry, such as Task, and its errors. The
+// handlers bind and render them, and pkg/client and taskctl send and
+// decode the same types. Binding tags are validated by the server only.
+package api
+
+import (
+	"time"
+)
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"alice@example.com"`
+	Password string `json:"password" binding:"required" example:"password123"`
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"alice@example.com"`
+	Password string `json:"password" binding:"required,min=6" minLength:"6" example:"password123"`
+}
+
+type RegisterAdminRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"admin@example.com"`
+	Password string `json:"password" binding:"required,min=8" minLength:"8" example:"admin-password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenResponse struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Role         string    `json:"role" enums:"user,admin"`
+}
+
+type CreateTaskRequest struct {
+	Title       string   `json:"title" binding:"required" example:"Write report"`
+	Description string   `json:"description,omitempty" example:"Q3 numbers"`
+	Tags        []string `json:"tags,omitempty" example:"billing,q3"`
+	// ParentID makes the task a subtask of another task.
+	ParentID *string `json:"parent_id,omitempty" example:"3f1c9a7e-2b4d-4e8a-9c61-5d2e8b7a4f10"`
+	// Recurrence makes the task the first occurrence of a recurring task.
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+}
+
+// Recurrence says how a task repeats.
+type Recurrence struct {
+	// RRule is an RFC 5545 rule: FREQ=DAILY, WEEKLY or MONTHLY, with
+	// optional INTERVAL, BYDAY and COUNT or UNTIL.
+	RRule string `json:"rrule" binding:"required" example:"FREQ=WEEKLY;BYDAY=MO,TH"`
+	// Timezone is the IANA zone the rule is evaluated in. It defaults to UTC,
+	// or to the series' zone when changing its rule.
+	Timezone string `json:"timezone,omitempty" example:"Europe/Berlin"`
+	// StartsAt is the earliest an occurrence may fall on, and sets their
+	// time of day. It defaults to now, or to the occurrence being updated.
+	StartsAt *time.Time `json:"starts_at,omitempty" example:"2025-01-06T09:00:00+01:00"`
+}
+
+// UpdateTaskRequest is a partial update; omitted fields keep their value.
+type UpdateTaskRequest struct {
+	Title       *string     `json:"title,omitempty" example:"Write final report"`
+	Description *string     `json:"description,omitempty"`
+	Status      *TaskStatus `json:"status,omitempty" binding:"omitempty,oneof=pending in_progress completed" enums:"pending,in_progress,completed"`
+	// Tags replaces every tag on the task; [] removes them all.
+	Tags *[]string `json:"tags,omitempty" example:"billing"`
+	// ParentID moves the task under another task; "" makes it a
+	// top-level task.
+	ParentID *string `json:"parent_id,omitempty"`
+	// Recurrence changes the rule of the task's series from this
+	// occurrence on; it needs scope=future.
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+}
+
+// BatchRequest is a list of task operations sent in one call.
+type BatchRequest struct {
+	// Mode is atomic (every operation is applied, or none is) or
+	// best_effort (each operation is applied or fails on its own).
+	Mode       string           `json:"mode" binding:"required,oneof=atomic best_effort" enums:"atomic,best_effort"`
+	Operations []BatchOperation `json:"operations" binding:"required" maxItems:"100"`
+}
+
+// BatchOperation is one operation of a batch. Which fields apply depends
+// on Op: create takes title, description, tags and parent_id; update
+// takes any of title, description, status, tags and parent_id; status
+// takes status alone; update, delete and status need id.
+type BatchOperation struct {
+	Op          string      `json:"op" enums:"create,update,delete,status" example:"create"`
+	ID          string      `json:"id,omitempty"`
+	Title       *string     `json:"title,omitempty" example:"Write report"`
+	Description *string     `json:"description,omitempty"`
+	Status      *TaskStatus `json:"status,omitempty" enums:"pending,in_progress,completed"`
+	Tags        *[]string   `json:"tags,omitempty" example:"billing"`
+	ParentID    *string     `json:"parent_id,omitempty"`
+	// IfMatch is checked like the If-Match header of PATCH and DELETE
+	// /tasks/{id}.
+	IfMatch string `json:"if_match,omitempty"`
+}
+
+// BatchResponse holds one result per operation, in request order.
+type BatchResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of one operation. Status is the HTTP status
+// the operation would have had on its own. An operation of an atomic
+// batch that was only dropped because another failed has status 424.
+type BatchResult struct {
+	Index  int      `json:"index"`
+	Op     string   `json:"op"`
+	Status int      `json:"status" example:"201"`
+	Task   *Task    `json:"task,omitempty"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+type TaskListResponse struct {
+	Count int    `json:"count"`
+	Tasks []Task `json:"tasks"`
+	// NextCursor fetches the following page; it is omitted on the last.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type TaskHistoryResponse struct {
+	Count  int         `json:"count"`
+	Events []TaskEvent `json:"events"`
+	// NextCursor fetches the following page; it is omitted on the last.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type TagListResponse struct {
+	Count int   `json:"count"`
+	Tags  []Tag `json:"tags"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required" example:"invoicing"`
+}
+
+type MergeTagRequest struct {
+	// Into is the tag that remains.
+	Into string `json:"into" binding:"required" example:"billing"`
+}
+
+// DependencyRequest marks a task as blocked by another.
+type DependencyRequest struct {
+	BlockerID string `json:"blocker_id" binding:"required" example:"3f1c9a7e-2b4d-4e8a-9c61-5d2e8b7a4f10"`
+}
+
+type DependencyListResponse struct {
+	// BlockedBy are the tasks that must be completed before this one can
+	// be started or completed.
+	BlockedBy []Task `json:"blocked_by"`
+	// Blocks are the tasks waiting on this one.
+	Blocks []Task `json:"blocks"`
+}
+
+type UserListResponse struct {
+	Count int    `json:"count"`
+	Users []User `json:"users"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// CommentRequest is the body for adding and editing a comment.
+type CommentRequest struct {
+	Body string `json:"body" binding:"required" example:"Numbers are in the shared folder"`
+}
+
+type CommentListResponse struct {
+	Count    int       `json:"count"`
+	Comments []Comment `json:"comments"`
+	// NextCursor fetches the following page; it is omitted on the last.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type AttachmentListResponse struct {
+	Count       int          `json:"count"`
+	Attachments []Attachment `json:"attachments"`
+	// NextCursor fetches the following page; it is omitted on the last.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,19 @@
+package api
+
+import "time"
+
+// Attachment is a file attached to a task. Only its metadata lives here;
+// the content is kept in blob storage. UserID is the task's owner, whose
+// quota the file counts against, and UploaderID who attached it. Checksum
+// is the hex SHA-256 of the content.
+type Attachment struct {
+	ID          string    `db:"id" json:"id"`
+	TaskID      string    `db:"task_id" json:"task_id"`
+	UserID      string    `db:"user_id" json:"user_id"`
+	UploaderID  string    `db:"uploader_id" json:"uploader_id"`
+	Name        string    `db:"name" json:"name"`
+	Size        int64     `db:"size" json:"size"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Checksum    string    `db:"checksum" json:"checksum"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package api
+
+import "time"
+
+// Comment is a note left on a task. Edited is set once the body has been
+// changed after posting.
+type Comment struct {
+	ID        string    `db:"id" json:"id"`
+	TaskID    string    `db:"task_id" json:"task_id"`
+	AuthorID  string    `db:"author_id" json:"author_id"`
+	Body      string    `db:"body" json:"body"`
+	Edited    bool      `db:"edited" json:"edited"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,11 @@
+package api
+
+import "time"
+
+// Dependency records that a task is blocked by another: TaskID cannot be
+// started or completed while BlockerID is open.
+type Dependency struct {
+	TaskID    string    `db:"task_id" json:"task_id"`
+	BlockerID string    `db:"blocker_id" json:"blocker_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
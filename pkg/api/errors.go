@@ -0,0 +1,70 @@
+package api
+
+import "errors"
+
+// The kinds of error the API reports, one per problem code. The server's
+// domain errors are these values, and pkg/client matches responses to
+// them, so both sides test them with errors.Is.
+var (
+	// ErrNotFound means the requested resource does not exist (or is not
+	// visible to the caller).
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden means the caller is authenticated but may not act on
+	// the resource.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthorized means the caller is not authenticated.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrConflict means the request clashes with existing state, such as
+	// a duplicate email.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the input is malformed. Its Problem lists the
+	// invalid fields.
+	ErrValidation = errors.New("validation failed")
+	// ErrPreconditionFailed means a conditional request, such as one with
+	// If-Match, was made against a version that is no longer current.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrFailedDependency means an operation was sound but not carried
+	// out, because another operation it was made together with failed.
+	ErrFailedDependency = errors.New("failed dependency")
+	// ErrTooLarge means an upload is bigger than the largest one accepted.
+	ErrTooLarge = errors.New("too large")
+	// ErrUnsupportedMediaType means an upload is of a type that is not
+	// accepted.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrQuotaExceeded means an upload would take its owner over their
+	// storage quota.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrRateLimited means the caller exceeded a rate limit.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// FieldError describes one invalid input field. Code is a stable,
+// machine-readable reason such as "required" or "max".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ProblemContentType is the media type of Problem bodies (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 body of every error response. Code is stable and
+// meant for programs; Title and Detail are for people.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Code + ": " + p.Detail
+	}
+	return p.Code
+}
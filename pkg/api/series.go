@@ -0,0 +1,24 @@
+package api
+
+import "time"
+
+// Series is a recurring task. It is the template each occurrence's task is
+// created from, and tracks the next occurrence still to be created.
+type Series struct {
+	ID          string   `db:"id" json:"id"`
+	UserID      string   `db:"user_id" json:"-"`
+	Title       string   `db:"title" json:"title"`
+	Description string   `db:"description" json:"description"`
+	Tags        []string `db:"tags" json:"tags"`
+	// RRule is an RFC 5545 recurrence rule, such as FREQ=WEEKLY;BYDAY=MO.
+	RRule string `db:"rrule" json:"rrule"`
+	// Timezone is the IANA zone the rule is evaluated in, so occurrences
+	// keep their wall-clock time across DST changes.
+	Timezone string    `db:"timezone" json:"timezone"`
+	StartsAt time.Time `db:"starts_at" json:"starts_at"`
+	// NextAt is the next occurrence that has no task yet. It is nil once
+	// the rule has run out or the series was ended.
+	NextAt    *time.Time `db:"next_at" json:"next_at,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package api
+
+import "time"
+
+// Tag is a label a user puts on their tasks. Names are unique per user.
+type Tag struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"-"`
+	Name      string    `db:"name" json:"name"`
+	TaskCount int       `db:"-" json:"task_count"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
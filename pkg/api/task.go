@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "pending"
+	StatusInProgress TaskStatus = "in_progress"
+	StatusCompleted  TaskStatus = "completed"
+)
+
+// Valid reports whether s is one of the known statuses.
+func (s TaskStatus) Valid() bool {
+	switch s {
+	case StatusPending, StatusInProgress, StatusCompleted:
+		return true
+	}
+	return false
+}
+
+type Task struct {
+	ID          string     `db:"id" json:"id"`
+	Title       string     `db:"title" json:"title"`
+	Description string     `db:"description" json:"description"`
+	Status      TaskStatus `db:"status" json:"status"`
+	UserID      string     `db:"user_id" json:"-"`
+	// ParentID is the task this one is a subtask of; nil for a top-level
+	// task. Subtasks always have their parent's owner.
+	ParentID *string `db:"parent_id" json:"parent_id,omitempty"`
+	// Progress is the percentage of the task's subtasks, at every level
+	// below it, that are completed. It is nil for a task without subtasks.
+	Progress *int `db:"-" json:"progress,omitempty"`
+	// SeriesID and OccursAt link an occurrence of a recurring task to its
+	// series; both are nil for a one-off task.
+	SeriesID *string    `db:"series_id" json:"series_id,omitempty"`
+	OccursAt *time.Time `db:"occurs_at" json:"occurs_at,omitempty"`
+	// Tags are the owner's tag names on the task, sorted. They are stored
+	// in the tags and task_tags tables.
+	Tags      []string  `db:"-" json:"tags"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// DeletedAt is when the task was moved to the trash; nil for a live
+	// task. Trashed tasks are only visible through the trash.
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	// Version starts at 1 and goes up with every write to the task.
+	Version int64 `db:"version" json:"version"`
+}
+
+// ETag is the strong entity tag of the task as the API returns it. It
+// covers Version and Progress, which changes with the task's subtasks
+// rather than the task itself.
+func (t *Task) ETag() string {
+	if t.Progress == nil {
+		return fmt.Sprintf(`"%d"`, t.Version)
+	}
+	return fmt.Sprintf(`"%d-%d"`, t.Version, *t.Progress)
+}
@@ -0,0 +1,45 @@
+package api
+
+import "time"
+
+type TaskEventType string
+
+const (
+	EventCreated       TaskEventType = "created"
+	EventUpdated       TaskEventType = "updated"
+	EventStatusChanged TaskEventType = "status_changed"
+	EventDeleted       TaskEventType = "deleted"
+	EventRestored      TaskEventType = "restored"
+)
+
+// Who made a change to a task. The system actors are background work: the
+// auto-complete worker, parents following their subtasks, and the
+// occurrences of recurring tasks.
+const (
+	ActorUser         = "user"
+	ActorAdmin        = "admin"
+	ActorAutoComplete = "system:auto-complete"
+	ActorSubtasks     = "system:subtasks"
+	ActorRecurrence   = "system:recurrence"
+)
+
+// FieldChange is one field of a task before and after an event. Old is
+// null for a created task.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// TaskEvent is one entry in a task's history. ActorID is the user behind
+// a user or admin change, and empty for a system one. IDs increase in the
+// order the events were recorded.
+type TaskEvent struct {
+	ID        int64         `db:"id" json:"id"`
+	TaskID    string        `db:"task_id" json:"task_id"`
+	Type      TaskEventType `db:"type" json:"type"`
+	Actor     string        `db:"actor" json:"actor"`
+	ActorID   string        `db:"actor_id" json:"actor_id,omitempty"`
+	Changes   []FieldChange `db:"changes" json:"changes,omitempty"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+}
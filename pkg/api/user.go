@@ -1,4 +1,4 @@
-package models
+package api
 
 type User struct {
 	ID       string `db:"id" json:"id"`
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+// AttachmentPage is one page of ListAttachments. NextCursor is empty on
+// the last one.
+type AttachmentPage = api.AttachmentListResponse
+
+// AttachmentContent is the content of a downloaded attachment, described
+// by the response headers. The caller closes it.
+type AttachmentContent struct {
+	io.ReadCloser
+	Name        string
+	ContentType string
+	// Size is -1 when the server did not send it.
+	Size int64
+	// Checksum is the hex SHA-256 of the content.
+	Checksum string
+}
+
+func attachmentsPath(taskID string) string {
+	return "/tasks/" + url.PathEscape(taskID) + "/attachments"
+}
+
+// ListAttachments returns one page of a task's attachments, oldest first.
+func (c *Client) ListAttachments(ctx context.Context, taskID string, opts ListOptions) (*AttachmentPage, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+
+	var page AttachmentPage
+	err := c.do(ctx, call{method: http.MethodGet, path: attachmentsPath(taskID), query: q, out: &page, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// UploadAttachment attaches the content of r to a task under name. The
+// content is read into memory first, so that the upload can be retried.
+func (c *Client) UploadAttachment(ctx context.Context, taskID, name string, r io.Reader) (*api.Attachment, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", name)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode request: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("client: read attachment: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("client: encode request: %w", err)
+	}
+
+	var attachment api.Attachment
+	err = c.do(ctx, call{
+		method:      http.MethodPost,
+		path:        attachmentsPath(taskID),
+		raw:         body.Bytes(),
+		contentType: w.FormDataContentType(),
+		out:         &attachment,
+		auth:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DownloadAttachment opens the content of one of a task's attachments.
+func (c *Client) DownloadAttachment(ctx context.Context, taskID, attachmentID string) (*AttachmentContent, error) {
+	resp, err := c.open(ctx, call{method: http.MethodGet, path: attachmentsPath(taskID) + "/" + url.PathEscape(attachmentID), auth: true})
+	if err != nil {
+		return nil, err
+	}
+
+	content := &AttachmentContent{
+		ReadCloser:  resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+		Checksum:    strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		content.Name = params["filename"]
+	}
+	return content, nil
+}
+
+func (c *Client) DeleteAttachment(ctx context.Context, taskID, attachmentID string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: attachmentsPath(taskID) + "/" + url.PathEscape(attachmentID), auth: true})
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+// Tokens are the credentials returned by Login and Refresh.
+type Tokens = api.TokenResponse
+
+// Login authenticates and keeps the returned tokens for later calls.
+func (c *Client) Login(ctx context.Context, email, password string) (Tokens, error) {
+	var t Tokens
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   api.LoginRequest{Email: email, Password: password},
+		out:    &t,
+	})
+	if err != nil {
+		return Tokens{}, err
+	}
+	c.setTokens(t)
+	return t, nil
+}
+
+// Register creates a regular user account.
+func (c *Client) Register(ctx context.Context, email, password string) error {
+	return c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/auth/register",
+		body:   api.RegisterRequest{Email: email, Password: password},
+	})
+}
+
+// RegisterAdmin creates an admin account. The client must be logged in as
+// an admin.
+func (c *Client) RegisterAdmin(ctx context.Context, email, password string) error {
+	return c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/auth/admin/register",
+		body:   api.RegisterAdminRequest{Email: email, Password: password},
+		auth:   true,
+	})
+}
+
+// Refresh exchanges the refresh token for new tokens. Calls do this on
+// their own when the access token is about to expire, so most callers
+// never need to.
+func (c *Client) Refresh(ctx context.Context) (Tokens, error) {
+	if err := c.refresh(ctx, ""); err != nil {
+		return Tokens{}, err
+	}
+	return c.Tokens(), nil
+}
+
+func (c *Client) refreshIfExpiring(ctx context.Context) error {
+	t := c.Tokens()
+	if t.RefreshToken == "" || t.ExpiresAt.IsZero() || time.Until(t.ExpiresAt) > refreshSkew {
+		return nil
+	}
+	return c.refresh(ctx, t.Token)
+}
+
+// refresh replaces the tokens. When stale is set and the access token has
+// already changed from it, another call refreshed in the meantime and
+// nothing is done.
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	current := c.Tokens()
+	if stale != "" && current.Token != stale {
+		return nil
+	}
+	if current.RefreshToken == "" {
+		return fmt.Errorf("client: no refresh token, log in first: %w", ErrUnauthorized)
+	}
+
+	var t Tokens
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/auth/refresh",
+		body:   api.RefreshRequest{RefreshToken: current.RefreshToken},
+		out:    &t,
+	})
+	if err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return fmt.Errorf("client: refresh token rejected, log in again: %w", err)
+		}
+		return err
+	}
+	c.setTokens(t)
+	if c.onRefresh != nil {
+		c.onRefresh(t)
+	}
+	return nil
+}
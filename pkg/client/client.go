@@ -0,0 +1,304 @@
+// Package client is the Go SDK for the task API.
+//
+//	c := client.New("https://tasks.example.com")
+//	if _, err := c.Login(ctx, "alice@example.com", "secret"); err != nil {
+//		return err
+//	}
+//	for task, err := range c.Tasks(ctx, client.ListOptions{}) {
+//		...
+//	}
+//
+// After Login the client refreshes its access token on its own. Failed
+// calls return an *Error, which matches the Err* sentinels with errors.Is.
+// Idempotent calls (GET, PUT, DELETE) are retried on network errors, 429
+// and 502-504.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 2
+	defaultBackoff    = 100 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+
+	// refreshSkew is how long before expiry an access token is replaced,
+	// so it does not lapse in flight.
+	refreshSkew = 30 * time.Second
+)
+
+// Client calls the task API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+
+	requestHooks  []func(*http.Request)
+	responseHooks []func(*http.Response)
+	onRefresh     func(Tokens)
+
+	mu     sync.Mutex
+	tokens Tokens
+	// refreshMu serialises refreshes so concurrent calls share one.
+	refreshMu sync.Mutex
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client. The default is a client
+// with a 30 second timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how many times an idempotent call is retried and the
+// backoff before the first retry, which doubles on each attempt. Zero
+// retries turns retrying off.
+func WithRetries(max int, backoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.backoff = max, backoff }
+}
+
+// WithTokens starts the client with tokens from an earlier Login, for
+// example ones restored from disk.
+func WithTokens(t Tokens) Option {
+	return func(c *Client) { c.tokens = t }
+}
+
+// WithRequestHook calls fn before every HTTP attempt, retries included. It
+// may add headers.
+func WithRequestHook(fn func(*http.Request)) Option {
+	return func(c *Client) { c.requestHooks = append(c.requestHooks, fn) }
+}
+
+// WithResponseHook calls fn with every HTTP response, before its body is
+// read. fn must not read or close the body.
+func WithResponseHook(fn func(*http.Response)) Option {
+	return func(c *Client) { c.responseHooks = append(c.responseHooks, fn) }
+}
+
+// WithTokenRefreshHook calls fn with the new tokens after every automatic
+// refresh, so callers can persist them.
+func WithTokenRefreshHook(fn func(Tokens)) Option {
+	return func(c *Client) { c.onRefresh = fn }
+}
+
+// New returns a client for the API at baseURL, such as
+// "https://tasks.example.com". It panics if baseURL is not a valid URL.
+func New(baseURL string, opts ...Option) *Client {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		panic(fmt.Sprintf("client: invalid base URL %q: %v", baseURL, err))
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokens returns the client's current tokens.
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+func (c *Client) setTokens(t Tokens) {
+	c.mu.Lock()
+	c.tokens = t
+	c.mu.Unlock()
+}
+
+// call is one API request. body, when non-nil, is sent as JSON; out, when
+// non-nil, receives the decoded JSON response.
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	// raw, when non-nil, is sent as is in place of body, as contentType.
+	raw         []byte
+	contentType string
+	out         any
+	// header is added to the request, after the client's own headers.
+	header http.Header
+	// auth sends the access token and refreshes it when needed.
+	auth bool
+}
+
+func (c *Client) do(ctx context.Context, cl call) error {
+	resp, err := c.open(ctx, cl)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+
+	if cl.out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(cl.out); err != nil {
+			return fmt.Errorf("client: decode %s %s response: %w", cl.method, cl.path, err)
+		}
+	}
+	return nil
+}
+
+// open performs cl and returns the response of a successful call with its
+// body unread; the caller closes it. Error responses are returned as an
+// *Error.
+func (c *Client) open(ctx context.Context, cl call) (*http.Response, error) {
+	body := cl.raw
+	if cl.body != nil {
+		var err error
+		if body, err = json.Marshal(cl.body); err != nil {
+			return nil, fmt.Errorf("client: encode request: %w", err)
+		}
+	}
+
+	if cl.auth {
+		if err := c.refreshIfExpiring(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.send(ctx, cl, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token may have been revoked or the clocks disagree; one refresh
+	// and retry is worth it.
+	if cl.auth && resp.StatusCode == http.StatusUnauthorized && c.Tokens().RefreshToken != "" {
+		stale := c.Tokens().Token
+		drain(resp)
+		if err := c.refresh(ctx, stale); err != nil {
+			return nil, err
+		}
+		if resp, err = c.send(ctx, cl, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		defer drain(resp)
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// send performs the request, retrying idempotent ones.
+func (c *Client) send(ctx context.Context, cl call, body []byte) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += cl.path
+	u.RawQuery = cl.query.Encode()
+
+	retries := 0
+	if idempotent(cl.method) {
+		retries = c.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, cl.method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("client: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			contentType := cl.contentType
+			if cl.body != nil || contentType == "" {
+				contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", contentType)
+		}
+		for name, values := range cl.header {
+			req.Header[name] = values
+		}
+		if cl.auth {
+			if tok := c.Tokens().Token; tok != "" {
+				req.Header.Set("Authorization", "Bearer "+tok)
+			}
+		}
+		for _, hook := range c.requestHooks {
+			hook(req)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			for _, hook := range c.responseHooks {
+				hook(resp)
+			}
+		}
+
+		if attempt >= retries || !retryable(resp, err) || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("client: %s %s: %w", cl.method, cl.path, err)
+			}
+			return resp, nil
+		}
+
+		wait := c.backoffFor(attempt, resp)
+		if resp != nil {
+			drain(resp)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("client: %s %s: %w", cl.method, cl.path, ctx.Err())
+		}
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoffFor honours Retry-After when the server sent one, and otherwise
+// backs off exponentially with jitter.
+func (c *Client) backoffFor(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, maxBackoff)
+		}
+	}
+	d := min(c.backoff<<attempt, maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+// drain reads the rest of the body so the connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
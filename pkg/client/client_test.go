@@ -0,0 +1,447 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CashInvoice-Golang-Assignment/internal/server/servertest"
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+	"github.com/CashInvoice-Golang-Assignment/pkg/client"
+)
+
+func loggedIn(t *testing.T, opts ...client.Option) (*client.Client, *servertest.TestServer) {
+	t.Helper()
+	ts := servertest.New(t)
+	c := client.New(ts.URL, opts...)
+	ctx := context.Background()
+	if err := c.Register(ctx, "alice@test.com", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Login(ctx, "alice@test.com", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	return c, ts
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, client.NewTask{Title: "write report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.GetTask(ctx, task.ID)
+	if err != nil || got.Title != "write report" || got.Status != api.StatusPending {
+		t.Fatalf("GetTask = %+v, %v", got, err)
+	}
+
+	updated, err := c.UpdateTask(ctx, task.ID, client.TaskUpdate{Status: client.Ptr(api.StatusCompleted)})
+	if err != nil || updated.Status != api.StatusCompleted || updated.Title != "write report" {
+		t.Fatalf("UpdateTask = %+v, %v", updated, err)
+	}
+
+	if err := c.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetTask(ctx, task.ID)
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GetTask after delete: %v", err)
+	}
+	if page, err := c.ListTrash(ctx, client.ListOptions{}); err != nil || len(page.Tasks) != 1 || page.Tasks[0].ID != task.ID {
+		t.Errorf("ListTrash = %+v, %v", page, err)
+	}
+	if restored, err := c.RestoreTask(ctx, task.ID); err != nil || restored.DeletedAt != nil {
+		t.Errorf("RestoreTask = %+v, %v", restored, err)
+	}
+	if _, err := c.RestoreTask(ctx, task.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("RestoreTask of a live task: %v", err)
+	}
+	if page, err := c.TaskHistory(ctx, task.ID, client.ListOptions{}); err != nil || page.Count != 4 ||
+		page.Events[1].Type != api.EventStatusChanged || page.Events[3].Type != api.EventRestored {
+		t.Errorf("TaskHistory = %+v, %v", page, err)
+	}
+
+	_, err = c.CreateTask(ctx, client.NewTask{})
+	if fields := client.FieldErrors(err); !errors.Is(err, client.ErrValidation) || len(fields) != 1 || fields[0].Field != "title" {
+		t.Errorf("CreateTask without title: %v, fields %+v", err, fields)
+	}
+}
+
+func TestConditionalUpdates(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, client.NewTask{Title: "draft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := task.ETag()
+	updated, err := c.UpdateTaskIfMatch(ctx, task.ID, stale, client.TaskUpdate{Title: client.Ptr("final")})
+	if err != nil || updated.Version != task.Version+1 {
+		t.Fatalf("UpdateTaskIfMatch = %+v, %v", updated, err)
+	}
+	if _, err := c.UpdateTaskIfMatch(ctx, task.ID, stale, client.TaskUpdate{Title: client.Ptr("lost")}); !errors.Is(err, client.ErrPreconditionFailed) {
+		t.Errorf("UpdateTaskIfMatch with a stale tag: %v", err)
+	}
+	if err := c.DeleteTaskIfMatch(ctx, task.ID, stale); !errors.Is(err, client.ErrPreconditionFailed) {
+		t.Errorf("DeleteTaskIfMatch with a stale tag: %v", err)
+	}
+	if err := c.DeleteTaskIfMatch(ctx, task.ID, updated.ETag()); err != nil {
+		t.Errorf("DeleteTaskIfMatch: %v", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, client.NewTask{Title: "draft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Batch(ctx, client.BatchAtomic, []client.BatchOperation{
+		{Op: "create", Title: client.Ptr("imported")},
+		{Op: "status", ID: "missing", Status: client.Ptr(api.StatusCompleted)},
+	})
+	if err != nil || resp.Failed != 2 ||
+		!errors.Is(client.BatchError(resp.Results[0]), client.ErrFailedDependency) ||
+		!errors.Is(client.BatchError(resp.Results[1]), client.ErrNotFound) {
+		t.Fatalf("atomic Batch = %+v, %v", resp, err)
+	}
+
+	resp, err = c.Batch(ctx, client.BatchBestEffort, []client.BatchOperation{
+		{Op: "create", Title: client.Ptr("imported")},
+		{Op: "status", ID: task.ID, Status: client.Ptr(api.StatusCompleted), IfMatch: task.ETag()},
+	})
+	if err != nil || resp.Succeeded != 2 || client.BatchError(resp.Results[1]) != nil ||
+		resp.Results[0].Task.Title != "imported" || resp.Results[1].Task.Status != api.StatusCompleted {
+		t.Fatalf("best-effort Batch = %+v, %v", resp, err)
+	}
+}
+
+func TestTasksIterator(t *testing.T) {
+	c, ts := loggedIn(t)
+	ctx := context.Background()
+
+	for i := range 5 {
+		if _, err := c.CreateTask(ctx, client.NewTask{Title: fmt.Sprintf("task %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var requests atomic.Int32
+	c2 := client.New(ts.URL, client.WithTokens(c.Tokens()), client.WithRequestHook(func(*http.Request) { requests.Add(1) }))
+	seen := map[string]bool{}
+	for task, err := range c2.Tasks(ctx, client.ListOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[task.ID] {
+			t.Errorf("task %s returned twice", task.ID)
+		}
+		seen[task.ID] = true
+	}
+	if len(seen) != 5 || requests.Load() != 3 {
+		t.Errorf("iterated %d tasks in %d requests, want 5 in 3", len(seen), requests.Load())
+	}
+
+	page, err := c.ListTasks(ctx, client.ListOptions{Limit: 5})
+	if err != nil || len(page.Tasks) != 5 || page.NextCursor != "" {
+		t.Errorf("single full page: %+v, %v", page, err)
+	}
+}
+
+func TestAutomaticRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	c, ts := loggedIn(t)
+	ctx := context.Background()
+
+	// An access token about to expire is replaced before the call.
+	tok := c.Tokens()
+	tok.ExpiresAt = time.Now()
+	c = client.New(ts.URL, client.WithTokens(tok), client.WithTokenRefreshHook(func(client.Tokens) { refreshes.Add(1) }))
+	if _, err := c.ListTasks(ctx, client.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes.Load() != 1 || c.Tokens().ExpiresAt.Before(time.Now()) {
+		t.Fatalf("expiring token: %d refreshes, expires %v", refreshes.Load(), c.Tokens().ExpiresAt)
+	}
+
+	// A rejected access token is refreshed once and the call retried.
+	tok = c.Tokens()
+	tok.Token = "not-a-jwt"
+	c = client.New(ts.URL, client.WithTokens(tok), client.WithTokenRefreshHook(func(client.Tokens) { refreshes.Add(1) }))
+	if _, err := c.ListTasks(ctx, client.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes.Load() != 2 {
+		t.Errorf("rejected token: %d refreshes, want 2", refreshes.Load())
+	}
+
+	// Without a usable refresh token the caller has to log in again.
+	c = client.New(ts.URL, client.WithTokens(client.Tokens{Token: "x", RefreshToken: "y"}))
+	if _, err := c.ListTasks(ctx, client.ListOptions{}); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("bad refresh token: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"t1","title":"ok","status":"pending"}`)
+	}))
+	defer srv.Close()
+
+	var responses atomic.Int32
+	c := client.New(srv.URL,
+		client.WithRetries(2, time.Millisecond),
+		client.WithResponseHook(func(*http.Response) { responses.Add(1) }),
+	)
+	task, err := c.GetTask(context.Background(), "t1")
+	if err != nil || task.Title != "ok" {
+		t.Fatalf("GetTask = %+v, %v", task, err)
+	}
+	if calls.Load() != 3 || responses.Load() != 3 {
+		t.Errorf("calls = %d, hooked responses = %d, want 3", calls.Load(), responses.Load())
+	}
+
+	// POST is not idempotent and is not retried.
+	calls.Store(0)
+	_, err = c.CreateTask(context.Background(), client.NewTask{Title: "x"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("CreateTask: %v after %d calls, want one 503", err, calls.Load())
+	}
+}
+
+func TestComments(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, client.NewTask{Title: "discuss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := c.AddComment(ctx, task.ID, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := c.EditComment(ctx, task.ID, comment.ID, "first, edited")
+	if err != nil || !edited.Edited {
+		t.Fatalf("EditComment = %+v, %v", edited, err)
+	}
+	page, err := c.ListComments(ctx, task.ID, client.ListOptions{})
+	if err != nil || page.Count != 1 || page.Comments[0].Body != "first, edited" {
+		t.Fatalf("ListComments = %+v, %v", page, err)
+	}
+	if err := c.DeleteComment(ctx, task.ID, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteComment(ctx, task.ID, comment.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("second delete: %v", err)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, client.NewTask{Title: "file the receipt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := c.UploadAttachment(ctx, task.ID, "receipt.txt", strings.NewReader("paid in full\n"))
+	if err != nil || attachment.Name != "receipt.txt" || attachment.Size != 13 || attachment.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("UploadAttachment = %+v, %v", attachment, err)
+	}
+	if _, err := c.UploadAttachment(ctx, task.ID, "tool.zip", strings.NewReader("PK\x03\x04 not really a zip")); !errors.Is(err, client.ErrUnsupportedMediaType) {
+		t.Errorf("upload of a zip: %v", err)
+	}
+
+	page, err := c.ListAttachments(ctx, task.ID, client.ListOptions{})
+	if err != nil || page.Count != 1 || page.Attachments[0].ID != attachment.ID {
+		t.Fatalf("ListAttachments = %+v, %v", page, err)
+	}
+
+	content, err := c.DownloadAttachment(ctx, task.ID, attachment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil || string(data) != "paid in full\n" {
+		t.Fatalf("downloaded %q, %v", data, err)
+	}
+	if content.Name != "receipt.txt" || content.Size != 13 || content.Checksum != attachment.Checksum {
+		t.Errorf("DownloadAttachment = %+v, want the upload's metadata %+v", content, attachment)
+	}
+
+	if err := c.DeleteAttachment(ctx, task.ID, attachment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DownloadAttachment(ctx, task.ID, attachment.ID); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("download after delete: %v", err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	for _, nt := range []client.NewTask{
+		{Title: "invoice", Tags: []string{"Billing", "q3"}},
+		{Title: "crash", Tags: []string{"bug", "billing"}},
+		{Title: "plain"},
+	} {
+		if _, err := c.CreateTask(ctx, nt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := c.ListTasks(ctx, client.ListOptions{Tags: []string{"billing", "bug"}, MatchAll: true})
+	if err != nil || page.Count != 1 || page.Tasks[0].Title != "crash" {
+		t.Fatalf("ListTasks match all = %+v, %v", page, err)
+	}
+	page, err = c.ListTasks(ctx, client.ListOptions{Tags: []string{"q3", "bug"}})
+	if err != nil || page.Count != 2 {
+		t.Fatalf("ListTasks match any = %+v, %v", page, err)
+	}
+
+	if _, err := c.RenameTag(ctx, "bug", "defect"); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := c.MergeTags(ctx, "q3", "billing")
+	if err != nil || merged.Name != "billing" || merged.TaskCount != 2 {
+		t.Fatalf("MergeTags = %+v, %v", merged, err)
+	}
+	if err := c.DeleteTag(ctx, "defect"); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := c.ListTags(ctx)
+	if err != nil || len(tags) != 1 || tags[0].Name != "billing" {
+		t.Fatalf("ListTags = %+v, %v", tags, err)
+	}
+	if err := c.DeleteTag(ctx, "defect"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("second delete: %v", err)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	parent, err := c.CreateTask(ctx, client.NewTask{Title: "release"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := c.CreateTask(ctx, client.NewTask{Title: "build", ParentID: &parent.ID})
+	if err != nil || child.ParentID == nil || *child.ParentID != parent.ID {
+		t.Fatalf("CreateTask subtask = %+v, %v", child, err)
+	}
+	if _, err := c.UpdateTask(ctx, parent.ID, client.TaskUpdate{Status: client.Ptr(api.StatusCompleted)}); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("completing a parent with an open subtask: %v", err)
+	}
+
+	page, err := c.ListSubtasks(ctx, parent.ID, client.ListOptions{})
+	if err != nil || page.Count != 1 || page.Tasks[0].ID != child.ID {
+		t.Fatalf("ListSubtasks = %+v, %v", page, err)
+	}
+	if _, err := c.UpdateTask(ctx, child.ID, client.TaskUpdate{Status: client.Ptr(api.StatusCompleted)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.GetTask(ctx, parent.ID)
+	if err != nil || got.Status != api.StatusCompleted || got.Progress == nil || *got.Progress != 100 {
+		t.Errorf("parent after its subtask completed: %+v, %v", got, err)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	design, err := c.CreateTask(ctx, client.NewTask{Title: "design"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	build, err := c.CreateTask(ctx, client.NewTask{Title: "build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dep, err := c.AddDependency(ctx, build.ID, design.ID); err != nil || dep.BlockerID != design.ID {
+		t.Fatalf("AddDependency = %+v, %v", dep, err)
+	}
+	if _, err := c.AddDependency(ctx, design.ID, build.ID); !errors.Is(err, client.ErrValidation) {
+		t.Errorf("AddDependency closing a loop: %v", err)
+	}
+	if _, err := c.UpdateTask(ctx, build.ID, client.TaskUpdate{Status: client.Ptr(api.StatusInProgress)}); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("starting a blocked task: %v", err)
+	}
+
+	deps, err := c.Dependencies(ctx, design.ID)
+	if err != nil || len(deps.BlockedBy) != 0 || len(deps.Blocks) != 1 || deps.Blocks[0].ID != build.ID {
+		t.Fatalf("Dependencies = %+v, %v", deps, err)
+	}
+	if err := c.RemoveDependency(ctx, build.ID, design.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UpdateTask(ctx, build.ID, client.TaskUpdate{Status: client.Ptr(api.StatusInProgress)}); err != nil {
+		t.Errorf("starting an unblocked task: %v", err)
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	c, _ := loggedIn(t)
+	ctx := context.Background()
+
+	first, err := c.CreateTask(ctx, client.NewTask{
+		Title:      "standup",
+		Recurrence: &client.Recurrence{RRule: "FREQ=DAILY;COUNT=2", Timezone: "Europe/Berlin"},
+	})
+	if err != nil || first.SeriesID == nil {
+		t.Fatalf("CreateTask = %+v, %v", first, err)
+	}
+	if _, err := c.CreateTask(ctx, client.NewTask{Title: "x", Recurrence: &client.Recurrence{RRule: "FREQ=YEARLY"}}); !errors.Is(err, client.ErrValidation) {
+		t.Errorf("CreateTask with an unsupported rule: %v", err)
+	}
+
+	if _, err := c.UpdateFutureOccurrences(ctx, first.ID, client.TaskUpdate{Title: client.Ptr("daily sync")}); err != nil {
+		t.Fatal(err)
+	}
+	series, err := c.GetSeries(ctx, *first.SeriesID)
+	if err != nil || series.Title != "daily sync" || series.RRule != "FREQ=DAILY;COUNT=2" {
+		t.Fatalf("GetSeries = %+v, %v", series, err)
+	}
+
+	// The second and last occurrence is created when the first completes.
+	if _, err := c.UpdateTask(ctx, first.ID, client.TaskUpdate{Status: client.Ptr(api.StatusCompleted)}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := c.ListTasks(ctx, client.ListOptions{SeriesID: series.ID})
+	if err != nil || len(page.Tasks) != 2 {
+		t.Fatalf("ListTasks by series = %+v, %v", page, err)
+	}
+	if series, err = c.GetSeries(ctx, series.ID); err != nil || series.NextAt != nil {
+		t.Errorf("series after its last occurrence = %+v, %v", series, err)
+	}
+
+	if _, err := c.EndSeries(ctx, series.ID); err != nil {
+		t.Errorf("EndSeries: %v", err)
+	}
+}
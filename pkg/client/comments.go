@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+// CommentPage is one page of ListComments. NextCursor is empty on the last
+// one.
+type CommentPage = api.CommentListResponse
+
+func commentsPath(taskID string) string {
+	return "/tasks/" + url.PathEscape(taskID) + "/comments"
+}
+
+// ListComments returns one page of a task's comments, oldest first.
+func (c *Client) ListComments(ctx context.Context, taskID string, opts ListOptions) (*CommentPage, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+
+	var page CommentPage
+	err := c.do(ctx, call{method: http.MethodGet, path: commentsPath(taskID), query: q, out: &page, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) AddComment(ctx context.Context, taskID, body string) (*api.Comment, error) {
+	var comment api.Comment
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   commentsPath(taskID),
+		body:   api.CommentRequest{Body: body},
+		out:    &comment,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// EditComment replaces the body of one of the caller's comments.
+func (c *Client) EditComment(ctx context.Context, taskID, commentID, body string) (*api.Comment, error) {
+	var comment api.Comment
+	err := c.do(ctx, call{
+		method: http.MethodPatch,
+		path:   commentsPath(taskID) + "/" + url.PathEscape(commentID),
+		body:   api.CommentRequest{Body: body},
+		out:    &comment,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *Client) DeleteComment(ctx context.Context, taskID, commentID string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: commentsPath(taskID) + "/" + url.PathEscape(commentID), auth: true})
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+func dependenciesPath(taskID string) string {
+	return "/tasks/" + url.PathEscape(taskID) + "/dependencies"
+}
+
+// Dependencies returns the tasks blocking a task and the tasks it blocks.
+func (c *Client) Dependencies(ctx context.Context, taskID string) (*api.DependencyListResponse, error) {
+	var resp api.DependencyListResponse
+	err := c.do(ctx, call{method: http.MethodGet, path: dependenciesPath(taskID), out: &resp, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AddDependency marks a task as blocked by blockerID. An edge that would
+// make tasks block each other in a loop fails with ErrValidation.
+func (c *Client) AddDependency(ctx context.Context, taskID, blockerID string) (*api.Dependency, error) {
+	var dep api.Dependency
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   dependenciesPath(taskID),
+		body:   api.DependencyRequest{BlockerID: blockerID},
+		out:    &dep,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dep, nil
+}
+
+// RemoveDependency stops a task from being blocked by blockerID.
+func (c *Client) RemoveDependency(ctx context.Context, taskID, blockerID string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: dependenciesPath(taskID) + "/" + url.PathEscape(blockerID), auth: true})
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+// The API's error kinds. An *Error matches the one for its code with
+// errors.Is, for example errors.Is(err, client.ErrNotFound).
+var (
+	ErrNotFound     = api.ErrNotFound
+	ErrForbidden    = api.ErrForbidden
+	ErrUnauthorized = api.ErrUnauthorized
+	ErrConflict     = api.ErrConflict
+	ErrValidation   = api.ErrValidation
+	ErrRateLimited  = api.ErrRateLimited
+	// ErrPreconditionFailed is returned by the IfMatch calls when the
+	// task has changed since its ETag was taken.
+	ErrPreconditionFailed = api.ErrPreconditionFailed
+	// ErrFailedDependency is the error of an operation of an atomic
+	// batch that was dropped because another one failed.
+	ErrFailedDependency = api.ErrFailedDependency
+	// The upload errors of UploadAttachment: the file is over the size
+	// limit, of a type that is not allowed, or over the owner's quota.
+	ErrTooLarge             = api.ErrTooLarge
+	ErrUnsupportedMediaType = api.ErrUnsupportedMediaType
+	ErrQuotaExceeded        = api.ErrQuotaExceeded
+)
+
+// Problem is the RFC 7807 body of an error response.
+type Problem = api.Problem
+
+// FieldError describes one invalid field of a rejected request.
+type FieldError = api.FieldError
+
+// Error is a response with a 4xx or 5xx status.
+type Error struct {
+	StatusCode int
+	Problem    Problem
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("task api: %d %s", e.StatusCode, e.Problem.Error())
+}
+
+// Unwrap returns the sentinel for the problem code, or nil for codes
+// without one (such as "internal").
+func (e *Error) Unwrap() error {
+	switch e.Problem.Code {
+	case "not_found":
+		return ErrNotFound
+	case "forbidden":
+		return ErrForbidden
+	case "unauthorized":
+		return ErrUnauthorized
+	case "conflict":
+		return ErrConflict
+	case "validation_failed":
+		return ErrValidation
+	case "rate_limited":
+		return ErrRateLimited
+	case "precondition_failed":
+		return ErrPreconditionFailed
+	case "failed_dependency":
+		return ErrFailedDependency
+	case "too_large":
+		return ErrTooLarge
+	case "unsupported_media_type":
+		return ErrUnsupportedMediaType
+	case "quota_exceeded":
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// decodeError reads an error response. Bodies that are not problem
+// documents, such as a proxy's HTML error page, still produce an *Error
+// with a code derived from the status.
+func decodeError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(body, &e.Problem) != nil || e.Problem.Code == "" {
+		e.Problem = Problem{
+			Status: resp.StatusCode,
+			Title:  http.StatusText(resp.StatusCode),
+			Code:   codeForStatus(resp.StatusCode),
+		}
+	}
+	return e
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "validation_failed"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusRequestEntityTooLarge:
+		return "too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusInsufficientStorage:
+		return "quota_exceeded"
+	case http.StatusGatewayTimeout:
+		return "timeout"
+	}
+	return "internal"
+}
+
+// FieldErrors returns the per-field details of a validation error, or nil.
+func FieldErrors(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Problem.Errors
+	}
+	return nil
+}
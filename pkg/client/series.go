@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+func seriesPath(seriesID string) string {
+	return "/series/" + url.PathEscape(seriesID)
+}
+
+// UpdateFutureOccurrences updates an occurrence of a recurring task with
+// scope=future: title, description and tags also change on its series and
+// its later open occurrences, and Recurrence may replace the rule.
+func (c *Client) UpdateFutureOccurrences(ctx context.Context, taskID string, u TaskUpdate) (*api.Task, error) {
+	var task api.Task
+	err := c.do(ctx, call{
+		method: http.MethodPatch,
+		path:   "/tasks/" + url.PathEscape(taskID),
+		query:  url.Values{"scope": {"future"}},
+		body:   u,
+		out:    &task,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetSeries returns the recurring task an occurrence's SeriesID refers to.
+func (c *Client) GetSeries(ctx context.Context, seriesID string) (*api.Series, error) {
+	var series api.Series
+	err := c.do(ctx, call{method: http.MethodGet, path: seriesPath(seriesID), out: &series, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// EndSeries stops a recurring task from creating further occurrences.
+func (c *Client) EndSeries(ctx context.Context, seriesID string) (*api.Series, error) {
+	var series api.Series
+	err := c.do(ctx, call{method: http.MethodDelete, path: seriesPath(seriesID), out: &series, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
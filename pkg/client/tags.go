@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+func tagPath(name string) string {
+	return "/tags/" + url.PathEscape(name)
+}
+
+// ListTags returns the caller's tags with the number of tasks carrying each.
+func (c *Client) ListTags(ctx context.Context) ([]api.Tag, error) {
+	var resp api.TagListResponse
+	err := c.do(ctx, call{method: http.MethodGet, path: "/tags", out: &resp, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Tags, nil
+}
+
+// RenameTag renames a tag on every task that carries it.
+func (c *Client) RenameTag(ctx context.Context, name, newName string) (*api.Tag, error) {
+	var tag api.Tag
+	err := c.do(ctx, call{
+		method: http.MethodPatch,
+		path:   tagPath(name),
+		body:   api.RenameTagRequest{Name: newName},
+		out:    &tag,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// MergeTags moves every task tagged from onto into and deletes from.
+func (c *Client) MergeTags(ctx context.Context, from, into string) (*api.Tag, error) {
+	var tag api.Tag
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   tagPath(from) + "/merge",
+		body:   api.MergeTagRequest{Into: into},
+		out:    &tag,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag removes a tag from every task and deletes it.
+func (c *Client) DeleteTag(ctx context.Context, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: tagPath(name), auth: true})
+}
@@ -0,0 +1,274 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+// NewTask is the input to CreateTask.
+type NewTask = api.CreateTaskRequest
+
+// TaskUpdate is the input to UpdateTask. Nil fields are left unchanged.
+type TaskUpdate = api.UpdateTaskRequest
+
+// Recurrence makes a NewTask repeat, or changes the rule in
+// UpdateFutureOccurrences.
+type Recurrence = api.Recurrence
+
+// Ptr returns a pointer to v, for filling in a TaskUpdate.
+func Ptr[T any](v T) *T { return &v }
+
+// ListOptions selects a page of tasks.
+type ListOptions struct {
+	// Limit is the page size. Zero uses the server default.
+	Limit int
+	// Cursor is TaskPage.NextCursor from the previous page. Empty starts
+	// from the newest task.
+	Cursor string
+	// Tags keeps only tasks carrying any of these tags, or all of them
+	// when MatchAll is set. Only ListTasks and Tasks use them.
+	Tags     []string
+	MatchAll bool
+	// SeriesID keeps only the occurrences of one recurring task. Only
+	// ListTasks and Tasks use it.
+	SeriesID string
+}
+
+// TaskPage is one page of ListTasks. NextCursor is empty on the last one.
+type TaskPage = api.TaskListResponse
+
+// BatchOperation is one operation of a Batch call.
+type BatchOperation = api.BatchOperation
+
+// BatchResponse holds a result per operation of a Batch call.
+type BatchResponse = api.BatchResponse
+
+// BatchResult is the outcome of one operation of a Batch call.
+type BatchResult = api.BatchResult
+
+// BatchError returns the error of a failed operation as an *Error, which
+// errors.Is matches like any other, or nil for one that succeeded.
+func BatchError(r BatchResult) error {
+	if r.Error == nil {
+		return nil
+	}
+	return &Error{StatusCode: r.Status, Problem: *r.Error}
+}
+
+// Batch modes: all operations or none, or each on its own.
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+// MaxBatchSize is the most operations one Batch call takes.
+const MaxBatchSize = 100
+
+// HistoryPage is one page of TaskHistory. NextCursor is empty on the last
+// one.
+type HistoryPage = api.TaskHistoryResponse
+
+func (c *Client) CreateTask(ctx context.Context, t NewTask) (*api.Task, error) {
+	var task api.Task
+	err := c.do(ctx, call{method: http.MethodPost, path: "/tasks", body: t, out: &task, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, id string) (*api.Task, error) {
+	var task api.Task
+	err := c.do(ctx, call{method: http.MethodGet, path: "/tasks/" + url.PathEscape(id), out: &task, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTask changes the fields set in u and returns the updated task.
+func (c *Client) UpdateTask(ctx context.Context, id string, u TaskUpdate) (*api.Task, error) {
+	var task api.Task
+	err := c.do(ctx, call{method: http.MethodPatch, path: "/tasks/" + url.PathEscape(id), body: u, out: &task, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// UpdateTaskIfMatch is UpdateTask that only applies u while the task
+// still has etag, as returned by api.Task.ETag. Otherwise it fails
+// with ErrPreconditionFailed.
+func (c *Client) UpdateTaskIfMatch(ctx context.Context, id, etag string, u TaskUpdate) (*api.Task, error) {
+	var task api.Task
+	err := c.do(ctx, call{
+		method: http.MethodPatch,
+		path:   "/tasks/" + url.PathEscape(id),
+		body:   u,
+		out:    &task,
+		header: http.Header{"If-Match": {etag}},
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: "/tasks/" + url.PathEscape(id), auth: true})
+}
+
+// DeleteTaskIfMatch is DeleteTask that only deletes the task while it
+// still has etag. Otherwise it fails with ErrPreconditionFailed.
+func (c *Client) DeleteTaskIfMatch(ctx context.Context, id, etag string) error {
+	return c.do(ctx, call{
+		method: http.MethodDelete,
+		path:   "/tasks/" + url.PathEscape(id),
+		header: http.Header{"If-Match": {etag}},
+		auth:   true,
+	})
+}
+
+// ListTasks returns one page of tasks, newest first. Admins see every
+// user's tasks.
+func (c *Client) ListTasks(ctx context.Context, opts ListOptions) (*TaskPage, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+	for _, tag := range opts.Tags {
+		q.Add("tag", tag)
+	}
+	if opts.MatchAll {
+		q.Set("match", "all")
+	}
+	if opts.SeriesID != "" {
+		q.Set("series_id", opts.SeriesID)
+	}
+
+	var page TaskPage
+	err := c.do(ctx, call{method: http.MethodGet, path: "/tasks", query: q, out: &page, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// ListTrash returns one page of deleted tasks, most recently deleted
+// first. Only Limit and Cursor of opts are used.
+func (c *Client) ListTrash(ctx context.Context, opts ListOptions) (*TaskPage, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+
+	var page TaskPage
+	err := c.do(ctx, call{method: http.MethodGet, path: "/tasks/trash", query: q, out: &page, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// RestoreTask takes a deleted task, and the subtasks deleted with it, out
+// of the trash.
+func (c *Client) RestoreTask(ctx context.Context, id string) (*api.Task, error) {
+	var task api.Task
+	err := c.do(ctx, call{method: http.MethodPost, path: "/tasks/" + url.PathEscape(id) + "/restore", out: &task, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListSubtasks returns one page of a task's direct subtasks, newest first.
+// opts.Tags and opts.MatchAll are ignored.
+func (c *Client) ListSubtasks(ctx context.Context, taskID string, opts ListOptions) (*TaskPage, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+
+	var page TaskPage
+	err := c.do(ctx, call{method: http.MethodGet, path: "/tasks/" + url.PathEscape(taskID) + "/subtasks", query: q, out: &page, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Batch runs up to MaxBatchSize operations in one call, in mode
+// BatchAtomic or BatchBestEffort. Failed operations do not make it return
+// an error; check the result of each. An atomic batch whose transaction
+// fails returns an error instead.
+func (c *Client) Batch(ctx context.Context, mode string, ops []BatchOperation) (*BatchResponse, error) {
+	var resp BatchResponse
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/tasks/batch",
+		body:   api.BatchRequest{Mode: mode, Operations: ops},
+		out:    &resp,
+		auth:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TaskHistory returns one page of the changes made to a task, oldest
+// first. Only Limit and Cursor of opts are used.
+func (c *Client) TaskHistory(ctx context.Context, taskID string, opts ListOptions) (*HistoryPage, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+
+	var page HistoryPage
+	err := c.do(ctx, call{method: http.MethodGet, path: "/tasks/" + url.PathEscape(taskID) + "/history", query: q, out: &page, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Tasks iterates over every task, fetching pages of opts.Limit as needed.
+// Iteration stops after the first error, which is yielded.
+func (c *Client) Tasks(ctx context.Context, opts ListOptions) iter.Seq2[api.Task, error] {
+	return func(yield func(api.Task, error) bool) {
+		for {
+			page, err := c.ListTasks(ctx, opts)
+			if err != nil {
+				yield(api.Task{}, err)
+				return
+			}
+			for _, t := range page.Tasks {
+				if !yield(t, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
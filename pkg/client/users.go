@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/CashInvoice-Golang-Assignment/pkg/api"
+)
+
+// ListUsers returns every user account. It requires an admin login.
+func (c *Client) ListUsers(ctx context.Context) ([]api.User, error) {
+	var resp api.UserListResponse
+	err := c.do(ctx, call{method: http.MethodGet, path: "/auth/admin/users", out: &resp, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Users, nil
+}
+
+// DeleteUser removes a user account. It requires an admin login.
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: "/auth/admin/users/" + url.PathEscape(id), auth: true})
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// Migration is a single versioned schema change. Versions are applied in
+// ascending order and recorded in schema_migrations so each one runs once.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+}
+
+func applyMigrations(db *sql.DB, createTable string, migrations []Migration) error {
+	if _, err := db.Exec(createTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	applied := map[int]bool{}
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[v] = true
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if _, err := db.Exec(m.Up); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if _, err := db.Exec(
+			`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`,
+			m.Version, m.Name,
+		); err != nil {
+			return fmt.Errorf("record migration %d: %w", m.Version, err)
+		}
+		slog.Info("applied migration", "version", m.Version, "name", m.Name)
+	}
+	return nil
+}
+
+// PendingMigrations reports how many of driver's migrations have not been
+// applied to db yet.
+func PendingMigrations(ctx context.Context, db *sql.DB, driver string) (int, error) {
+	var migrations []Migration
+	switch driver {
+	case "mysql":
+		migrations = mysqlMigrations
+	case "sqlite":
+		migrations = sqliteMigrations
+	default:
+		return 0, fmt.Errorf("unknown driver %q", driver)
+	}
+
+	var applied int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&applied)
+	if err != nil {
+		return 0, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	if pending := len(migrations) - applied; pending > 0 {
+		return pending, nil
+	}
+	return 0, nil
+}
@@ -3,36 +3,47 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/CashInvoice-Golang-Assignment/internal/config"
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
 )
 
-func Connect(cfg *config.Config) *sql.DB {
+func Connect(cfg config.DBConfig) *sql.DB {
 
-	host := cfg.DBHost
-	port := cfg.DBPort
-	user := cfg.DBUser
-	pass := cfg.DBPass
-	name := cfg.DBName
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, pass, host, port, name)
+	host := cfg.Host
+	port := cfg.Port
+	user := cfg.User
+	pass := cfg.Password
+	name := cfg.Name
+	// clientFoundRows makes RowsAffected count matched rows, so an UPDATE
+	// that changes nothing is not mistaken for a missing row.
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?clientFoundRows=true", user, pass, host, port, name)
 
-	log.Println("Connecting to database...", dsn)
+	slog.Info("connecting to database", "driver", "mysql", "host", host, "port", port, "database", name)
 	var db *sql.DB
 	var err error
 
-	db, err = sql.Open("mysql", dsn)
-	if err == nil && db.Ping() == nil {
-		return db
+	db, err = otelsql.Open("mysql", dsn, traceOptions(semconv.DBSystemNameMySQL)...)
+	if err == nil {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		if err = db.Ping(); err == nil {
+			return db
+		}
 	}
-	log.Println(err)
+	slog.Error("could not connect to MySQL", "error", err)
 	panic("Could not connect to MySQL")
 }
 
-func RunMigrations(db *sql.DB) {
-	queries := []string{
-		`
+var mysqlMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: `
         CREATE TABLE IF NOT EXISTS users (
             id VARCHAR(36) PRIMARY KEY,
             email VARCHAR(255) UNIQUE,
@@ -40,7 +51,11 @@ func RunMigrations(db *sql.DB) {
             role VARCHAR(20)
         );
         `,
-		`
+	},
+	{
+		Version: 2,
+		Name:    "create_tasks",
+		Up: `
         CREATE TABLE IF NOT EXISTS tasks (
             id VARCHAR(36) PRIMARY KEY,
             title VARCHAR(255) NOT NULL,
@@ -51,11 +66,210 @@ func RunMigrations(db *sql.DB) {
             updated_at TIMESTAMP NOT NULL
         );
         `,
-	}
+	},
+	{
+		Version: 3,
+		Name:    "index_tasks_user_created",
+		Up:      `CREATE INDEX idx_tasks_user_created ON tasks (user_id, created_at);`,
+	},
+	{
+		Version: 4,
+		Name:    "create_task_comments",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_comments (
+            id VARCHAR(36) PRIMARY KEY,
+            task_id VARCHAR(36) NOT NULL,
+            author_id VARCHAR(36) NOT NULL,
+            body TEXT NOT NULL,
+            edited BOOLEAN NOT NULL DEFAULT 0,
+            created_at TIMESTAMP NOT NULL,
+            updated_at TIMESTAMP NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 5,
+		Name:    "index_task_comments_task_created",
+		Up:      `CREATE INDEX idx_task_comments_task_created ON task_comments (task_id, created_at);`,
+	},
+	{
+		Version: 6,
+		Name:    "create_tags",
+		Up: `
+        CREATE TABLE IF NOT EXISTS tags (
+            id VARCHAR(36) PRIMARY KEY,
+            user_id VARCHAR(36) NOT NULL,
+            name VARCHAR(50) NOT NULL,
+            created_at TIMESTAMP NOT NULL,
+            UNIQUE (user_id, name)
+        );
+        `,
+	},
+	{
+		Version: 7,
+		Name:    "create_task_tags",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_tags (
+            task_id VARCHAR(36) NOT NULL,
+            tag_id VARCHAR(36) NOT NULL,
+            PRIMARY KEY (task_id, tag_id)
+        );
+        `,
+	},
+	{
+		Version: 8,
+		Name:    "index_task_tags_tag",
+		Up:      `CREATE INDEX idx_task_tags_tag ON task_tags (tag_id);`,
+	},
+	{
+		Version: 9,
+		Name:    "add_tasks_parent_id",
+		Up:      `ALTER TABLE tasks ADD COLUMN parent_id VARCHAR(36) NULL;`,
+	},
+	{
+		Version: 10,
+		Name:    "index_tasks_parent",
+		Up:      `CREATE INDEX idx_tasks_parent ON tasks (parent_id);`,
+	},
+	{
+		Version: 11,
+		Name:    "create_task_dependencies",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_dependencies (
+            task_id VARCHAR(36) NOT NULL,
+            blocker_id VARCHAR(36) NOT NULL,
+            created_at TIMESTAMP NOT NULL,
+            PRIMARY KEY (task_id, blocker_id)
+        );
+        `,
+	},
+	{
+		Version: 12,
+		Name:    "index_task_dependencies_blocker",
+		Up:      `CREATE INDEX idx_task_dependencies_blocker ON task_dependencies (blocker_id);`,
+	},
+	{
+		Version: 13,
+		Name:    "create_task_series",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_series (
+            id VARCHAR(36) PRIMARY KEY,
+            user_id VARCHAR(36) NOT NULL,
+            title VARCHAR(255) NOT NULL,
+            description TEXT,
+            tags TEXT NOT NULL,
+            rrule VARCHAR(255) NOT NULL,
+            timezone VARCHAR(64) NOT NULL,
+            starts_at DATETIME NOT NULL,
+            next_at DATETIME NULL,
+            created_at TIMESTAMP NOT NULL,
+            updated_at TIMESTAMP NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 14,
+		Name:    "index_task_series_next_at",
+		Up:      `CREATE INDEX idx_task_series_next_at ON task_series (next_at);`,
+	},
+	{
+		Version: 15,
+		Name:    "add_tasks_series_id",
+		Up:      `ALTER TABLE tasks ADD COLUMN series_id VARCHAR(36) NULL;`,
+	},
+	{
+		Version: 16,
+		Name:    "add_tasks_occurs_at",
+		Up:      `ALTER TABLE tasks ADD COLUMN occurs_at DATETIME NULL;`,
+	},
+	{
+		Version: 17,
+		Name:    "index_tasks_series",
+		Up:      `CREATE INDEX idx_tasks_series ON tasks (series_id, occurs_at);`,
+	},
+	{
+		Version: 18,
+		Name:    "add_tasks_deleted_at",
+		Up:      `ALTER TABLE tasks ADD COLUMN deleted_at DATETIME NULL;`,
+	},
+	{
+		Version: 19,
+		Name:    "index_tasks_deleted_at",
+		Up:      `CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at);`,
+	},
+	{
+		Version: 20,
+		Name:    "create_task_events",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_events (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            task_id VARCHAR(36) NOT NULL,
+            type VARCHAR(20) NOT NULL,
+            actor VARCHAR(30) NOT NULL,
+            actor_id VARCHAR(36) NOT NULL,
+            changes TEXT NOT NULL,
+            created_at TIMESTAMP NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 21,
+		Name:    "index_task_events_task",
+		Up:      `CREATE INDEX idx_task_events_task ON task_events (task_id, id);`,
+	},
+	{
+		Version: 22,
+		Name:    "add_tasks_version",
+		Up:      `ALTER TABLE tasks ADD COLUMN version BIGINT NOT NULL DEFAULT 1;`,
+	},
+	{
+		Version: 23,
+		Name:    "create_task_attachments",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_attachments (
+            id VARCHAR(36) PRIMARY KEY,
+            task_id VARCHAR(36) NOT NULL,
+            user_id VARCHAR(36) NOT NULL,
+            uploader_id VARCHAR(36) NOT NULL,
+            name VARCHAR(255) NOT NULL,
+            size BIGINT NOT NULL,
+            content_type VARCHAR(100) NOT NULL,
+            checksum CHAR(64) NOT NULL,
+            created_at TIMESTAMP NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 24,
+		Name:    "index_task_attachments_task_created",
+		Up:      `CREATE INDEX idx_task_attachments_task_created ON task_attachments (task_id, created_at);`,
+	},
+	{
+		Version: 25,
+		Name:    "index_task_attachments_user",
+		Up:      `CREATE INDEX idx_task_attachments_user ON task_attachments (user_id);`,
+	},
+	{
+		Version: 26,
+		Name:    "unique_tasks_series_occurrence",
+		Up:      `CREATE UNIQUE INDEX idx_tasks_series_occurrence ON tasks (series_id, occurs_at);`,
+	},
+	{
+		Version: 27,
+		Name:    "drop_index_tasks_series",
+		Up:      `DROP INDEX idx_tasks_series ON tasks;`,
+	},
+}
 
-	for _, q := range queries {
-		if _, err := db.Exec(q); err != nil {
-			log.Println("Migration error:", err)
-		}
+func RunMigrations(db *sql.DB) {
+	err := applyMigrations(db, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INT PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        );
+        `, mysqlMigrations)
+	if err != nil {
+		slog.Error("migration error", "error", err)
 	}
 }
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"database/sql"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	_ "modernc.org/sqlite"
+)
+
+// ConnectSQLite opens (creating if needed) the SQLite database at path.
+// SQLite allows a single writer, so the pool is capped at one connection
+// to avoid SQLITE_BUSY errors under concurrent requests and the worker.
+func ConnectSQLite(path string) *sql.DB {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			slog.Error("could not create SQLite directory", "error", err)
+			panic("Could not create SQLite directory")
+		}
+	}
+
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+	slog.Info("opening database", "driver", "sqlite", "path", path)
+	db, err := otelsql.Open("sqlite", dsn, traceOptions(semconv.DBSystemNameSQLite)...)
+	if err == nil {
+		db.SetMaxOpenConns(1)
+		if err = db.Ping(); err == nil {
+			return db
+		}
+	}
+	slog.Error("could not open SQLite database", "error", err)
+	panic("Could not open SQLite database")
+}
+
+// Timestamps are stored as TEXT in the same layout MySQL returns them in, so
+// repositories can share the parsing logic.
+var sqliteMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: `
+        CREATE TABLE IF NOT EXISTS users (
+            id TEXT PRIMARY KEY,
+            email TEXT UNIQUE,
+            password TEXT,
+            role TEXT
+        );
+        `,
+	},
+	{
+		Version: 2,
+		Name:    "create_tasks",
+		Up: `
+        CREATE TABLE IF NOT EXISTS tasks (
+            id TEXT PRIMARY KEY,
+            title TEXT NOT NULL,
+            description TEXT,
+            status TEXT NOT NULL,
+            user_id TEXT NOT NULL,
+            created_at TEXT NOT NULL,
+            updated_at TEXT NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 3,
+		Name:    "index_tasks_user_created",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_tasks_user_created ON tasks (user_id, created_at);`,
+	},
+	{
+		Version: 4,
+		Name:    "create_task_comments",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_comments (
+            id TEXT PRIMARY KEY,
+            task_id TEXT NOT NULL,
+            author_id TEXT NOT NULL,
+            body TEXT NOT NULL,
+            edited INTEGER NOT NULL DEFAULT 0,
+            created_at TEXT NOT NULL,
+            updated_at TEXT NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 5,
+		Name:    "index_task_comments_task_created",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_comments_task_created ON task_comments (task_id, created_at);`,
+	},
+	{
+		Version: 6,
+		Name:    "create_tags",
+		Up: `
+        CREATE TABLE IF NOT EXISTS tags (
+            id TEXT PRIMARY KEY,
+            user_id TEXT NOT NULL,
+            name TEXT NOT NULL,
+            created_at TEXT NOT NULL,
+            UNIQUE (user_id, name)
+        );
+        `,
+	},
+	{
+		Version: 7,
+		Name:    "create_task_tags",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_tags (
+            task_id TEXT NOT NULL,
+            tag_id TEXT NOT NULL,
+            PRIMARY KEY (task_id, tag_id)
+        );
+        `,
+	},
+	{
+		Version: 8,
+		Name:    "index_task_tags_tag",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_tags_tag ON task_tags (tag_id);`,
+	},
+	{
+		Version: 9,
+		Name:    "add_tasks_parent_id",
+		Up:      `ALTER TABLE tasks ADD COLUMN parent_id TEXT;`,
+	},
+	{
+		Version: 10,
+		Name:    "index_tasks_parent",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks (parent_id);`,
+	},
+	{
+		Version: 11,
+		Name:    "create_task_dependencies",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_dependencies (
+            task_id TEXT NOT NULL,
+            blocker_id TEXT NOT NULL,
+            created_at TEXT NOT NULL,
+            PRIMARY KEY (task_id, blocker_id)
+        );
+        `,
+	},
+	{
+		Version: 12,
+		Name:    "index_task_dependencies_blocker",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocker ON task_dependencies (blocker_id);`,
+	},
+	{
+		Version: 13,
+		Name:    "create_task_series",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_series (
+            id TEXT PRIMARY KEY,
+            user_id TEXT NOT NULL,
+            title TEXT NOT NULL,
+            description TEXT,
+            tags TEXT NOT NULL,
+            rrule TEXT NOT NULL,
+            timezone TEXT NOT NULL,
+            starts_at TEXT NOT NULL,
+            next_at TEXT,
+            created_at TEXT NOT NULL,
+            updated_at TEXT NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 14,
+		Name:    "index_task_series_next_at",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_series_next_at ON task_series (next_at);`,
+	},
+	{
+		Version: 15,
+		Name:    "add_tasks_series_id",
+		Up:      `ALTER TABLE tasks ADD COLUMN series_id TEXT;`,
+	},
+	{
+		Version: 16,
+		Name:    "add_tasks_occurs_at",
+		Up:      `ALTER TABLE tasks ADD COLUMN occurs_at TEXT;`,
+	},
+	{
+		Version: 17,
+		Name:    "index_tasks_series",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_tasks_series ON tasks (series_id, occurs_at);`,
+	},
+	{
+		Version: 18,
+		Name:    "add_tasks_deleted_at",
+		Up:      `ALTER TABLE tasks ADD COLUMN deleted_at TEXT;`,
+	},
+	{
+		Version: 19,
+		Name:    "index_tasks_deleted_at",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks (deleted_at);`,
+	},
+	{
+		Version: 20,
+		Name:    "create_task_events",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_events (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            task_id TEXT NOT NULL,
+            type TEXT NOT NULL,
+            actor TEXT NOT NULL,
+            actor_id TEXT NOT NULL,
+            changes TEXT NOT NULL,
+            created_at TEXT NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 21,
+		Name:    "index_task_events_task",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_events_task ON task_events (task_id, id);`,
+	},
+	{
+		Version: 22,
+		Name:    "add_tasks_version",
+		Up:      `ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+	},
+	{
+		Version: 23,
+		Name:    "create_task_attachments",
+		Up: `
+        CREATE TABLE IF NOT EXISTS task_attachments (
+            id TEXT PRIMARY KEY,
+            task_id TEXT NOT NULL,
+            user_id TEXT NOT NULL,
+            uploader_id TEXT NOT NULL,
+            name TEXT NOT NULL,
+            size INTEGER NOT NULL,
+            content_type TEXT NOT NULL,
+            checksum TEXT NOT NULL,
+            created_at TEXT NOT NULL
+        );
+        `,
+	},
+	{
+		Version: 24,
+		Name:    "index_task_attachments_task_created",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_attachments_task_created ON task_attachments (task_id, created_at);`,
+	},
+	{
+		Version: 25,
+		Name:    "index_task_attachments_user",
+		Up:      `CREATE INDEX IF NOT EXISTS idx_task_attachments_user ON task_attachments (user_id);`,
+	},
+	{
+		Version: 26,
+		Name:    "unique_tasks_series_occurrence",
+		Up:      `CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_series_occurrence ON tasks (series_id, occurs_at);`,
+	},
+	{
+		Version: 27,
+		Name:    "drop_index_tasks_series",
+		Up:      `DROP INDEX IF EXISTS idx_tasks_series;`,
+	},
+}
+
+func RunSQLiteMigrations(db *sql.DB) {
+	err := applyMigrations(db, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TEXT NOT NULL DEFAULT (datetime('now'))
+        );
+        `, sqliteMigrations)
+	if err != nil {
+		slog.Error("migration error", "error", err)
+	}
+}
@@ -0,0 +1,21 @@
+package database
+
+import (
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// traceOptions makes every SQL statement a span under the caller's span.
+// Row iteration and connection housekeeping are left out to keep traces
+// readable.
+func traceOptions(system attribute.KeyValue) []otelsql.Option {
+	return []otelsql.Option{
+		otelsql.WithAttributes(system),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+		}),
+	}
+}